@@ -14,8 +14,12 @@ import (
 	"yield/apps/yield/internal/config"
 	crawler2 "yield/apps/yield/internal/crawler"
 	"yield/apps/yield/internal/event_publisher"
+	"yield/apps/yield/internal/order_stream"
+	"yield/apps/yield/internal/rate_snapshotter"
 	"yield/apps/yield/internal/repository"
+	"yield/apps/yield/internal/transaction_tracker"
 	"yield/apps/yield/internal/transfer_materializer"
+	"yield/apps/yield/internal/webhook"
 )
 
 // Main function example
@@ -55,9 +59,28 @@ func main() {
 		logger.Fatal("Failed to initialize database", zap.Error(err))
 	}
 
+	// Run a one-off subcommand instead of the application if one was given
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "rebuild-orders":
+			runRebuildOrders(os.Args[2:], cfg, db, logger)
+			return
+		default:
+			logger.Fatal("Unknown subcommand", zap.String("subcommand", os.Args[1]))
+		}
+	}
+
 	crawlerRepository := repository.NewCrawlerRepository(db, logger)
 	orderRepository := repository.NewOrderRepository(db, logger)
 	monitoredAddressRepository := repository.NewMonitoredAddressRepository(db, logger)
+	orderUpdateRepository := repository.NewOrderUpdateRepository(db, logger)
+	webhookRepository := repository.NewWebhookRepository(db, logger)
+	transactionRepository := repository.NewTransactionRepository(db, logger)
+	rateSnapshotRepository := repository.NewRateSnapshotRepository(db, logger)
+	activityRepository := repository.NewActivityRepository(db, logger)
+
+	// Order updates from the materializer are fanned out to API stream clients in this process
+	orderBroker := order_stream.NewBroker(orderUpdateRepository, logger)
 
 	// Create event publisher
 	eventPublisher, err := event_publisher.NewEventPublisher(cfg.KafkaBroker, cfg.KafkaTopic, logger, crawlerRepository)
@@ -70,7 +93,7 @@ func main() {
 	go eventPublisher.StartPublishing()
 
 	// Create transfer materializer
-	materializer, err := transfer_materializer.NewTransferMaterializer(cfg.KafkaBroker, cfg.KafkaTopic, logger, orderRepository)
+	materializer, err := transfer_materializer.NewTransferMaterializer(cfg.KafkaBroker, cfg.KafkaTopic, logger, orderRepository, orderBroker)
 	if err != nil {
 		logger.Fatal("Failed to create transfer materializer", zap.Error(err))
 	}
@@ -83,8 +106,37 @@ func main() {
 		}
 	}()
 
+	// Create webhook dispatcher
+	webhookDispatcher, err := webhook.NewDispatcher(cfg.KafkaBroker, cfg.KafkaTopic, logger, webhookRepository)
+	if err != nil {
+		logger.Fatal("Failed to create webhook dispatcher", zap.Error(err))
+	}
+	defer webhookDispatcher.Close()
+
+	// Start webhook dispatcher and delivery worker in background
+	go func() {
+		if err := webhookDispatcher.Start(); err != nil {
+			logger.Fatal("Webhook dispatcher failed", zap.Error(err))
+		}
+	}()
+	go webhook.NewDeliveryWorker(logger, webhookRepository).Start()
+
+	// Create and start the tracker of relayed transactions
+	transactionTracker, err := transaction_tracker.NewTracker(cfg.RpcURL, logger, transactionRepository)
+	if err != nil {
+		logger.Fatal("Failed to create transaction tracker", zap.Error(err))
+	}
+	go transactionTracker.Start()
+
+	// Create and start the recorder of vault rate snapshots used for APYs
+	rateSnapshotter, err := rate_snapshotter.NewSnapshotter(cfg.RpcURL, logger, rateSnapshotRepository)
+	if err != nil {
+		logger.Fatal("Failed to create rate snapshotter", zap.Error(err))
+	}
+	go rateSnapshotter.Start()
+
 	// Create and start API server
-	apiServer, err := api.NewServer(cfg.APIPort, orderRepository, monitoredAddressRepository, cfg.RpcURL, logger)
+	apiServer, err := api.NewServer(cfg.APIPort, orderRepository, monitoredAddressRepository, orderBroker, webhookRepository, transactionRepository, rateSnapshotRepository, activityRepository, cfg.RpcURL, cfg.Fees, cfg.Cache, cfg.Vault, logger)
 	if err != nil {
 		logger.Fatal("Failed to create API server", zap.Error(err))
 	}
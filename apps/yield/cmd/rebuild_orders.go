@@ -0,0 +1,47 @@
+package main
+
+import (
+	"database/sql"
+	"flag"
+
+	"go.uber.org/zap"
+	"yield/apps/yield/internal/config"
+	"yield/apps/yield/internal/order_rebuilder"
+	"yield/apps/yield/internal/repository"
+)
+
+// runRebuildOrders implements the rebuild-orders subcommand. It replays source events through the
+// transfer materializer into a shadow table and atomically swaps it in place of the orders table.
+// The materializer of the running application should be stopped while a rebuild is in progress,
+// otherwise orders written to the live table during the replay are lost at swap time.
+func runRebuildOrders(args []string, cfg *config.Config, db *sql.DB, logger *zap.Logger) {
+	flags := flag.NewFlagSet("rebuild-orders", flag.ExitOnError)
+	source := flags.String("source", order_rebuilder.SourceOutbox, "event source to replay: outbox or kafka")
+	fromOffset := flags.Int64("from-offset", 0, "first Kafka offset to replay on every partition (kafka source only)")
+	toOffset := flags.Int64("to-offset", -1, "Kafka offset to stop at on every partition, exclusive; -1 for the end of the topic (kafka source only)")
+	dryRun := flags.Bool("dry-run", false, "rebuild into the shadow table without swapping it in")
+	if err := flags.Parse(args); err != nil {
+		logger.Fatal("Failed to parse rebuild-orders flags", zap.Error(err))
+	}
+
+	rebuilder := order_rebuilder.NewOrderRebuilder(db, cfg.KafkaBroker, cfg.KafkaTopic, logger,
+		repository.NewCrawlerRepository(db, logger),
+		repository.NewOrderProjectionRepository(db, logger))
+
+	logger.Info("Rebuilding orders projection",
+		zap.String("source", *source),
+		zap.Int64("from_offset", *fromOffset),
+		zap.Int64("to_offset", *toOffset),
+		zap.Bool("dry_run", *dryRun))
+
+	if err := rebuilder.Rebuild(order_rebuilder.Options{
+		Source:     *source,
+		FromOffset: *fromOffset,
+		ToOffset:   *toOffset,
+		DryRun:     *dryRun,
+	}); err != nil {
+		logger.Fatal("Failed to rebuild orders projection", zap.Error(err))
+	}
+
+	logger.Info("Orders projection rebuild complete")
+}
@@ -0,0 +1,84 @@
+package amount
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Errors returned by Parse. Callers can report them to users as-is.
+var (
+	ErrInvalidFormat = errors.New("amount must be a plain decimal number such as 0.001")
+	ErrNotPositive   = errors.New("amount must be greater than zero")
+	ErrTooPrecise    = errors.New("amount has more decimal places than the asset supports")
+)
+
+// Parse converts a decimal string into the token's smallest units without rounding. It accepts digits
+// with an optional fractional part ("1", "0.29", "1.50000000") and rejects signs, exponents,
+// whitespace, zero, and more fractional digits than the token has decimals.
+func Parse(value string, decimals int) (*big.Int, error) {
+	if strings.HasPrefix(value, "-") {
+		return nil, ErrNotPositive
+	}
+
+	whole, fraction, hasFraction := strings.Cut(value, ".")
+	if !isDigits(whole) || (hasFraction && !isDigits(fraction)) {
+		return nil, ErrInvalidFormat
+	}
+
+	if len(fraction) > decimals {
+		// Trailing zeros carry no value, so "1.000000000" is still exact for 8 decimals
+		if strings.TrimRight(fraction[decimals:], "0") != "" {
+			return nil, fmt.Errorf("%w: at most %d", ErrTooPrecise, decimals)
+		}
+		fraction = fraction[:decimals]
+	}
+
+	units, ok := new(big.Int).SetString(whole+fraction+strings.Repeat("0", decimals-len(fraction)), 10)
+	if !ok {
+		return nil, ErrInvalidFormat
+	}
+
+	if units.Sign() == 0 {
+		return nil, ErrNotPositive
+	}
+
+	return units, nil
+}
+
+// Format converts an amount in the token's smallest units to a decimal string, without trailing
+// fractional zeros. Format and Parse round-trip for every positive amount.
+func Format(units *big.Int, decimals int) string {
+	sign := ""
+	if units.Sign() < 0 {
+		sign = "-"
+	}
+
+	divisor := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	wholePart, remainder := new(big.Int).QuoRem(new(big.Int).Abs(units), divisor, new(big.Int))
+
+	if remainder.Sign() == 0 {
+		return sign + wholePart.String()
+	}
+
+	// Pad remainder with leading zeros to match decimal places, then drop trailing zeros
+	remainderStr := remainder.String()
+	remainderStr = strings.Repeat("0", decimals-len(remainderStr)) + remainderStr
+	remainderStr = strings.TrimRight(remainderStr, "0")
+
+	return sign + wholePart.String() + "." + remainderStr
+}
+
+// isDigits reports whether s is a non-empty string of ASCII digits
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,156 @@
+package amount_test
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"testing/quick"
+
+	"yield/apps/yield/internal/amount"
+)
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		value    string
+		decimals int
+		expected string
+	}{
+		{"1", 8, "100000000"},
+		{"0.29", 8, "29000000"},
+		{"0.00000001", 8, "1"},
+		{"21000000.12345678", 8, "2100000012345678"},
+		{"1.000000000", 8, "100000000"}, // Trailing zeros beyond the decimals lose nothing
+		{"007.5", 8, "750000000"},
+		{"0.1", 18, "100000000000000000"},
+		{"3", 0, "3"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			units, err := amount.Parse(test.value, test.decimals)
+			if err != nil {
+				t.Fatalf("Expected %s to parse, got %v", test.value, err)
+			}
+			if units.String() != test.expected {
+				t.Errorf("Expected %s, got %s", test.expected, units)
+			}
+		})
+	}
+}
+
+func TestParseAmountRejects(t *testing.T) {
+	tests := []struct {
+		value    string
+		decimals int
+		expected error
+	}{
+		{"", 8, amount.ErrInvalidFormat},
+		{"abc", 8, amount.ErrInvalidFormat},
+		{"1e-3", 8, amount.ErrInvalidFormat},
+		{"1E3", 8, amount.ErrInvalidFormat},
+		{"+1", 8, amount.ErrInvalidFormat},
+		{" 1", 8, amount.ErrInvalidFormat},
+		{"1.", 8, amount.ErrInvalidFormat},
+		{".5", 8, amount.ErrInvalidFormat},
+		{"1.2.3", 8, amount.ErrInvalidFormat},
+		{"1,5", 8, amount.ErrInvalidFormat},
+		{"0x10", 8, amount.ErrInvalidFormat},
+		{"Inf", 8, amount.ErrInvalidFormat},
+		{"NaN", 8, amount.ErrInvalidFormat},
+		{"-1", 8, amount.ErrNotPositive},
+		{"-0.001", 8, amount.ErrNotPositive},
+		{"0", 8, amount.ErrNotPositive},
+		{"0.00000000", 8, amount.ErrNotPositive},
+		{"0.000000001", 8, amount.ErrTooPrecise},
+		{"1.123456789", 8, amount.ErrTooPrecise},
+		{"0.5", 0, amount.ErrTooPrecise},
+	}
+
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			_, err := amount.Parse(test.value, test.decimals)
+			if !errors.Is(err, test.expected) {
+				t.Errorf("Expected %v for %q, got %v", test.expected, test.value, err)
+			}
+		})
+	}
+}
+
+func TestAmountRoundTrip(t *testing.T) {
+	decimalsList := []int{0, 6, 8, 18}
+
+	// Formatting any positive amount and parsing it back gives the same units
+	unitsRoundTrip := func(raw []byte) bool {
+		units := new(big.Int).SetBytes(raw)
+		if units.Sign() == 0 {
+			units.SetInt64(1)
+		}
+		for _, decimals := range decimalsList {
+			parsed, err := amount.Parse(amount.Format(units, decimals), decimals)
+			if err != nil || parsed.Cmp(units) != 0 {
+				t.Logf("%s with %d decimals became %v (%v)", units, decimals, parsed, err)
+				return false
+			}
+		}
+		return true
+	}
+	if err := quick.Check(unitsRoundTrip, &quick.Config{MaxCount: 2000}); err != nil {
+		t.Error(err)
+	}
+
+	// Parsing a canonical decimal string and formatting it back gives the same string
+	stringRoundTrip := func(whole uint64, fraction uint32, fractionDigits uint8) bool {
+		digits := int(fractionDigits % 9)
+		value := new(big.Int).SetUint64(whole).String()
+		if digits > 0 {
+			fractionStr := new(big.Int).SetUint64(uint64(fraction)).String()
+			if len(fractionStr) > digits {
+				fractionStr = fractionStr[:digits]
+			}
+			fractionStr = strings.TrimRight(strings.Repeat("0", digits-len(fractionStr))+fractionStr, "0")
+			if fractionStr != "" {
+				value += "." + fractionStr
+			}
+		}
+		if value == "0" {
+			return true
+		}
+
+		parsed, err := amount.Parse(value, 8)
+		if err != nil {
+			t.Logf("%s failed to parse: %v", value, err)
+			return false
+		}
+		if formatted := amount.Format(parsed, 8); formatted != value {
+			t.Logf("%s formatted back as %s", value, formatted)
+			return false
+		}
+		return true
+	}
+	if err := quick.Check(stringRoundTrip, &quick.Config{MaxCount: 2000}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFormatAmount(t *testing.T) {
+	tests := []struct {
+		units    int64
+		decimals int
+		expected string
+	}{
+		{0, 8, "0"},
+		{1, 8, "0.00000001"},
+		{29000000, 8, "0.29"},
+		{100000000, 8, "1"},
+		{2100000012345678, 8, "21000000.12345678"},
+		{-150000000, 8, "-1.5"},
+		{42, 0, "42"},
+	}
+
+	for _, test := range tests {
+		if formatted := amount.Format(big.NewInt(test.units), test.decimals); formatted != test.expected {
+			t.Errorf("Expected %d with %d decimals to format as %s, got %s", test.units, test.decimals, test.expected, formatted)
+		}
+	}
+}
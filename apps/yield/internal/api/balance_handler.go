@@ -3,10 +3,13 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math/big"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi"
@@ -14,10 +17,16 @@ import (
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/gorilla/mux"
 	"go.uber.org/zap"
+	"yield/apps/yield/internal/amount"
 	"yield/apps/yield/internal/assets"
+	"yield/apps/yield/internal/config"
+	"yield/apps/yield/internal/model"
+	"yield/apps/yield/internal/position"
+	"yield/apps/yield/internal/pricing"
+	"yield/apps/yield/internal/repository"
 )
 
-// ERC20 ABI for balanceOf function
+// ERC20 ABI for balanceOf, allowance, approve and decimals functions
 const ERC20ABI = `[
 	{
 		"constant": true,
@@ -26,6 +35,20 @@ const ERC20ABI = `[
 		"outputs": [{"name": "balance", "type": "uint256"}],
 		"type": "function"
 	},
+	{
+		"constant": false,
+		"inputs": [{"name": "_spender", "type": "address"}, {"name": "_value", "type": "uint256"}],
+		"name": "approve",
+		"outputs": [{"name": "", "type": "bool"}],
+		"type": "function"
+	},
+	{
+		"constant": true,
+		"inputs": [{"name": "_owner", "type": "address"}, {"name": "_spender", "type": "address"}],
+		"name": "allowance",
+		"outputs": [{"name": "", "type": "uint256"}],
+		"type": "function"
+	},
 	{
 		"constant": true,
 		"inputs": [],
@@ -35,6 +58,9 @@ const ERC20ABI = `[
 	}
 ]`
 
+// Most wallets POST /api/balances reads at once. Each wallet adds two calls per asset to the batch.
+const MaxBatchBalanceWallets = 50
+
 // Token configuration
 type TokenConfig struct {
 	Symbol   string
@@ -42,16 +68,28 @@ type TokenConfig struct {
 	Decimals int
 }
 
+// The accountant's base asset. Shares received by transfer are costed in it.
+const PositionBaseAsset = "LBTC"
+
 // BalanceHandler handles balance-related API endpoints
 type BalanceHandler struct {
-	client        *ethclient.Client
-	logger        *zap.Logger
-	erc20ABI      abi.ABI
-	assetRegistry *assets.AssetRegistry
+	client                 *ethclient.Client
+	logger                 *zap.Logger
+	erc20ABI               abi.ABI
+	accountantABI          abi.ABI
+	assetRegistry          *assets.AssetRegistry
+	orderRepository        *repository.OrderRepository
+	rateSnapshotRepository *repository.RateSnapshotRepository
+	multicaller            *Multicaller
+	oracle                 *pricing.Oracle
+	blockResolver          *BlockResolver
+	cache                  *ResponseCache
+	cacheConfig            config.CacheConfig
 }
 
-// NewBalanceHandler creates a new BalanceHandler
-func NewBalanceHandler(rpcURL string, logger *zap.Logger) (*BalanceHandler, error) {
+// NewBalanceHandler creates a new BalanceHandler. Positions are computed from the orders projection, with
+// transfers in costed from the rate snapshots. Balance responses are cached as configured by cacheConfig.
+func NewBalanceHandler(rpcURL string, orderRepository *repository.OrderRepository, rateSnapshotRepository *repository.RateSnapshotRepository, cacheConfig config.CacheConfig, logger *zap.Logger) (*BalanceHandler, error) {
 	client, err := ethclient.Dial(rpcURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to Ethereum client: %w", err)
@@ -62,11 +100,34 @@ func NewBalanceHandler(rpcURL string, logger *zap.Logger) (*BalanceHandler, erro
 		return nil, fmt.Errorf("failed to parse ERC20 ABI: %w", err)
 	}
 
+	accountantABI, err := abi.JSON(strings.NewReader(AccountantABI))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse accountant ABI: %w", err)
+	}
+
+	multicaller, err := NewMulticaller(client)
+	if err != nil {
+		return nil, err
+	}
+
+	oracle, err := pricing.NewOracle(client, assets.GlobalRegistry)
+	if err != nil {
+		return nil, err
+	}
+
 	return &BalanceHandler{
-		client:        client,
-		logger:        logger,
-		erc20ABI:      parsedABI,
-		assetRegistry: assets.GlobalRegistry,
+		client:                 client,
+		logger:                 logger,
+		erc20ABI:               parsedABI,
+		accountantABI:          accountantABI,
+		assetRegistry:          assets.GlobalRegistry,
+		orderRepository:        orderRepository,
+		rateSnapshotRepository: rateSnapshotRepository,
+		multicaller:            multicaller,
+		oracle:                 oracle,
+		blockResolver:          NewBlockResolver(client, cacheConfig.HeadTTL),
+		cache:                  NewResponseCache(),
+		cacheConfig:            cacheConfig,
 	}, nil
 }
 
@@ -86,47 +147,478 @@ func (h *BalanceHandler) GetBalance(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	address := common.HexToAddress(walletAddress)
-	balances := make(map[string]TokenBalance)
+	query := r.URL.Query()
+	if query.Get("block") != "" && query.Get("timestamp") != "" {
+		h.writeErrorResponse(w, http.StatusBadRequest, "invalid_block_params", "Only one of block and timestamp can be given")
+		return
+	}
+
+	ctx := r.Context()
+	head, err := h.blockResolver.Head(ctx)
+	if err != nil {
+		h.logger.Error("Failed to get block number", zap.Error(err))
+		h.writeErrorResponse(w, http.StatusInternalServerError, "fetch_error", "Failed to fetch balances")
+		return
+	}
+
+	blockNumber := head
+	historical := false
 
-	// Get balance for each supported token
-	for symbol, asset := range h.assetRegistry.GetAll() {
-		balance, err := h.getTokenBalance(address, asset)
+	if value := query.Get("block"); value != "" {
+		requested, err := strconv.ParseUint(value, 10, 64)
 		if err != nil {
-			h.logger.Error("Failed to get token balance",
-				zap.String("token", asset.Symbol),
-				zap.String("address", walletAddress),
-				zap.Error(err))
-			// Continue with other tokens instead of failing completely
-			balances[symbol] = TokenBalance{
-				Balance:  "0",
-				Symbol:   asset.Symbol,
-				Address:  asset.Address.Hex(),
-				Decimals: asset.Decimals,
-			}
-			continue
+			h.writeErrorResponse(w, http.StatusBadRequest, "invalid_block", "Block must be a block number")
+			return
+		}
+		if requested > head {
+			h.writeErrorResponse(w, http.StatusBadRequest, "invalid_block", fmt.Sprintf("Block must not be beyond the chain head %d", head))
+			return
+		}
+		blockNumber, historical = requested, true
+	}
+
+	if value := query.Get("timestamp"); value != "" {
+		timestamp, err := parseDateParam(value)
+		if err != nil {
+			h.writeErrorResponse(w, http.StatusBadRequest, "invalid_timestamp", "Timestamp must be an RFC 3339 timestamp or a YYYY-MM-DD date")
+			return
+		}
+		if timestamp.After(time.Now()) {
+			h.writeErrorResponse(w, http.StatusBadRequest, "invalid_timestamp", "Timestamp must not be in the future")
+			return
 		}
 
-		balances[symbol] = TokenBalance{
-			Balance:  balance,
-			Symbol:   asset.Symbol,
-			Address:  asset.Address.Hex(),
-			Decimals: asset.Decimals,
+		blockNumber, err = h.blockResolver.BlockAtTimestamp(ctx, timestamp, head)
+		if errors.Is(err, ErrTimestampBeforeGenesis) {
+			h.writeErrorResponse(w, http.StatusBadRequest, "invalid_timestamp", "Timestamp must not be before the genesis block")
+			return
+		}
+		if err != nil {
+			h.logger.Error("Failed to resolve timestamp to block", zap.Time("timestamp", timestamp), zap.Error(err))
+			h.writeErrorResponse(w, http.StatusInternalServerError, "fetch_error", "Failed to resolve timestamp to a block")
+			return
 		}
+		historical = true
+	}
+
+	ttl := h.cacheConfig.BalanceTTL
+	if historical {
+		ttl = h.cacheConfig.HistoricalTTL
 	}
 
-	response := BalanceResponse{
-		WalletAddress: walletAddress,
-		Balances:      balances,
+	key := fmt.Sprintf("balance:%s@%d", walletAddress, blockNumber)
+	entry, hit, err := h.cache.Get(key, blockNumber, ttl, func() (interface{}, error) {
+		// Other requests may be waiting on this read, so it outlives the request that started it
+		ctx := context.WithoutCancel(ctx)
+
+		responses, err := h.readBalances(ctx, []string{walletAddress}, blockNumber)
+		if err != nil {
+			return nil, err
+		}
+
+		response := responses[0]
+		if historical {
+			blockTime, err := h.blockResolver.HeaderTime(ctx, blockNumber, head)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get block timestamp: %w", err)
+			}
+			timestamp := time.Unix(int64(blockTime), 0).UTC()
+			response.BlockTimestamp = &timestamp
+		}
+		return response, nil
+	})
+	if errors.Is(err, ErrHistoricalStateUnavailable) {
+		h.logger.Warn("Historical state unavailable", zap.Uint64("block_number", blockNumber), zap.Error(err))
+		h.writeErrorResponse(w, http.StatusServiceUnavailable, "historical_state_unavailable",
+			fmt.Sprintf("The node no longer holds the state of block %d; historical balances need an archive node", blockNumber))
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to read wallet balances", zap.String("wallet_address", walletAddress), zap.Error(err))
+		h.writeErrorResponse(w, http.StatusInternalServerError, "fetch_error", "Failed to fetch balances")
+		return
 	}
 
 	h.logger.Info("Retrieved wallet balances",
 		zap.String("wallet_address", walletAddress),
-		zap.Int("token_count", len(balances)))
+		zap.Uint64("block_number", blockNumber),
+		zap.Bool("historical", historical),
+		zap.Bool("cached", hit))
+
+	if err := writeCachedResponse(w, r, entry, hit); err != nil {
+		h.logger.Error("Failed to write balance response", zap.Error(err))
+	}
+}
+
+// GetBalances handles POST /api/balances
+func (h *BalanceHandler) GetBalances(w http.ResponseWriter, r *http.Request) {
+	var req BatchBalanceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "invalid_request_body", "Invalid JSON in request body")
+		return
+	}
+
+	if len(req.WalletAddresses) == 0 {
+		h.writeErrorResponse(w, http.StatusBadRequest, "missing_wallet_addresses", "At least one wallet address is required")
+		return
+	}
+
+	if len(req.WalletAddresses) > MaxBatchBalanceWallets {
+		h.writeErrorResponse(w, http.StatusBadRequest, "too_many_wallets", fmt.Sprintf("At most %d wallet addresses can be read at once", MaxBatchBalanceWallets))
+		return
+	}
+
+	for _, walletAddress := range req.WalletAddresses {
+		if !common.IsHexAddress(walletAddress) {
+			h.writeErrorResponse(w, http.StatusBadRequest, "invalid_wallet_address", fmt.Sprintf("Invalid Ethereum address format: %s", walletAddress))
+			return
+		}
+	}
+
+	blockNumber, err := h.blockResolver.Head(r.Context())
+	if err != nil {
+		h.logger.Error("Failed to get block number", zap.Error(err))
+		h.writeErrorResponse(w, http.StatusInternalServerError, "fetch_error", "Failed to fetch balances")
+		return
+	}
+
+	responses, err := h.readBalances(r.Context(), req.WalletAddresses, blockNumber)
+	if err != nil {
+		h.logger.Error("Failed to read wallet balances", zap.Int("wallets", len(req.WalletAddresses)), zap.Error(err))
+		h.writeErrorResponse(w, http.StatusInternalServerError, "fetch_error", "Failed to fetch balances")
+		return
+	}
+
+	h.logger.Info("Retrieved batch wallet balances",
+		zap.Int("wallets", len(responses)),
+		zap.Uint64("block_number", responses[0].BlockNumber))
+
+	h.writeJSONResponse(w, http.StatusOK, BatchBalanceResponse{
+		BlockNumber: responses[0].BlockNumber,
+		Balances:    responses,
+	})
+}
+
+// readBalances reads every asset's balance and allowance for each wallet, along with the accountant
+// rate, in one Multicall3 call pinned to blockNumber. A read that reverts is reported as zero, and the
+// LBTCv value is left out if the rate cannot be read.
+func (h *BalanceHandler) readBalances(ctx context.Context, walletAddresses []string, blockNumber uint64) ([]BalanceResponse, error) {
+	rateData, err := h.accountantABI.Pack("getRate")
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack getRate call: %w", err)
+	}
+
+	assetList := h.assetRegistry.GetAllAsArray()
+	calls := []MulticallCall{{Target: common.HexToAddress(assets.AccountantContractAddress), AllowFailure: true, CallData: rateData}}
+	for _, walletAddress := range walletAddresses {
+		owner := common.HexToAddress(walletAddress)
+		for _, asset := range assetList {
+			balanceData, err := h.erc20ABI.Pack("balanceOf", owner)
+			if err != nil {
+				return nil, fmt.Errorf("failed to pack balanceOf call: %w", err)
+			}
+			allowanceData, err := h.erc20ABI.Pack("allowance", owner, ApprovalSpender(asset))
+			if err != nil {
+				return nil, fmt.Errorf("failed to pack allowance call: %w", err)
+			}
+			calls = append(calls,
+				MulticallCall{Target: asset.Address, AllowFailure: true, CallData: balanceData},
+				MulticallCall{Target: asset.Address, AllowFailure: true, CallData: allowanceData})
+		}
+	}
+
+	results, err := h.multicaller.Aggregate3(ctx, calls, new(big.Int).SetUint64(blockNumber))
+	if err != nil && isMissingStateError(err) {
+		return nil, fmt.Errorf("%w: %v", ErrHistoricalStateUnavailable, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rate := unpackUint256(h.accountantABI, "getRate", results[0])
+	if rate == nil {
+		h.logger.Warn("Failed to read accountant rate", zap.Uint64("block_number", blockNumber))
+	}
+
+	responses := make([]BalanceResponse, len(walletAddresses))
+	next := 1
+	for i, walletAddress := range walletAddresses {
+		balances := make(map[string]TokenBalance, len(assetList))
+		for _, asset := range assetList {
+			balance := unpackUint256(h.erc20ABI, "balanceOf", results[next])
+			allowance := unpackUint256(h.erc20ABI, "allowance", results[next+1])
+			next += 2
+
+			// Continue with other tokens instead of failing completely
+			if balance == nil {
+				h.logger.Error("Failed to get token balance", zap.String("token", asset.Symbol), zap.String("address", walletAddress))
+				balance = new(big.Int)
+			}
+			if allowance == nil {
+				h.logger.Error("Failed to get token allowance", zap.String("token", asset.Symbol), zap.String("address", walletAddress))
+				allowance = new(big.Int)
+			}
+
+			tokenBalance := TokenBalance{
+				Balance:   amount.Format(balance, asset.Decimals),
+				Symbol:    asset.Symbol,
+				Address:   asset.Address.Hex(),
+				Decimals:  asset.Decimals,
+				Allowance: amount.Format(allowance, asset.Decimals),
+				Spender:   ApprovalSpender(asset).Hex(),
+			}
+			if asset.Address == assets.LBTCVAddress && rate != nil {
+				value := new(big.Int).Mul(balance, rate)
+				tokenBalance.Value = amount.Format(value.Div(value, oneShare()), asset.Decimals)
+			}
+			balances[asset.Symbol] = tokenBalance
+		}
+
+		responses[i] = BalanceResponse{
+			WalletAddress: walletAddress,
+			BlockNumber:   blockNumber,
+			Balances:      balances,
+		}
+	}
+
+	return responses, nil
+}
+
+// GetPosition handles GET /api/wallets/{address}/position
+func (h *BalanceHandler) GetPosition(w http.ResponseWriter, r *http.Request) {
+	walletAddress := mux.Vars(r)["address"]
+
+	// Validate Ethereum address format
+	if !common.IsHexAddress(walletAddress) {
+		h.writeErrorResponse(w, http.StatusBadRequest, "invalid_wallet_address", "Invalid Ethereum address format")
+		return
+	}
+
+	// Orders are stored with checksummed addresses
+	address := common.HexToAddress(walletAddress)
+	orders, err := h.orderRepository.ListOrdersByWalletInChainOrder(address.Hex())
+	if err != nil {
+		h.logger.Error("Failed to list orders", zap.String("wallet_address", walletAddress), zap.Error(err))
+		h.writeErrorResponse(w, http.StatusInternalServerError, "database_error", "Failed to fetch orders")
+		return
+	}
+
+	// Rates are read once per asset, in the asset's smallest units per share
+	rates := make(map[string]*big.Int)
+	getRate := func(symbol string) (*big.Int, error) {
+		if rate, exists := rates[symbol]; exists {
+			return rate, nil
+		}
+		asset, exists := h.assetRegistry.GetBySymbol(symbol)
+		if !exists {
+			return nil, fmt.Errorf("asset %s is not supported", symbol)
+		}
+		rate, err := h.getRateInQuote(r.Context(), asset.Address)
+		if err != nil {
+			return nil, err
+		}
+		rates[symbol] = rate
+		return rate, nil
+	}
+
+	ledger, unaccounted, err := h.buildLedger(orders, getRate)
+	if err != nil {
+		h.logger.Error("Failed to build position ledger", zap.String("wallet_address", walletAddress), zap.Error(err))
+		h.writeErrorResponse(w, http.StatusInternalServerError, "fetch_error", "Failed to compute position")
+		return
+	}
+
+	lbtcvAsset, _ := h.assetRegistry.GetBySymbol("LBTCv")
+	shares, err := h.getTokenBalance(address, lbtcvAsset)
+	if err != nil {
+		h.logger.Error("Failed to get token balance", zap.String("token", lbtcvAsset.Symbol), zap.String("address", walletAddress), zap.Error(err))
+		h.writeErrorResponse(w, http.StatusInternalServerError, "fetch_error", "Failed to fetch share balance")
+		return
+	}
+
+	response := PositionResponse{
+		WalletAddress:     walletAddress,
+		Shares:            shares,
+		LedgerShares:      formatRat(ledger.Shares(), lbtcvAsset.Decimals),
+		Lots:              []PositionLotResponse{},
+		UnaccountedOrders: unaccounted,
+	}
+
+	// Lots are also valued from the price feeds; without them the position is reported in tokens only
+	quote, err := h.oracle.Quote(r.Context(), nil)
+	if err != nil {
+		h.logger.Warn("Failed to read price feeds", zap.String("wallet_address", walletAddress), zap.Error(err))
+	}
+	valueBTC, valueUSD := new(big.Rat), new(big.Rat)
+	priced := quote != nil
+
+	// Totals count every lot at par, since the deposit assets are all BTC
+	value, costBasis := new(big.Rat), new(big.Rat)
+	realizedYield, unrealizedYield := new(big.Rat), new(big.Rat)
+	for _, lot := range ledger.Lots() {
+		rate, err := getRate(lot.Asset)
+		if err != nil {
+			h.logger.Error("Failed to get accountant rate", zap.String("asset", lot.Asset), zap.Error(err))
+			h.writeErrorResponse(w, http.StatusInternalServerError, "fetch_error", "Failed to fetch share price")
+			return
+		}
+
+		asset, _ := h.assetRegistry.GetBySymbol(lot.Asset)
+		unit := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(asset.Decimals)), nil))
+		lotValue := new(big.Rat).Mul(lot.Shares, new(big.Rat).Quo(new(big.Rat).SetInt(rate), unit))
+		lotUnrealized := new(big.Rat).Sub(lotValue, lot.CostBasis)
+
+		lotResponse := PositionLotResponse{
+			Asset:           lot.Asset,
+			Shares:          formatRat(lot.Shares, lbtcvAsset.Decimals),
+			CostBasis:       formatRat(lot.CostBasis, asset.Decimals),
+			SharePrice:      formatRat(new(big.Rat).Quo(new(big.Rat).SetInt(rate), unit), asset.Decimals),
+			Value:           formatRat(lotValue, asset.Decimals),
+			RealizedYield:   formatRat(lot.RealizedYield, asset.Decimals),
+			UnrealizedYield: formatRat(lotUnrealized, asset.Decimals),
+		}
+
+		// A lot whose asset cannot be priced leaves the position's totals unpriced too
+		if quote != nil {
+			lotBTC, lotUSD, err := quote.Value(lot.Asset, lotValue)
+			if err != nil {
+				h.logger.Warn("Failed to value position lot", zap.String("asset", lot.Asset), zap.Error(err))
+				priced = false
+			} else {
+				lotResponse.ValueBTC, lotResponse.ValueUSD = formatRat(lotBTC, 8), formatRat(lotUSD, 2)
+				valueBTC.Add(valueBTC, lotBTC)
+				valueUSD.Add(valueUSD, lotUSD)
+			}
+		}
+		response.Lots = append(response.Lots, lotResponse)
+
+		value.Add(value, lotValue)
+		costBasis.Add(costBasis, lot.CostBasis)
+		realizedYield.Add(realizedYield, lot.RealizedYield)
+		unrealizedYield.Add(unrealizedYield, lotUnrealized)
+	}
+
+	response.Value = formatRat(value, lbtcvAsset.Decimals)
+	response.CostBasis = formatRat(costBasis, lbtcvAsset.Decimals)
+	response.RealizedYield = formatRat(realizedYield, lbtcvAsset.Decimals)
+	response.UnrealizedYield = formatRat(unrealizedYield, lbtcvAsset.Decimals)
+	if priced {
+		pricedAt := quote.OldestUpdate()
+		response.ValueBTC, response.ValueUSD = formatRat(valueBTC, 8), formatRat(valueUSD, 2)
+		response.BTCPriceUSD = formatRat(quote.BTCPriceUSD.Value, 2)
+		response.PricedAt = &pricedAt
+	}
 
 	h.writeJSONResponse(w, http.StatusOK, response)
 }
 
+// buildLedger replays a wallet's orders, oldest first. Deposits open lots in the deposited asset at the
+// deposited amount; shares received by transfer are costed in the base asset at the last recorded rate
+// snapshot, or at the current rate when none precedes them. Completed withdrawals realize their estimated
+// amount, which the materializer sets to the want_amount_received of the fulfillment, so that a partly
+// filled request realizes what was paid for the shares it spent. It also returns how many orders were
+// skipped for lack of a share amount.
+func (h *BalanceHandler) buildLedger(orders []model.Order, getRate func(symbol string) (*big.Int, error)) (*position.Ledger, int, error) {
+	ledger := position.NewLedger()
+	unaccounted := 0
+
+	for _, order := range orders {
+		if order.ShareAmount == nil {
+			unaccounted++
+			continue
+		}
+
+		shares, ok := new(big.Rat).SetString(*order.ShareAmount)
+		if !ok {
+			return nil, 0, fmt.Errorf("invalid share amount %s for order %s", *order.ShareAmount, order.OrderID)
+		}
+
+		switch order.TransferType {
+		case "deposit":
+			cost, ok := new(big.Rat).SetString(order.Amount)
+			if !ok {
+				return nil, 0, fmt.Errorf("invalid amount %s for order %s", order.Amount, order.OrderID)
+			}
+			ledger.Add(order.FromAssetName, shares, cost)
+
+		case "transfer_in":
+			rate, err := h.transferRate(order, getRate)
+			if err != nil {
+				return nil, 0, err
+			}
+			ledger.Add(PositionBaseAsset, shares, new(big.Rat).Mul(shares, rate))
+
+		case "transfer_out":
+			ledger.Remove(shares, nil)
+
+		case "withdrawal":
+			// Shares stay in the wallet until the request is fulfilled
+			if order.Status != "completed" {
+				continue
+			}
+			received := order.Amount
+			if order.EstimatedAmount != nil {
+				received = *order.EstimatedAmount
+			}
+			proceeds, ok := new(big.Rat).SetString(received)
+			if !ok {
+				return nil, 0, fmt.Errorf("invalid estimated amount %s for order %s", received, order.OrderID)
+			}
+			ledger.Remove(shares, proceeds)
+		}
+	}
+
+	return ledger, unaccounted, nil
+}
+
+// transferRate returns the price of one share in whole units of the base asset when the order was made
+func (h *BalanceHandler) transferRate(order model.Order, getRate func(symbol string) (*big.Int, error)) (*big.Rat, error) {
+	snapshot, err := h.rateSnapshotRepository.GetRateSnapshotAtOrBefore(order.TxDate)
+	if err != nil {
+		return nil, err
+	}
+
+	var rate *big.Int
+	if snapshot != nil {
+		var ok bool
+		if rate, ok = new(big.Int).SetString(snapshot.Rate, 10); !ok {
+			return nil, fmt.Errorf("invalid rate %s in snapshot at block %d", snapshot.Rate, snapshot.BlockNumber)
+		}
+	} else if rate, err = getRate(PositionBaseAsset); err != nil {
+		return nil, err
+	}
+
+	return new(big.Rat).SetFrac(rate, oneShare()), nil
+}
+
+// getRateInQuote reads the price of one vault share in the quote asset's smallest units
+func (h *BalanceHandler) getRateInQuote(ctx context.Context, quote common.Address) (*big.Int, error) {
+	data, err := h.accountantABI.Pack("getRateInQuoteSafe", quote)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack getRateInQuoteSafe call: %w", err)
+	}
+
+	accountant := common.HexToAddress(assets.AccountantContractAddress)
+	result, err := h.client.CallContract(ctx, ethereum.CallMsg{To: &accountant, Data: data}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call getRateInQuoteSafe: %w", err)
+	}
+
+	var rate *big.Int
+	if err := h.accountantABI.UnpackIntoInterface(&rate, "getRateInQuoteSafe", result); err != nil {
+		return nil, fmt.Errorf("failed to unpack getRateInQuoteSafe result: %w", err)
+	}
+
+	return rate, nil
+}
+
+// formatRat rounds an exact amount toward zero to the given decimals and formats it like amount.Format
+func formatRat(value *big.Rat, decimals int) string {
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	units := new(big.Int).Quo(new(big.Int).Mul(value.Num(), scale), value.Denom())
+	return amount.Format(units, decimals)
+}
+
 // getTokenBalance retrieves the balance for a specific ERC20 token
 func (h *BalanceHandler) getTokenBalance(walletAddress common.Address, asset *assets.Asset) (string, error) {
 	tokenAddress := asset.Address
@@ -154,31 +646,7 @@ func (h *BalanceHandler) getTokenBalance(walletAddress common.Address, asset *as
 	}
 
 	// Convert to decimal representation
-	return h.convertToDecimalAmount(balance, asset.Decimals), nil
-}
-
-// convertToDecimalAmount converts wei amount to decimal representation
-func (h *BalanceHandler) convertToDecimalAmount(amount *big.Int, decimals int) string {
-	divisor := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
-	wholePart := new(big.Int).Div(amount, divisor)
-	remainder := new(big.Int).Mod(amount, divisor)
-
-	// Format as decimal string
-	if remainder.Cmp(big.NewInt(0)) == 0 {
-		return wholePart.String()
-	} else {
-		// Pad remainder with leading zeros to match decimal places
-		remainderStr := remainder.String()
-		for len(remainderStr) < decimals {
-			remainderStr = "0" + remainderStr
-		}
-		// Remove trailing zeros
-		remainderStr = strings.TrimRight(remainderStr, "0")
-		if remainderStr == "" {
-			return wholePart.String()
-		}
-		return wholePart.String() + "." + remainderStr
-	}
+	return amount.Format(balance, asset.Decimals), nil
 }
 
 // writeJSONResponse writes a JSON response with the specified status code
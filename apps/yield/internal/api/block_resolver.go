@@ -0,0 +1,157 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+	"golang.org/x/sync/singleflight"
+)
+
+// ErrHistoricalStateUnavailable is returned when the node has pruned the state of a past block. Reading
+// balances at old blocks needs an archive node.
+var ErrHistoricalStateUnavailable = errors.New("historical state unavailable")
+
+// ErrTimestampBeforeGenesis is returned when a timestamp precedes the first block
+var ErrTimestampBeforeGenesis = errors.New("timestamp is before the genesis block")
+
+const (
+	// Blocks at least this far below the head are not expected to reorg, so their timestamps are cached
+	headerCacheDepth = 64
+
+	// Most header timestamps kept in memory. The cache is cleared when it fills up.
+	maxCachedHeaders = 100000
+)
+
+// Error messages nodes return for calls against state they no longer hold
+var missingStateErrors = []string{
+	"missing trie node",
+	"historical state",
+	"state is not available",
+	"state not available",
+	"pruned",
+	"header not found",
+}
+
+// BlockResolver tracks the chain head and maps timestamps to block numbers. Timestamps are resolved by
+// binary search over block headers; the headers' timestamps are cached, so that later searches mostly
+// revisit cached blocks.
+type BlockResolver struct {
+	client        *ethclient.Client
+	headTTL       time.Duration
+	mu            sync.Mutex
+	timestamps    map[uint64]uint64 // Header timestamp by block number
+	head          uint64
+	headFetchedAt time.Time
+	headGroup     singleflight.Group
+}
+
+// NewBlockResolver creates a new BlockResolver. The head block number is reused for headTTL.
+func NewBlockResolver(client *ethclient.Client, headTTL time.Duration) *BlockResolver {
+	return &BlockResolver{
+		client:     client,
+		headTTL:    headTTL,
+		timestamps: make(map[uint64]uint64),
+	}
+}
+
+// Head returns the latest block number. Within headTTL of the last lookup the same number is returned,
+// and concurrent lookups share a single RPC call.
+func (b *BlockResolver) Head(ctx context.Context) (uint64, error) {
+	b.mu.Lock()
+	head, fetchedAt := b.head, b.headFetchedAt
+	b.mu.Unlock()
+	if !fetchedAt.IsZero() && time.Since(fetchedAt) < b.headTTL {
+		return head, nil
+	}
+
+	value, err, _ := b.headGroup.Do("head", func() (interface{}, error) {
+		// A caller going away must not fail the lookup for the others sharing it
+		head, err := b.client.BlockNumber(context.WithoutCancel(ctx))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get block number from blockchain: %w", err)
+		}
+
+		b.mu.Lock()
+		b.head, b.headFetchedAt = head, time.Now()
+		b.mu.Unlock()
+		return head, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return value.(uint64), nil
+}
+
+// BlockAtTimestamp returns the last block mined at or before timestamp, given the current head
+func (b *BlockResolver) BlockAtTimestamp(ctx context.Context, timestamp time.Time, head uint64) (uint64, error) {
+	target := uint64(timestamp.Unix())
+
+	genesisTime, err := b.HeaderTime(ctx, 0, head)
+	if err != nil {
+		return 0, err
+	}
+	if timestamp.Unix() < 0 || target < genesisTime {
+		return 0, ErrTimestampBeforeGenesis
+	}
+
+	// Invariant: block low is at or before the target, every block above high is after it
+	low, high := uint64(0), head
+	for low < high {
+		mid := low + (high-low+1)/2
+		midTime, err := b.HeaderTime(ctx, mid, head)
+		if err != nil {
+			return 0, err
+		}
+		if midTime <= target {
+			low = mid
+		} else {
+			high = mid - 1
+		}
+	}
+
+	return low, nil
+}
+
+// HeaderTime returns the timestamp of a block. Blocks deep enough below head are cached.
+func (b *BlockResolver) HeaderTime(ctx context.Context, blockNumber, head uint64) (uint64, error) {
+	b.mu.Lock()
+	timestamp, cached := b.timestamps[blockNumber]
+	b.mu.Unlock()
+	if cached {
+		return timestamp, nil
+	}
+
+	header, err := b.client.HeaderByNumber(ctx, new(big.Int).SetUint64(blockNumber))
+	if err != nil {
+		return 0, fmt.Errorf("failed to get header of block %d: %w", blockNumber, err)
+	}
+
+	if blockNumber+headerCacheDepth <= head {
+		b.mu.Lock()
+		if len(b.timestamps) >= maxCachedHeaders {
+			b.timestamps = make(map[uint64]uint64)
+		}
+		b.timestamps[blockNumber] = header.Time
+		b.mu.Unlock()
+	}
+
+	return header.Time, nil
+}
+
+// isMissingStateError reports whether an RPC error means the node no longer holds the requested state
+func isMissingStateError(err error) bool {
+	message := strings.ToLower(err.Error())
+	for _, missing := range missingStateErrors {
+		if strings.Contains(message, missing) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"go.uber.org/zap"
+)
+
+// DecodeHandler decodes calldata so that users can check what they are about to sign
+type DecodeHandler struct {
+	transactionBuilder *TransactionBuilder
+	logger             *zap.Logger
+}
+
+// NewDecodeHandler creates a new DecodeHandler. Calldata is decoded with the transaction builder's ABIs,
+// so every transaction the API builds can be decoded, including the calls batched in Safe proposals and
+// UserOperations.
+func NewDecodeHandler(transactionBuilder *TransactionBuilder, logger *zap.Logger) *DecodeHandler {
+	return &DecodeHandler{
+		transactionBuilder: transactionBuilder,
+		logger:             logger,
+	}
+}
+
+// DecodeCalldata handles POST /api/decode
+func (h *DecodeHandler) DecodeCalldata(w http.ResponseWriter, r *http.Request) {
+	var req DecodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "invalid_request_body", "Invalid JSON in request body")
+		return
+	}
+
+	if req.To == "" {
+		h.writeErrorResponse(w, http.StatusBadRequest, "missing_to", "Contract address is required")
+		return
+	}
+
+	if !common.IsHexAddress(req.To) {
+		h.writeErrorResponse(w, http.StatusBadRequest, "invalid_to", "Invalid Ethereum address format")
+		return
+	}
+
+	if req.Data == "" {
+		h.writeErrorResponse(w, http.StatusBadRequest, "missing_data", "Calldata is required")
+		return
+	}
+
+	data, err := hexutil.Decode(req.Data)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "invalid_data", "Calldata must be a 0x-prefixed hex string")
+		return
+	}
+
+	decoded, err := h.transactionBuilder.DecodeCalldata(common.HexToAddress(req.To), data)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrUnknownContract):
+			h.writeErrorResponse(w, http.StatusBadRequest, "unknown_contract", "Contract is not the Teller, the AtomicQueue or a supported asset")
+		case errors.Is(err, ErrUnknownFunction):
+			h.writeErrorResponse(w, http.StatusBadRequest, "unknown_function", "Function selector is not a known function of the contract")
+		case errors.Is(err, ErrInvalidCalldata):
+			h.writeErrorResponse(w, http.StatusBadRequest, "invalid_calldata", "Calldata does not match the function's arguments")
+		default:
+			h.logger.Error("Failed to decode calldata", zap.String("to", req.To), zap.Error(err))
+			h.writeErrorResponse(w, http.StatusInternalServerError, "decode_error", "Failed to decode calldata")
+		}
+		return
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, decoded)
+}
+
+// writeJSONResponse writes a JSON response with the specified status code
+func (h *DecodeHandler) writeJSONResponse(w http.ResponseWriter, statusCode int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		h.logger.Error("Failed to encode JSON response", zap.Error(err))
+	}
+}
+
+// writeErrorResponse writes an error response
+func (h *DecodeHandler) writeErrorResponse(w http.ResponseWriter, statusCode int, errorCode, message string) {
+	errorResponse := ErrorResponse{
+		Error:   errorCode,
+		Message: message,
+	}
+	h.writeJSONResponse(w, statusCode, errorResponse)
+}
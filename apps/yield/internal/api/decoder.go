@@ -0,0 +1,191 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"slices"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"yield/apps/yield/internal/assets"
+)
+
+// Errors returned by DecodeCalldata
+var (
+	ErrUnknownContract = errors.New("contract is not the Teller, the AtomicQueue or a supported asset")
+	ErrUnknownFunction = errors.New("function selector is not in the contract's ABI")
+	ErrInvalidCalldata = errors.New("calldata does not match the function's arguments")
+)
+
+// DecodeCalldata decodes a call to one of the contracts the builder creates transactions for, using the
+// same ABIs. Smart account payloads are decoded too: a Safe proposal's MultiSendCallOnly batch, and a
+// UserOperation's execute or executeBatch call data, whatever the account's address. Their inner calls
+// are decoded into Calls. Address arguments of registered assets are annotated with the asset symbol.
+func (tb *TransactionBuilder) DecodeCalldata(to common.Address, data []byte) (*DecodeResponse, error) {
+	var contract string
+	var contractABI abi.ABI
+	var functions []string // Restricts the smart account ABI to the contract's functions
+	switch to {
+	case common.HexToAddress(assets.TellerContractAddress):
+		contract, contractABI = "Teller", tb.tellerABI
+	case common.HexToAddress(assets.AtomicRequestContractAddress):
+		contract, contractABI = "AtomicQueue", tb.atomicRequestABI
+	case common.HexToAddress(MultiSendCallOnlyAddress):
+		contract, contractABI, functions = "MultiSendCallOnly", tb.smartAccountABI, []string{"multiSend"}
+	default:
+		if asset, exists := assets.GlobalRegistry.GetByAddress(to); exists {
+			contract, contractABI = asset.Symbol, tb.erc20ABI
+		} else if tb.isAccountExecution(data) {
+			contract, contractABI, functions = "SmartAccount", tb.smartAccountABI, []string{"execute", "executeBatch"}
+		} else {
+			return nil, ErrUnknownContract
+		}
+	}
+
+	if len(data) < 4 {
+		return nil, ErrUnknownFunction
+	}
+
+	method, err := contractABI.MethodById(data[:4])
+	if err != nil || (functions != nil && !slices.Contains(functions, method.Name)) {
+		return nil, ErrUnknownFunction
+	}
+
+	values, err := method.Inputs.Unpack(data[4:])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCalldata, err)
+	}
+
+	arguments := make([]DecodedArgument, len(method.Inputs))
+	for i, input := range method.Inputs {
+		arguments[i] = DecodedArgument{
+			Name:  input.Name,
+			Type:  input.Type.String(),
+			Value: formatABIValue(input.Type, reflect.ValueOf(values[i])),
+		}
+		if address, ok := values[i].(common.Address); ok {
+			if asset, exists := assets.GlobalRegistry.GetByAddress(address); exists {
+				arguments[i].Symbol = asset.Symbol
+			}
+		}
+	}
+
+	decoded := &DecodeResponse{
+		To:        to.Hex(),
+		Contract:  contract,
+		Function:  method.Name,
+		Signature: method.Sig,
+		Arguments: arguments,
+	}
+
+	switch method.Name {
+	case "multiSend":
+		decoded.Calls, err = tb.decodeMultiSend(values[0].([]byte))
+	case "execute":
+		decoded.Calls, err = tb.decodeInnerCalls([]common.Address{values[0].(common.Address)}, []*big.Int{values[1].(*big.Int)}, [][]byte{values[2].([]byte)})
+	case "executeBatch":
+		dests, amounts, funcs := values[0].([]common.Address), values[1].([]*big.Int), values[2].([][]byte)
+		if len(amounts) != len(dests) || len(funcs) != len(dests) {
+			return nil, fmt.Errorf("%w: executeBatch arrays differ in length", ErrInvalidCalldata)
+		}
+		decoded.Calls, err = tb.decodeInnerCalls(dests, amounts, funcs)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return decoded, nil
+}
+
+// isAccountExecution reports whether the calldata calls a smart account's execute or executeBatch
+func (tb *TransactionBuilder) isAccountExecution(data []byte) bool {
+	if len(data) < 4 {
+		return false
+	}
+	for _, name := range []string{"execute", "executeBatch"} {
+		if bytes.Equal(data[:4], tb.smartAccountABI.Methods[name].ID) {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeMultiSend unpacks MultiSend transactions, the reverse of packMultiSend, and decodes each call.
+// MultiSendCallOnly reverts on delegate calls, so only calls are accepted.
+func (tb *TransactionBuilder) decodeMultiSend(packed []byte) ([]DecodeResponse, error) {
+	const headerLength = 1 + common.AddressLength + 32 + 32
+
+	var dests []common.Address
+	var amounts []*big.Int
+	var funcs [][]byte
+	for len(packed) > 0 {
+		if len(packed) < headerLength {
+			return nil, fmt.Errorf("%w: truncated MultiSend transaction", ErrInvalidCalldata)
+		}
+		if packed[0] != safeOperationCall {
+			return nil, fmt.Errorf("%w: MultiSend transaction %d is not a call", ErrInvalidCalldata, len(dests))
+		}
+
+		length := new(big.Int).SetBytes(packed[53:85])
+		if !length.IsUint64() || length.Uint64() > uint64(len(packed)-headerLength) {
+			return nil, fmt.Errorf("%w: truncated MultiSend transaction", ErrInvalidCalldata)
+		}
+
+		dests = append(dests, common.BytesToAddress(packed[1:21]))
+		amounts = append(amounts, new(big.Int).SetBytes(packed[21:53]))
+		funcs = append(funcs, packed[headerLength:headerLength+int(length.Uint64())])
+		packed = packed[headerLength+int(length.Uint64()):]
+	}
+
+	return tb.decodeInnerCalls(dests, amounts, funcs)
+}
+
+// decodeInnerCalls decodes the calls a batch makes. Every call must decode, so that nothing the batch
+// does is hidden from the user.
+func (tb *TransactionBuilder) decodeInnerCalls(dests []common.Address, amounts []*big.Int, funcs [][]byte) ([]DecodeResponse, error) {
+	calls := make([]DecodeResponse, len(dests))
+	for i, dest := range dests {
+		call, err := tb.DecodeCalldata(dest, funcs[i])
+		if err != nil {
+			return nil, fmt.Errorf("call %d to %s: %w", i, dest.Hex(), err)
+		}
+		call.Value = amounts[i].String()
+		calls[i] = *call
+	}
+	return calls, nil
+}
+
+// formatABIValue converts a decoded ABI value to JSON: integers as decimal strings so that uint256
+// values keep their precision, bytes as hex, and tuples as objects keyed by component name
+func formatABIValue(typ abi.Type, value reflect.Value) interface{} {
+	switch typ.T {
+	case abi.AddressTy:
+		return value.Interface().(common.Address).Hex()
+	case abi.IntTy, abi.UintTy:
+		return fmt.Sprint(value.Interface())
+	case abi.BytesTy:
+		return hexutil.Encode(value.Bytes())
+	case abi.FixedBytesTy:
+		fixed := make([]byte, value.Len())
+		reflect.Copy(reflect.ValueOf(fixed), value)
+		return hexutil.Encode(fixed)
+	case abi.TupleTy:
+		fields := make(map[string]interface{}, len(typ.TupleElems))
+		for i, elem := range typ.TupleElems {
+			fields[typ.TupleRawNames[i]] = formatABIValue(*elem, value.Field(i))
+		}
+		return fields
+	case abi.SliceTy, abi.ArrayTy:
+		elems := make([]interface{}, value.Len())
+		for i := range elems {
+			elems[i] = formatABIValue(*typ.Elem, value.Index(i))
+		}
+		return elems
+	default:
+		return value.Interface()
+	}
+}
@@ -0,0 +1,359 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+	"yield/apps/yield/internal/amount"
+	"yield/apps/yield/internal/model"
+	"yield/apps/yield/internal/repository"
+)
+
+const (
+	ExportFormatCSV  = "csv"
+	ExportFormatJSON = "json"
+
+	// Number of events read from the outbox per query while streaming an export
+	exportPageSize = 500
+)
+
+// exportColumns is the CSV header, in the order of ExportRecord's JSON fields
+var exportColumns = []string{
+	"event_type", "tx_hash", "log_index", "block_number", "block_time",
+	"from_asset", "from_amount", "to_asset", "to_amount", "shares", "share_price", "value_btc",
+}
+
+// ExportHandler exports a wallet's activity for accounting
+type ExportHandler struct {
+	activityRepository *repository.ActivityRepository
+	logger             *zap.Logger
+}
+
+// NewExportHandler creates a new ExportHandler
+func NewExportHandler(activityRepository *repository.ActivityRepository, logger *zap.Logger) *ExportHandler {
+	return &ExportHandler{
+		activityRepository: activityRepository,
+		logger:             logger,
+	}
+}
+
+// ExportWalletActivity handles GET /api/wallets/{address}/export
+func (h *ExportHandler) ExportWalletActivity(w http.ResponseWriter, r *http.Request) {
+	walletAddress := mux.Vars(r)["address"]
+
+	// Validate Ethereum address format
+	if !common.IsHexAddress(walletAddress) {
+		h.writeErrorResponse(w, http.StatusBadRequest, "invalid_wallet_address", "Invalid Ethereum address format")
+		return
+	}
+
+	query := r.URL.Query()
+	filter := repository.ActivityFilter{
+		// Events are stored with checksummed addresses
+		WalletAddress: common.HexToAddress(walletAddress).Hex(),
+		Limit:         exportPageSize,
+	}
+
+	format := strings.ToLower(query.Get("format"))
+	switch format {
+	case "":
+		format = ExportFormatCSV
+	case ExportFormatCSV, ExportFormatJSON:
+	default:
+		h.writeErrorResponse(w, http.StatusBadRequest, "invalid_format", "Format must be csv or json")
+		return
+	}
+
+	if value := query.Get("from"); value != "" {
+		from, err := parseDateParam(value)
+		if err != nil {
+			h.writeErrorResponse(w, http.StatusBadRequest, "invalid_from", "From must be an RFC 3339 timestamp or a YYYY-MM-DD date")
+			return
+		}
+		filter.From = &from
+	}
+
+	if value := query.Get("to"); value != "" {
+		to, err := parseDateParam(value)
+		if err != nil {
+			h.writeErrorResponse(w, http.StatusBadRequest, "invalid_to", "To must be an RFC 3339 timestamp or a YYYY-MM-DD date")
+			return
+		}
+		filter.To = &to
+	}
+
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		h.writeErrorResponse(w, http.StatusBadRequest, "invalid_time_range", "From must be before to")
+		return
+	}
+
+	// The first page is read before anything is written, so that a database error is still reported
+	// as an error response
+	events, err := h.activityRepository.ListWalletActivity(filter)
+	if err != nil {
+		h.logger.Error("Failed to list wallet activity", zap.String("wallet_address", walletAddress), zap.Error(err))
+		h.writeErrorResponse(w, http.StatusInternalServerError, "database_error", "Failed to fetch wallet activity")
+		return
+	}
+
+	// Exports of long histories outlive the server's write timeout
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		h.logger.Warn("Failed to clear write deadline for export", zap.Error(err))
+	}
+
+	var writer exportWriter
+	if format == ExportFormatJSON {
+		w.Header().Set("Content-Type", "application/json")
+		writer = &jsonExportWriter{w: w}
+	} else {
+		w.Header().Set("Content-Type", "text/csv")
+		writer = &csvExportWriter{w: csv.NewWriter(w)}
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-activity.%s"`, filter.WalletAddress, format))
+	w.WriteHeader(http.StatusOK)
+
+	flusher, _ := w.(http.Flusher)
+	exported := 0
+
+	if err := writer.begin(); err != nil {
+		h.logger.Warn("Export interrupted", zap.String("wallet_address", walletAddress), zap.Error(err))
+		return
+	}
+
+	// Events are streamed a page at a time so that memory use does not grow with the history
+	for {
+		for _, event := range events {
+			record, err := toExportRecord(event)
+			if err != nil {
+				h.logger.Error("Failed to export event", zap.String("tx_hash", event.TxHash), zap.Uint("log_index", event.LogIndex), zap.Error(err))
+				return
+			}
+			if err := writer.write(record); err != nil {
+				h.logger.Warn("Export interrupted", zap.String("wallet_address", walletAddress), zap.Error(err))
+				return
+			}
+			exported++
+		}
+
+		if err := writer.flush(); err != nil {
+			h.logger.Warn("Export interrupted", zap.String("wallet_address", walletAddress), zap.Error(err))
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+
+		if len(events) < exportPageSize {
+			break
+		}
+
+		last := events[len(events)-1]
+		filter.AfterBlock, filter.AfterLogIndex, filter.AfterEventType = last.BlockNumber, last.LogIndex, last.EventType
+		events, err = h.activityRepository.ListWalletActivity(filter)
+		if err != nil {
+			// The status has been sent already; ending the stream early leaves a truncated export
+			h.logger.Error("Failed to list wallet activity", zap.String("wallet_address", walletAddress), zap.Error(err))
+			return
+		}
+	}
+
+	if err := writer.end(); err != nil {
+		h.logger.Warn("Export interrupted", zap.String("wallet_address", walletAddress), zap.Error(err))
+		return
+	}
+
+	h.logger.Info("Exported wallet activity",
+		zap.String("wallet_address", walletAddress),
+		zap.String("format", format),
+		zap.Int("events", exported))
+}
+
+// toExportRecord describes an event as what left and entered the wallet. Deposits and fulfilled
+// withdrawals are valued at the BTC amount deposited or received; withdrawal requests and share
+// transfers, which move no BTC, are valued at the share price.
+func toExportRecord(event model.ActivityEvent) (ExportRecord, error) {
+	record := ExportRecord{
+		EventType:   event.EventType,
+		TxHash:      event.TxHash,
+		LogIndex:    event.LogIndex,
+		BlockNumber: event.BlockNumber,
+		BlockTime:   event.TxDate.UTC(),
+		FromAsset:   event.FromAssetName,
+		ToAsset:     event.ToAssetName,
+	}
+
+	eventAmount, err := formatDecimal(event.Amount)
+	if err != nil {
+		return record, err
+	}
+
+	var sharePrice *big.Rat
+	if event.Rate != nil {
+		rate, ok := new(big.Int).SetString(*event.Rate, 10)
+		if !ok {
+			return record, fmt.Errorf("invalid snapshot rate %s", *event.Rate)
+		}
+		sharePrice = new(big.Rat).SetFrac(rate, oneShare())
+		record.SharePrice = amount.Format(rate, 8)
+	}
+
+	var eventData map[string]interface{}
+	if err := json.Unmarshal(event.EventBlob, &eventData); err != nil {
+		return record, fmt.Errorf("failed to unmarshal event data: %w", err)
+	}
+
+	switch event.EventType {
+	case "deposit":
+		shares, err := formatEventUnits(eventData, "share_amount")
+		if err != nil {
+			return record, err
+		}
+		record.FromAmount, record.ToAmount, record.Shares, record.ValueBTC = eventAmount, shares, shares, eventAmount
+
+	case "withdrawal_completed":
+		shares, err := formatEventUnits(eventData, "offer_amount_spent")
+		if err != nil {
+			return record, err
+		}
+		record.FromAmount, record.ToAmount, record.Shares, record.ValueBTC = shares, eventAmount, shares, eventAmount
+
+	default:
+		// Withdrawal requests and share transfers are denominated in shares. A request pays out on
+		// fulfilment, so it has no to amount.
+		record.FromAmount, record.Shares = eventAmount, eventAmount
+		if event.EventType != "withdrawal_requested" {
+			record.ToAmount = eventAmount
+		}
+		if sharePrice != nil {
+			shares, _ := new(big.Rat).SetString(eventAmount)
+			record.ValueBTC = formatRat(new(big.Rat).Mul(shares, sharePrice), 8)
+		}
+	}
+
+	return record, nil
+}
+
+// formatDecimal normalizes a stored DECIMAL amount to the 8 decimals of the vault's assets
+func formatDecimal(value string) (string, error) {
+	parsed, ok := new(big.Rat).SetString(value)
+	if !ok {
+		return "", fmt.Errorf("invalid amount %s", value)
+	}
+	return formatRat(parsed, 8), nil
+}
+
+// formatEventUnits formats an amount in smallest units from the event data
+func formatEventUnits(eventData map[string]interface{}, field string) (string, error) {
+	raw, ok := eventData[field].(string)
+	if !ok {
+		return "", fmt.Errorf("%s not found in event data", field)
+	}
+
+	units, ok := new(big.Int).SetString(raw, 10)
+	if !ok {
+		return "", fmt.Errorf("failed to parse %s: %s", field, raw)
+	}
+
+	return amount.Format(units, 8), nil
+}
+
+// exportWriter writes export records in one format. begin starts the document, write is called for each
+// record and flush after each page; end completes the document.
+type exportWriter interface {
+	begin() error
+	write(record ExportRecord) error
+	flush() error
+	end() error
+}
+
+// csvExportWriter writes a header row followed by one row per record
+type csvExportWriter struct {
+	w *csv.Writer
+}
+
+func (c *csvExportWriter) begin() error {
+	return c.w.Write(exportColumns)
+}
+
+func (c *csvExportWriter) write(record ExportRecord) error {
+	return c.w.Write([]string{
+		record.EventType,
+		record.TxHash,
+		strconv.FormatUint(uint64(record.LogIndex), 10),
+		strconv.FormatUint(record.BlockNumber, 10),
+		record.BlockTime.Format(time.RFC3339),
+		record.FromAsset,
+		record.FromAmount,
+		record.ToAsset,
+		record.ToAmount,
+		record.Shares,
+		record.SharePrice,
+		record.ValueBTC,
+	})
+}
+
+func (c *csvExportWriter) flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvExportWriter) end() error {
+	return c.flush()
+}
+
+// jsonExportWriter writes a JSON array of records without holding them in memory
+type jsonExportWriter struct {
+	w       io.Writer
+	written int
+}
+
+func (j *jsonExportWriter) begin() error {
+	_, err := io.WriteString(j.w, "[")
+	return err
+}
+
+func (j *jsonExportWriter) write(record ExportRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	if j.written > 0 {
+		if _, err := io.WriteString(j.w, ",\n"); err != nil {
+			return err
+		}
+	}
+	j.written++
+
+	_, err = j.w.Write(data)
+	return err
+}
+
+func (j *jsonExportWriter) flush() error {
+	return nil
+}
+
+func (j *jsonExportWriter) end() error {
+	_, err := io.WriteString(j.w, "]\n")
+	return err
+}
+
+// writeErrorResponse writes an error response
+func (h *ExportHandler) writeErrorResponse(w http.ResponseWriter, statusCode int, errorCode, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	if err := json.NewEncoder(w).Encode(ErrorResponse{Error: errorCode, Message: message}); err != nil {
+		h.logger.Error("Failed to encode JSON response", zap.Error(err))
+	}
+}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+	"yield/apps/yield/internal/config"
+)
+
+// Transaction types accepted in the tx_type request field
+const (
+	TxTypeLegacy  = "legacy"
+	TxTypeEIP1559 = "eip1559"
+)
+
+// Fee urgency tiers accepted in the fee_urgency request field
+const (
+	FeeUrgencySlow   = "slow"
+	FeeUrgencyNormal = "normal"
+	FeeUrgencyFast   = "fast"
+)
+
+// Max fee headroom over the next block's base fee. Two times the base fee stays valid through six
+// consecutive full blocks, each of which raises the base fee by 12.5%.
+const baseFeeMultiplier = 2
+
+// DynamicFees holds the EIP-1559 fee caps for a transaction
+type DynamicFees struct {
+	MaxFeePerGas         *big.Int
+	MaxPriorityFeePerGas *big.Int
+}
+
+// FeeEstimator derives EIP-1559 fees from eth_feeHistory
+type FeeEstimator struct {
+	ethClient *ethclient.Client
+	config    config.FeeConfig
+}
+
+// NewFeeEstimator creates a new FeeEstimator
+func NewFeeEstimator(ethClient *ethclient.Client, feeConfig config.FeeConfig) *FeeEstimator {
+	return &FeeEstimator{
+		ethClient: ethClient,
+		config:    feeConfig,
+	}
+}
+
+// IsValidFeeUrgency checks if the given urgency tier is supported
+func IsValidFeeUrgency(urgency string) bool {
+	return urgency == FeeUrgencySlow || urgency == FeeUrgencyNormal || urgency == FeeUrgencyFast
+}
+
+// EstimateFees returns the fee caps for the given urgency tier. The priority fee is the median, across
+// recent blocks, of the tier's reward percentile; the max fee adds headroom over the next base fee.
+func (fe *FeeEstimator) EstimateFees(ctx context.Context, urgency string) (*DynamicFees, error) {
+	percentiles := []float64{fe.config.SlowPercentile, fe.config.NormalPercentile, fe.config.FastPercentile}
+
+	var tier int
+	switch urgency {
+	case FeeUrgencySlow:
+		tier = 0
+	case FeeUrgencyNormal:
+		tier = 1
+	case FeeUrgencyFast:
+		tier = 2
+	default:
+		return nil, fmt.Errorf("unsupported fee urgency: %s", urgency)
+	}
+
+	history, err := fe.ethClient.FeeHistory(ctx, fe.config.HistoryBlocks, nil, percentiles)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fee history from blockchain: %w", err)
+	}
+
+	if len(history.BaseFee) == 0 {
+		return nil, fmt.Errorf("fee history returned no base fees")
+	}
+	// The last base fee is the one for the next block
+	nextBaseFee := history.BaseFee[len(history.BaseFee)-1]
+
+	rewards := make([]*big.Int, 0, len(history.Reward))
+	for _, blockRewards := range history.Reward {
+		if tier < len(blockRewards) && blockRewards[tier] != nil {
+			rewards = append(rewards, blockRewards[tier])
+		}
+	}
+
+	priorityFee := big.NewInt(0)
+	if len(rewards) > 0 {
+		sort.Slice(rewards, func(i, j int) bool { return rewards[i].Cmp(rewards[j]) < 0 })
+		priorityFee = new(big.Int).Set(rewards[len(rewards)/2])
+	} else {
+		// Empty history, fall back to the node's suggestion
+		priorityFee, err = fe.ethClient.SuggestGasTipCap(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get priority fee from blockchain: %w", err)
+		}
+	}
+
+	maxFee := new(big.Int).Mul(nextBaseFee, big.NewInt(baseFeeMultiplier))
+	maxFee.Add(maxFee, priorityFee)
+
+	return &DynamicFees{
+		MaxFeePerGas:         maxFee,
+		MaxPriorityFeePerGas: priorityFee,
+	}, nil
+}
@@ -3,17 +3,42 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"math/big"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"go.uber.org/zap"
+	"yield/apps/yield/internal/amount"
 	"yield/apps/yield/internal/assets"
+	"yield/apps/yield/internal/config"
+	"yield/apps/yield/internal/model"
+	"yield/apps/yield/internal/pricing"
+	"yield/apps/yield/internal/repository"
+)
+
+// Trailing windows, in days, over which APYs are reported. The first is reported as the headline APY.
+var APYWindowDays = []int{7, 30, 90}
+
+// Vault history metrics and bucket intervals
+const (
+	HistoryMetricTVL        = "tvl"
+	HistoryMetricSharePrice = "share_price"
+	HistoryMetricAPY        = "apy"
+
+	HistoryIntervalHour = "hour"
+	HistoryIntervalDay  = "day"
+
+	// Range returned when from is omitted, and the most buckets a request may span
+	DefaultHistoryRange = 30 * 24 * time.Hour
+	MaxHistoryPoints    = 2000
 )
 
 // Vault ABI for fetching vault information - using actual Lombard vault functions
@@ -48,7 +73,7 @@ const VaultABI = `[
 	}
 ]`
 
-// Accountant ABI for fetching rate information (APY calculation)
+// Accountant ABI for fetching rate information (slippage protection and quotes)
 const AccountantABI = `[
 	{
 		"constant": true,
@@ -56,21 +81,39 @@ const AccountantABI = `[
 		"name": "getRate",
 		"outputs": [{"name": "", "type": "uint256"}],
 		"type": "function"
+	},
+	{
+		"constant": true,
+		"inputs": [{"name": "quote", "type": "address"}],
+		"name": "getRateInQuoteSafe",
+		"outputs": [{"name": "rateInQuote", "type": "uint256"}],
+		"type": "function"
 	}
 ]`
 
 // InfoHandler handles vault information API endpoints
 type InfoHandler struct {
-	client            *ethclient.Client
-	logger            *zap.Logger
-	vaultABI          abi.ABI
-	accountantABI     abi.ABI
-	vaultAddress      common.Address
-	accountantAddress common.Address
+	client                 *ethclient.Client
+	logger                 *zap.Logger
+	vaultABI               abi.ABI
+	accountantABI          abi.ABI
+	vaultAddress           common.Address
+	rateSnapshotRepository *repository.RateSnapshotRepository
+	settingsReader         *VaultSettingsReader
+	oracle                 *pricing.Oracle
+	blockResolver          *BlockResolver
+	cache                  *ResponseCache
+	cacheTTL               time.Duration
+}
+
+// tvlValuation is what valuing the TVL needs besides the total supply
+type tvlValuation struct {
+	rate  *big.Int // Accountant rate, in base asset units per share
+	quote *pricing.Quote
 }
 
-// NewInfoHandler creates a new InfoHandler
-func NewInfoHandler(rpcURL string, logger *zap.Logger) (*InfoHandler, error) {
+// NewInfoHandler creates a new InfoHandler. Vault info responses are cached as configured by cacheConfig.
+func NewInfoHandler(rpcURL string, rateSnapshotRepository *repository.RateSnapshotRepository, cacheConfig config.CacheConfig, vaultConfig config.VaultConfig, logger *zap.Logger) (*InfoHandler, error) {
 	client, err := ethclient.Dial(rpcURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to Ethereum client: %w", err)
@@ -92,29 +135,72 @@ func NewInfoHandler(rpcURL string, logger *zap.Logger) (*InfoHandler, error) {
 		return nil, fmt.Errorf("LBTCv asset not found in registry")
 	}
 
+	settingsReader, err := NewVaultSettingsReader(client, vaultConfig, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	oracle, err := pricing.NewOracle(client, assets.GlobalRegistry)
+	if err != nil {
+		return nil, err
+	}
+
 	return &InfoHandler{
-		client:            client,
-		logger:            logger,
-		vaultABI:          parsedVaultABI,
-		accountantABI:     parsedAccountantABI,
-		vaultAddress:      lbtcvAsset.Address,
-		accountantAddress: common.HexToAddress(assets.AccountantContractAddress),
+		client:                 client,
+		logger:                 logger,
+		vaultABI:               parsedVaultABI,
+		accountantABI:          parsedAccountantABI,
+		vaultAddress:           lbtcvAsset.Address,
+		rateSnapshotRepository: rateSnapshotRepository,
+		settingsReader:         settingsReader,
+		oracle:                 oracle,
+		blockResolver:          NewBlockResolver(client, cacheConfig.HeadTTL),
+		cache:                  NewResponseCache(),
+		cacheTTL:               cacheConfig.InfoTTL,
 	}, nil
 }
 
 // GetInfo handles GET /api/info
 func (h *InfoHandler) GetInfo(w http.ResponseWriter, r *http.Request) {
-	// Fetch vault information concurrently
-	tvlChan := make(chan string, 1)
+	head, err := h.blockResolver.Head(r.Context())
+	if err != nil {
+		h.logger.Error("Failed to get block number", zap.Error(err))
+		h.writeErrorResponse(w, http.StatusInternalServerError, "fetch_error", "Failed to fetch vault information")
+		return
+	}
+
+	entry, hit, err := h.cache.Get(fmt.Sprintf("info@%d", head), head, h.cacheTTL, func() (interface{}, error) {
+		return h.readInfo(head)
+	})
+	if err != nil {
+		h.logger.Error("Failed to fetch vault info", zap.Error(err))
+		h.writeErrorResponse(w, http.StatusInternalServerError, "fetch_error", "Failed to fetch vault information")
+		return
+	}
+
+	h.logger.Info("Retrieved vault info", zap.Uint64("block_number", head), zap.Bool("cached", hit))
+
+	if err := writeCachedResponse(w, r, entry, hit); err != nil {
+		h.logger.Error("Failed to write vault info response", zap.Error(err))
+	}
+}
+
+// readInfo reads the vault information at the block, making the contract calls concurrently
+func (h *InfoHandler) readInfo(blockNumber uint64) (*InfoResponse, error) {
+	block := new(big.Int).SetUint64(blockNumber)
+
+	tvlChan := make(chan *big.Int, 1)
 	symbolChan := make(chan string, 1)
 	decimalsChan := make(chan int, 1)
 	nameChan := make(chan string, 1)
-	apyChan := make(chan string, 1)
-	errorChan := make(chan error, 5)
+	apysChan := make(chan []APYWindowResponse, 1)
+	settingsChan := make(chan *VaultSettingsResponse, 1)
+	valuationChan := make(chan *tvlValuation, 1)
+	errorChan := make(chan error, 7)
 
 	// Get Total Value Locked (TVL)
 	go func() {
-		tvl, err := h.getTotalAssets()
+		tvl, err := h.getTotalAssets(block)
 		if err != nil {
 			errorChan <- fmt.Errorf("failed to get TVL: %w", err)
 			return
@@ -124,7 +210,7 @@ func (h *InfoHandler) GetInfo(w http.ResponseWriter, r *http.Request) {
 
 	// Get token symbol
 	go func() {
-		symbol, err := h.getSymbol()
+		symbol, err := h.getSymbol(block)
 		if err != nil {
 			errorChan <- fmt.Errorf("failed to get symbol: %w", err)
 			return
@@ -134,7 +220,7 @@ func (h *InfoHandler) GetInfo(w http.ResponseWriter, r *http.Request) {
 
 	// Get token decimals
 	go func() {
-		decimals, err := h.getDecimals()
+		decimals, err := h.getDecimals(block)
 		if err != nil {
 			errorChan <- fmt.Errorf("failed to get decimals: %w", err)
 			return
@@ -144,7 +230,7 @@ func (h *InfoHandler) GetInfo(w http.ResponseWriter, r *http.Request) {
 
 	// Get vault name
 	go func() {
-		name, err := h.getName()
+		name, err := h.getName(block)
 		if err != nil {
 			errorChan <- fmt.Errorf("failed to get name: %w", err)
 			return
@@ -152,87 +238,279 @@ func (h *InfoHandler) GetInfo(w http.ResponseWriter, r *http.Request) {
 		nameChan <- name
 	}()
 
-	// Get APY (from rate) - with fallback
+	// Get APYs from the rate snapshots; the rest of the info is still useful without them
 	go func() {
-		apy, err := h.getAPY()
+		apys, err := h.getAPYs()
 		if err != nil {
-			// Log error but provide fallback value instead of failing
-			h.logger.Warn("Failed to get APY from accountant contract, using fallback", zap.Error(err))
-			apyChan <- "0.00" // Fallback APY
+			h.logger.Warn("Failed to compute APYs from rate snapshots", zap.Error(err))
+			apysChan <- []APYWindowResponse{}
 			return
 		}
-		apyChan <- apy
+		apysChan <- apys
+	}()
+
+	// Get fees, pause flags and deposit and withdrawal settings
+	go func() {
+		settings, err := h.settingsReader.Read(context.Background(), block)
+		if err != nil {
+			errorChan <- fmt.Errorf("failed to get vault settings: %w", err)
+			return
+		}
+		settingsChan <- settings
+	}()
+
+	// Get the accountant rate and prices to value the TVL with; like APYs, the TVL is reported without
+	// them when the price feeds are unavailable or stale
+	go func() {
+		valuation, err := h.getTVLValuation(block)
+		if err != nil {
+			h.logger.Warn("Failed to value TVL from price feeds", zap.Error(err))
+		}
+		valuationChan <- valuation
 	}()
 
 	// Collect results
-	var tvl, symbol, name, apy string
+	var symbol, name string
+	var tvl *big.Int
+	var apys []APYWindowResponse
+	var settings *VaultSettingsResponse
+	var valuation *tvlValuation
 	var decimals int
-	var errors []error
+	var fetchErrors []error
 
-	for i := 0; i < 5; i++ {
+	for i := 0; i < 7; i++ {
 		select {
 		case tvl = <-tvlChan:
 		case symbol = <-symbolChan:
 		case decimals = <-decimalsChan:
 		case name = <-nameChan:
-		case apy = <-apyChan:
+		case apys = <-apysChan:
+		case settings = <-settingsChan:
+		case valuation = <-valuationChan:
 		case err := <-errorChan:
-			errors = append(errors, err)
+			fetchErrors = append(fetchErrors, err)
 		}
 	}
 
-	// If any errors occurred, return error response
-	if len(errors) > 0 {
-		h.logger.Error("Failed to fetch vault info", zap.Errors("errors", errors))
-		h.writeErrorResponse(w, http.StatusInternalServerError, "fetch_error", "Failed to fetch vault information")
+	if len(fetchErrors) > 0 {
+		return nil, errors.Join(fetchErrors...)
+	}
+
+	// The headline APY is the shortest window the snapshots cover
+	var apy string
+	if len(apys) > 0 {
+		apy = apys[0].APY
+	}
+
+	response := &InfoResponse{
+		APY:                   apy,
+		APYs:                  apys,
+		TVL:                   amount.Format(tvl, 8),
+		TokenSymbol:           symbol,
+		Decimals:              decimals,
+		VaultName:             name,
+		BlockNumber:           blockNumber,
+		VaultSettingsResponse: settings,
+	}
+
+	if valuation != nil {
+		// Shares are worth the accountant rate in the base asset, which is valued through its peg
+		baseAmount := new(big.Rat).SetFrac(new(big.Int).Mul(tvl, valuation.rate), new(big.Int).Mul(oneShare(), oneShare()))
+		btc, usd, err := valuation.quote.Value(PositionBaseAsset, baseAmount)
+		if err != nil {
+			h.logger.Warn("Failed to value TVL from price feeds", zap.Error(err))
+		} else {
+			pricedAt := valuation.quote.OldestUpdate()
+			response.TVLBTC = formatRat(btc, 8)
+			response.TVLUSD = formatRat(usd, 2)
+			response.BTCPriceUSD = formatRat(valuation.quote.BTCPriceUSD.Value, 2)
+			response.PricedAt = &pricedAt
+		}
+	}
+
+	return response, nil
+}
+
+// GetVaultHistory handles GET /api/vault/history
+func (h *InfoHandler) GetVaultHistory(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	metric := strings.ToLower(query.Get("metric"))
+	switch metric {
+	case HistoryMetricTVL, HistoryMetricSharePrice, HistoryMetricAPY:
+	default:
+		h.writeErrorResponse(w, http.StatusBadRequest, "invalid_metric", "Metric must be tvl, share_price or apy")
 		return
 	}
 
-	response := InfoResponse{
-		APY:         apy,
-		TVL:         tvl,
-		TokenSymbol: symbol,
-		Decimals:    decimals,
-		VaultName:   name,
+	interval := strings.ToLower(query.Get("interval"))
+	var bucket time.Duration
+	switch interval {
+	case "", HistoryIntervalDay:
+		interval, bucket = HistoryIntervalDay, 24*time.Hour
+	case HistoryIntervalHour:
+		bucket = time.Hour
+	default:
+		h.writeErrorResponse(w, http.StatusBadRequest, "invalid_interval", "Interval must be hour or day")
+		return
 	}
 
-	h.logger.Info("Retrieved vault info",
-		zap.String("apy", apy),
-		zap.String("tvl", tvl),
-		zap.String("symbol", symbol),
-		zap.Int("decimals", decimals),
-		zap.String("name", name))
+	to := time.Now().UTC()
+	if value := query.Get("to"); value != "" {
+		parsed, err := parseDateParam(value)
+		if err != nil {
+			h.writeErrorResponse(w, http.StatusBadRequest, "invalid_to", "To must be an RFC 3339 timestamp or a YYYY-MM-DD date")
+			return
+		}
+		to = parsed.UTC()
+	}
+
+	from := to.Add(-DefaultHistoryRange)
+	if value := query.Get("from"); value != "" {
+		parsed, err := parseDateParam(value)
+		if err != nil {
+			h.writeErrorResponse(w, http.StatusBadRequest, "invalid_from", "From must be an RFC 3339 timestamp or a YYYY-MM-DD date")
+			return
+		}
+		from = parsed.UTC()
+	}
+
+	if !from.Before(to) {
+		h.writeErrorResponse(w, http.StatusBadRequest, "invalid_time_range", "From must be before to")
+		return
+	}
+
+	if to.Sub(from)/bucket > MaxHistoryPoints {
+		h.writeErrorResponse(w, http.StatusBadRequest, "range_too_large", fmt.Sprintf("Range must span at most %d %s buckets", MaxHistoryPoints, interval))
+		return
+	}
+
+	// Each APY point compares its bucket with the one a window earlier, so the series starts earlier
+	window := time.Duration(APYWindowDays[0]) * 24 * time.Hour
+	since := from
+	if metric == HistoryMetricAPY {
+		since = from.Add(-window)
+	}
+
+	snapshots, err := h.rateSnapshotRepository.ListRateSnapshotBuckets(since, to, interval)
+	if err != nil {
+		h.logger.Error("Failed to list rate snapshots", zap.Error(err))
+		h.writeErrorResponse(w, http.StatusInternalServerError, "database_error", "Failed to fetch vault history")
+		return
+	}
+
+	response := VaultHistoryResponse{
+		Metric:   metric,
+		Interval: interval,
+		From:     from,
+		To:       to,
+		Points:   []VaultHistoryPoint{},
+	}
+	if metric == HistoryMetricAPY {
+		response.WindowDays = APYWindowDays[0]
+	}
+
+	start := 0
+	for i := range snapshots {
+		snapshot := &snapshots[i]
+		if snapshot.BlockTimestamp.Before(from) {
+			continue
+		}
+
+		var value string
+		switch metric {
+		case HistoryMetricTVL, HistoryMetricSharePrice:
+			raw := snapshot.TotalSupply
+			if metric == HistoryMetricSharePrice {
+				raw = snapshot.Rate
+			}
+			units, ok := new(big.Int).SetString(raw, 10)
+			if !ok {
+				h.logger.Error("Invalid rate snapshot", zap.Uint64("block_number", snapshot.BlockNumber), zap.String(metric, raw))
+				continue
+			}
+			value = amount.Format(units, 8)
+		case HistoryMetricAPY:
+			// Latest bucket at least a window older than this one
+			target := snapshot.BlockTimestamp.Add(-window)
+			for start+1 < i && !snapshots[start+1].BlockTimestamp.After(target) {
+				start++
+			}
+			if snapshots[start].BlockTimestamp.After(target) {
+				continue
+			}
+			value, err = annualizedYield(&snapshots[start], snapshot)
+			if err != nil {
+				h.logger.Error("Invalid rate snapshot", zap.Uint64("block_number", snapshot.BlockNumber), zap.Error(err))
+				continue
+			}
+		}
+
+		response.Points = append(response.Points, VaultHistoryPoint{
+			Timestamp:   snapshot.BlockTimestamp.UTC().Truncate(bucket),
+			BlockNumber: snapshot.BlockNumber,
+			Value:       value,
+		})
+	}
 
 	h.writeJSONResponse(w, http.StatusOK, response)
 }
 
-// getTotalAssets retrieves the total supply (TVL) from the vault
-func (h *InfoHandler) getTotalAssets() (string, error) {
+// getTotalAssets retrieves the total supply (TVL) from the vault, in share units
+func (h *InfoHandler) getTotalAssets(block *big.Int) (*big.Int, error) {
 	data, err := h.vaultABI.Pack("totalSupply")
 	if err != nil {
-		return "", fmt.Errorf("failed to pack totalSupply call: %w", err)
+		return nil, fmt.Errorf("failed to pack totalSupply call: %w", err)
 	}
 
 	result, err := h.client.CallContract(context.Background(), ethereum.CallMsg{
 		To:   &h.vaultAddress,
 		Data: data,
-	}, nil)
+	}, block)
 	if err != nil {
-		return "", fmt.Errorf("failed to call totalSupply: %w", err)
+		return nil, fmt.Errorf("failed to call totalSupply: %w", err)
 	}
 
 	var totalSupply *big.Int
 	err = h.vaultABI.UnpackIntoInterface(&totalSupply, "totalSupply", result)
 	if err != nil {
-		return "", fmt.Errorf("failed to unpack totalSupply result: %w", err)
+		return nil, fmt.Errorf("failed to unpack totalSupply result: %w", err)
+	}
+
+	return totalSupply, nil
+}
+
+// getTVLValuation reads the accountant rate and a price quote at the block
+func (h *InfoHandler) getTVLValuation(block *big.Int) (*tvlValuation, error) {
+	data, err := h.accountantABI.Pack("getRate")
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack getRate call: %w", err)
 	}
 
-	// Convert to decimal representation (assuming 8 decimals for BTC-based assets)
-	return h.convertToDecimalAmount(totalSupply, 8), nil
+	accountant := common.HexToAddress(assets.AccountantContractAddress)
+	result, err := h.client.CallContract(context.Background(), ethereum.CallMsg{
+		To:   &accountant,
+		Data: data,
+	}, block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call getRate: %w", err)
+	}
+
+	var rate *big.Int
+	if err := h.accountantABI.UnpackIntoInterface(&rate, "getRate", result); err != nil {
+		return nil, fmt.Errorf("failed to unpack getRate result: %w", err)
+	}
+
+	quote, err := h.oracle.Quote(context.Background(), block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tvlValuation{rate: rate, quote: quote}, nil
 }
 
 // getSymbol retrieves the token symbol from the vault
-func (h *InfoHandler) getSymbol() (string, error) {
+func (h *InfoHandler) getSymbol(block *big.Int) (string, error) {
 	data, err := h.vaultABI.Pack("symbol")
 	if err != nil {
 		return "", fmt.Errorf("failed to pack symbol call: %w", err)
@@ -241,7 +519,7 @@ func (h *InfoHandler) getSymbol() (string, error) {
 	result, err := h.client.CallContract(context.Background(), ethereum.CallMsg{
 		To:   &h.vaultAddress,
 		Data: data,
-	}, nil)
+	}, block)
 	if err != nil {
 		return "", fmt.Errorf("failed to call symbol: %w", err)
 	}
@@ -256,7 +534,7 @@ func (h *InfoHandler) getSymbol() (string, error) {
 }
 
 // getDecimals retrieves the token decimals from the vault
-func (h *InfoHandler) getDecimals() (int, error) {
+func (h *InfoHandler) getDecimals(block *big.Int) (int, error) {
 	data, err := h.vaultABI.Pack("decimals")
 	if err != nil {
 		return 0, fmt.Errorf("failed to pack decimals call: %w", err)
@@ -265,7 +543,7 @@ func (h *InfoHandler) getDecimals() (int, error) {
 	result, err := h.client.CallContract(context.Background(), ethereum.CallMsg{
 		To:   &h.vaultAddress,
 		Data: data,
-	}, nil)
+	}, block)
 	if err != nil {
 		return 0, fmt.Errorf("failed to call decimals: %w", err)
 	}
@@ -280,7 +558,7 @@ func (h *InfoHandler) getDecimals() (int, error) {
 }
 
 // getName retrieves the vault name
-func (h *InfoHandler) getName() (string, error) {
+func (h *InfoHandler) getName(block *big.Int) (string, error) {
 	data, err := h.vaultABI.Pack("name")
 	if err != nil {
 		return "", fmt.Errorf("failed to pack name call: %w", err)
@@ -289,7 +567,7 @@ func (h *InfoHandler) getName() (string, error) {
 	result, err := h.client.CallContract(context.Background(), ethereum.CallMsg{
 		To:   &h.vaultAddress,
 		Data: data,
-	}, nil)
+	}, block)
 	if err != nil {
 		return "", fmt.Errorf("failed to call name: %w", err)
 	}
@@ -303,69 +581,62 @@ func (h *InfoHandler) getName() (string, error) {
 	return name, nil
 }
 
-// getAPY retrieves and calculates APY from the accountant contract
-func (h *InfoHandler) getAPY() (string, error) {
-	data, err := h.accountantABI.Pack("getRate")
+// getAPYs computes the trailing APY over each of APYWindowDays from the stored rate snapshots. A
+// window is left out until the snapshots cover it.
+func (h *InfoHandler) getAPYs() ([]APYWindowResponse, error) {
+	latest, err := h.rateSnapshotRepository.GetLatestRateSnapshot()
 	if err != nil {
-		return "", fmt.Errorf("failed to pack getRate call: %w", err)
+		return nil, err
 	}
-
-	result, err := h.client.CallContract(context.Background(), ethereum.CallMsg{
-		To:   &h.accountantAddress,
-		Data: data,
-	}, nil)
-	if err != nil {
-		return "", fmt.Errorf("failed to call getRate: %w", err)
+	if latest == nil {
+		return []APYWindowResponse{}, nil
 	}
 
-	var rate *big.Int
-	err = h.accountantABI.UnpackIntoInterface(&rate, "getRate", result)
-	if err != nil {
-		return "", fmt.Errorf("failed to unpack getRate result: %w", err)
-	}
+	apys := []APYWindowResponse{}
+	for _, days := range APYWindowDays {
+		start, err := h.rateSnapshotRepository.GetRateSnapshotAtOrBefore(latest.BlockTimestamp.Add(-time.Duration(days) * 24 * time.Hour))
+		if err != nil {
+			return nil, err
+		}
+		if start == nil {
+			continue
+		}
 
-	// Convert rate to APY percentage
-	// The rate is typically returned in basis points or a similar format
-	// We'll convert to percentage with 2 decimal places
-	// Assuming rate is in basis points (1 basis point = 0.01%)
-	apy := h.convertRateToAPY(rate)
-	return apy, nil
-}
+		apy, err := annualizedYield(start, latest)
+		if err != nil {
+			return nil, err
+		}
 
-// convertRateToAPY converts a rate to APY percentage string
-func (h *InfoHandler) convertRateToAPY(rate *big.Int) string {
-	// Convert rate to percentage
-	// Assuming the rate is already an annual rate in some form
-	// We'll divide by 100 to get percentage and format to 2 decimal places
-	rateDivisor := big.NewInt(10000) // For basis points to percentage conversion
-	apy := new(big.Float).SetInt(rate)
-	apy.Quo(apy, new(big.Float).SetInt(rateDivisor))
+		apys = append(apys, APYWindowResponse{
+			WindowDays: days,
+			APY:        apy,
+			FromBlock:  start.BlockNumber,
+			ToBlock:    latest.BlockNumber,
+			From:       start.BlockTimestamp,
+			To:         latest.BlockTimestamp,
+		})
+	}
 
-	return fmt.Sprintf("%.2f", apy)
+	return apys, nil
 }
 
-// convertToDecimalAmount converts wei amount to decimal representation
-func (h *InfoHandler) convertToDecimalAmount(amount *big.Int, decimals int) string {
-	divisor := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
-	wholePart := new(big.Int).Div(amount, divisor)
-	remainder := new(big.Int).Mod(amount, divisor)
-
-	// Format as decimal string
-	if remainder.Cmp(big.NewInt(0)) == 0 {
-		return wholePart.String()
-	} else {
-		// Pad remainder with leading zeros to match decimal places
-		remainderStr := remainder.String()
-		for len(remainderStr) < decimals {
-			remainderStr = "0" + remainderStr
-		}
-		// Remove trailing zeros
-		remainderStr = strings.TrimRight(remainderStr, "0")
-		if remainderStr == "" {
-			return wholePart.String()
-		}
-		return wholePart.String() + "." + remainderStr
+// annualizedYield compounds the growth of the rate between two snapshots over a year, as a percentage
+// with 2 decimal places
+func annualizedYield(start, end *model.RateSnapshot) (string, error) {
+	startRate, ok := new(big.Rat).SetString(start.Rate)
+	if !ok || startRate.Sign() <= 0 {
+		return "", fmt.Errorf("invalid rate %q at block %d", start.Rate, start.BlockNumber)
 	}
+	endRate, ok := new(big.Rat).SetString(end.Rate)
+	if !ok {
+		return "", fmt.Errorf("invalid rate %q at block %d", end.Rate, end.BlockNumber)
+	}
+
+	growth, _ := new(big.Rat).Quo(endRate, startRate).Float64()
+	years := end.BlockTimestamp.Sub(start.BlockTimestamp).Hours() / (24 * 365)
+
+	apy := (math.Pow(growth, 1/years) - 1) * 100
+	return fmt.Sprintf("%.2f", apy), nil
 }
 
 // writeJSONResponse writes a JSON response with the specified status code
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"encoding/json"
 	"time"
 )
 
@@ -16,13 +17,31 @@ type OrderResponse struct {
 	TransferType    string     `json:"transfer_type"`
 	Amount          string     `json:"amount"`
 	EstimatedAmount *string    `json:"estimated_amount,omitempty"`
+	ShareAmount     *string    `json:"share_amount,omitempty"` // LBTCv shares added to or removed from the wallet
+}
+
+// OrderListResponse represents a page of a wallet's orders
+type OrderListResponse struct {
+	Orders     []OrderResponse `json:"orders"`
+	NextCursor string          `json:"next_cursor,omitempty"`
 }
 
 // DepositRequest represents the request body for creating a deposit order
 type DepositRequest struct {
-	Amount        string `json:"amount" validate:"required"`
-	FromAssetName string `json:"from_asset_name" validate:"required,oneof=LBTC CBTC WBTC"`
-	WalletAddress string `json:"wallet_address" validate:"required"`
+	Amount        string         `json:"amount" validate:"required"`
+	FromAssetName string         `json:"from_asset_name" validate:"required,oneof=LBTC CBTC WBTC"`
+	WalletAddress string         `json:"wallet_address" validate:"required"`
+	TxType        string         `json:"tx_type,omitempty" validate:"omitempty,oneof=legacy eip1559"`
+	FeeUrgency    string         `json:"fee_urgency,omitempty" validate:"omitempty,oneof=slow normal fast"`
+	SlippageBps   *int64         `json:"slippage_bps,omitempty" validate:"omitempty,min=0,max=1000"`
+	Permit        *DepositPermit `json:"permit,omitempty"` // Signed permit replacing the approval transaction
+	OutputFormat  string         `json:"output_format,omitempty" validate:"omitempty,oneof=transaction safe erc4337"`
+}
+
+// DepositPermit carries a signed EIP-2612 permit for a deposit
+type DepositPermit struct {
+	Signature string `json:"signature" validate:"required"` // 0x-prefixed 65 byte signature
+	Deadline  int64  `json:"deadline" validate:"required"`  // Unix timestamp the permit was signed with
 }
 
 // WithdrawalRequest represents the request body for creating a withdrawal order
@@ -30,54 +49,397 @@ type WithdrawalRequest struct {
 	Amount        string `json:"amount" validate:"required"`
 	ToAssetName   string `json:"to_asset_name" validate:"required,oneof=LBTC WBTC CBTC"`
 	WalletAddress string `json:"wallet_address" validate:"required"`
+	TxType        string `json:"tx_type,omitempty" validate:"omitempty,oneof=legacy eip1559"`
+	FeeUrgency    string `json:"fee_urgency,omitempty" validate:"omitempty,oneof=slow normal fast"`
+	SlippageBps   *int64 `json:"slippage_bps,omitempty" validate:"omitempty,min=0,max=100"`
+	OutputFormat  string `json:"output_format,omitempty" validate:"omitempty,oneof=transaction safe erc4337"`
 }
 
-// DepositResponse represents the response for a deposit transaction creation
+// DepositResponse represents the response for a deposit transaction creation. UnsignedTransaction is
+// the deposit itself; Transactions lists every transaction to send, in order, including any approval.
+// UnsignedTransaction is empty for smart account output formats.
 type DepositResponse struct {
-	UnsignedTransaction string `json:"unsigned_transaction"`
+	UnsignedTransaction string                  `json:"unsigned_transaction"`
+	ApprovalRequired    bool                    `json:"approval_required"`
+	Transactions        []TransactionStep       `json:"transactions"`
+	PriceProtection     PriceProtectionResponse `json:"price_protection"` // Amounts in LBTCv
 }
 
 // WithdrawalResponse represents the response for a withdrawal transaction creation
 type WithdrawalResponse struct {
+	UnsignedTransaction string                  `json:"unsigned_transaction"`
+	ApprovalRequired    bool                    `json:"approval_required"`
+	Transactions        []TransactionStep       `json:"transactions"`
+	PriceProtection     PriceProtectionResponse `json:"price_protection"` // Amounts in the withdrawn asset
+}
+
+// PriceProtectionResponse represents the rate a transaction was built against and the worst outcome it
+// accepts, as decimal amounts of the output token
+type PriceProtectionResponse struct {
+	Rate           string `json:"rate"` // Price of one LBTCv in the deposited or withdrawn asset
+	ExpectedAmount string `json:"expected_amount"`
+	MinimumAmount  string `json:"minimum_amount"`
+	SlippageBps    int64  `json:"slippage_bps"`
+}
+
+// TransactionStep is one transaction in an ordered list of transactions to sign and send. Smart account
+// output formats return a single step whose Safe transaction or UserOperation executes every call in
+// Calls, instead of an unsigned transaction.
+type TransactionStep struct {
+	Type                string               `json:"type"` // "approve", "deposit" or "withdrawal"
+	UnsignedTransaction *UnsignedTransaction `json:"unsigned_transaction,omitempty"`
+	SafeTransaction     *SafeTransaction     `json:"safe_transaction,omitempty"`
+	UserOperation       *UserOperation       `json:"user_operation,omitempty"`
+	Calls               []string             `json:"calls,omitempty"` // Step types batched into this step
+}
+
+// SafeTransaction is a proposal for the Safe transaction service, in the service's field names. It is
+// executed once enough owners have signed ContractTransactionHash.
+type SafeTransaction struct {
+	Safe                    string `json:"safe"`
+	To                      string `json:"to"`
+	Value                   string `json:"value"`
+	Data                    string `json:"data"`
+	Operation               int    `json:"operation"` // 0 for a call, 1 for a MultiSend delegatecall
+	SafeTxGas               string `json:"safeTxGas"`
+	BaseGas                 string `json:"baseGas"`
+	GasPrice                string `json:"gasPrice"`
+	GasToken                string `json:"gasToken"`
+	RefundReceiver          string `json:"refundReceiver"`
+	Nonce                   uint64 `json:"nonce"`
+	ContractTransactionHash string `json:"contractTransactionHash"`
+}
+
+// UserOperation holds the ERC-4337 UserOperation fields that depend on the calls. Gas limits, fees,
+// paymaster data and the signature are filled in by the client's bundler.
+type UserOperation struct {
+	Sender     string `json:"sender"`
+	Nonce      string `json:"nonce"`
+	CallData   string `json:"callData"`
+	EntryPoint string `json:"entryPoint"`
+}
+
+// ApprovalRequest represents the request body for building an ERC20 approval
+type ApprovalRequest struct {
+	AssetName     string `json:"asset_name" validate:"required,oneof=LBTC WBTC CBTC LBTCv"`
+	Amount        string `json:"amount,omitempty"` // Omit or "max" for an unlimited approval
+	WalletAddress string `json:"wallet_address" validate:"required"`
+	TxType        string `json:"tx_type,omitempty" validate:"omitempty,oneof=legacy eip1559"`
+	FeeUrgency    string `json:"fee_urgency,omitempty" validate:"omitempty,oneof=slow normal fast"`
+}
+
+// ApprovalResponse represents the response for an approval transaction creation
+type ApprovalResponse struct {
 	UnsignedTransaction string `json:"unsigned_transaction"`
+	Spender             string `json:"spender"`
+	CurrentAllowance    string `json:"current_allowance"` // In token units
+}
+
+// QuoteResponse represents a deposit or withdrawal preview. Deposit amounts are in LBTCv, withdrawal
+// amounts in the withdrawn asset, each with all of the token's decimals.
+type QuoteResponse struct {
+	Asset  string `json:"asset"`
+	Amount string `json:"amount"` // Amount deposited, or LBTCv withdrawn
+	PriceProtectionResponse
+	FeeBps      *int64    `json:"fee_bps,omitempty"`      // Deposits only: the Teller share premium
+	AtomicPrice *string   `json:"atomic_price,omitempty"` // Withdrawals only: the price the request is filled at
+	BlockNumber uint64    `json:"block_number"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// DecodeRequest represents the request body for decoding calldata
+type DecodeRequest struct {
+	To   string `json:"to" validate:"required"`   // Contract the calldata is sent to
+	Data string `json:"data" validate:"required"` // 0x-prefixed calldata
+}
+
+// DecodeResponse represents a decoded contract call
+type DecodeResponse struct {
+	To        string            `json:"to"`
+	Contract  string            `json:"contract"` // "Teller", "AtomicQueue", "MultiSendCallOnly", "SmartAccount" or the asset symbol
+	Function  string            `json:"function"`
+	Signature string            `json:"signature"` // e.g. "deposit(address,uint256,uint256)"
+	Arguments []DecodedArgument `json:"arguments"`
+	Value     string            `json:"value,omitempty"` // Wei sent with an inner call of a batch
+	Calls     []DecodeResponse  `json:"calls,omitempty"` // Inner calls of a MultiSend batch or smart account execution
+}
+
+// DecodedArgument represents one decoded function argument. Integers are decimal strings in the
+// token's smallest units, bytes are hex, and tuples are objects keyed by component name.
+type DecodedArgument struct {
+	Name   string      `json:"name"`
+	Type   string      `json:"type"`
+	Value  interface{} `json:"value"`
+	Symbol string      `json:"symbol,omitempty"` // Set for addresses of registered assets
+}
+
+// RelayTransactionRequest represents the request body for relaying a signed transaction
+type RelayTransactionRequest struct {
+	SignedTransaction string `json:"signed_transaction" validate:"required"` // 0x-prefixed raw transaction
+}
+
+// RelayedTransactionResponse represents the broadcast status of a relayed transaction
+type RelayedTransactionResponse struct {
+	TxHash        string    `json:"tx_hash"`
+	WalletAddress string    `json:"wallet_address"`
+	Nonce         uint64    `json:"nonce"`
+	StepType      string    `json:"step_type"` // "approve", "deposit" or "withdrawal"
+	Status        string    `json:"status"`    // "pending_onchain", "confirmed", "reverted", "replaced" or "dropped"
+	BlockNumber   *uint64   `json:"block_number,omitempty"`
+	FailureReason *string   `json:"failure_reason,omitempty"`
+	ReplacedBy    *string   `json:"replaced_by,omitempty"`
+	BroadcastAt   time.Time `json:"broadcast_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// PermitRequest represents the request body for building EIP-2612 permit typed data
+type PermitRequest struct {
+	AssetName     string `json:"asset_name" validate:"required"`
+	Amount        string `json:"amount" validate:"required"`
+	WalletAddress string `json:"wallet_address" validate:"required"`
+	Deadline      int64  `json:"deadline,omitempty"` // Unix timestamp, defaults to one hour from now
+}
+
+// PermitResponse represents the EIP-712 typed data for the wallet to sign with eth_signTypedData_v4
+type PermitResponse struct {
+	TypedData map[string]interface{} `json:"typed_data"`
+	Spender   string                 `json:"spender"`
+	Deadline  int64                  `json:"deadline"`
 }
 
-// UnsignedTransaction represents the unsigned Ethereum transaction data
+// UnsignedTransaction represents the unsigned Ethereum transaction data. Legacy transactions carry
+// gas_price; EIP-1559 transactions carry max_fee_per_gas and max_priority_fee_per_gas instead.
 type UnsignedTransaction struct {
-	To       string `json:"to"`
-	Data     string `json:"data"`
-	Value    string `json:"value"`
-	GasLimit string `json:"gas_limit"`
-	GasPrice string `json:"gas_price"`
-	ChainID  string `json:"chain_id"`
-	Nonce    string `json:"nonce"`
+	Type                 string `json:"type"` // "0x0" legacy, "0x2" EIP-1559
+	To                   string `json:"to"`
+	Data                 string `json:"data"`
+	Value                string `json:"value"`
+	GasLimit             string `json:"gas_limit"`
+	GasPrice             string `json:"gas_price,omitempty"`
+	MaxFeePerGas         string `json:"max_fee_per_gas,omitempty"`
+	MaxPriorityFeePerGas string `json:"max_priority_fee_per_gas,omitempty"`
+	ChainID              string `json:"chain_id"`
+	Nonce                string `json:"nonce"`
+	Serialized           string `json:"serialized"` // RLP-encoded unsigned transaction, typed envelope for EIP-1559
 }
 
 // BalanceResponse represents the API response for wallet balance information
 type BalanceResponse struct {
-	WalletAddress string                    `json:"wallet_address"`
-	Balances      map[string]TokenBalance   `json:"balances"`
+	WalletAddress  string                  `json:"wallet_address"`
+	BlockNumber    uint64                  `json:"block_number"`              // Block every balance was read at
+	BlockTimestamp *time.Time              `json:"block_timestamp,omitempty"` // Only for balances at a past block or timestamp
+	Balances       map[string]TokenBalance `json:"balances"`
 }
 
 // TokenBalance represents balance information for a specific token
 type TokenBalance struct {
-	Balance     string `json:"balance"`
-	Symbol      string `json:"symbol"`
-	Address     string `json:"address"`
-	Decimals    int    `json:"decimals"`
+	Balance   string `json:"balance"`
+	Symbol    string `json:"symbol"`
+	Address   string `json:"address"`
+	Decimals  int    `json:"decimals"`
+	Allowance string `json:"allowance"`       // Granted to the spender
+	Spender   string `json:"spender"`         // The vault for deposit assets, the AtomicQueue for LBTCv
+	Value     string `json:"value,omitempty"` // LBTCv only: the balance's worth in LBTC at the accountant rate
+}
+
+// BatchBalanceRequest represents a request for the balances of several wallets
+type BatchBalanceRequest struct {
+	WalletAddresses []string `json:"wallet_addresses"`
+}
+
+// BatchBalanceResponse represents the balances of several wallets, all read at the same block
+type BatchBalanceResponse struct {
+	BlockNumber uint64            `json:"block_number"`
+	Balances    []BalanceResponse `json:"balances"` // In the order of the request
 }
 
 // InfoResponse represents the API response for vault information
 type InfoResponse struct {
-	APY         string `json:"apy"`
-	TVL         string `json:"tvl"`
-	TokenSymbol string `json:"token_symbol"`
-	Decimals    int    `json:"decimals"`
-	VaultName   string `json:"vault_name"`
+	APY         string              `json:"apy,omitempty"` // Shortest window in APYs, omitted until one is available
+	APYs        []APYWindowResponse `json:"apys"`
+	TVL         string              `json:"tvl"`               // In LBTCv
+	TVLBTC      string              `json:"tvl_btc,omitempty"` // Valued from the price feeds; omitted when they are unavailable or stale
+	TVLUSD      string              `json:"tvl_usd,omitempty"`
+	BTCPriceUSD string              `json:"btc_price_usd,omitempty"`
+	PricedAt    *time.Time          `json:"priced_at,omitempty"` // When the oldest price used was updated
+	TokenSymbol string              `json:"token_symbol"`
+	Decimals    int                 `json:"decimals"`
+	VaultName   string              `json:"vault_name"`
+	BlockNumber uint64              `json:"block_number"` // Block the vault was read at
+	*VaultSettingsResponse
+}
+
+// VaultSettingsResponse describes the Teller, Accountant and AtomicQueue settings a deposit or withdrawal
+// depends on. Settings the contracts do not expose are omitted.
+type VaultSettingsResponse struct {
+	Fees                   *VaultFeesResponse     `json:"fees,omitempty"`
+	Paused                 VaultPauseResponse     `json:"paused"`
+	ShareLockPeriodSeconds *uint64                `json:"share_lock_period_seconds,omitempty"` // Shares minted by a deposit cannot move for this long
+	DepositCap             string                 `json:"deposit_cap,omitempty"`               // Most LBTCv the Teller mints in total, omitted when uncapped
+	WithdrawalDiscount     *DiscountRangeResponse `json:"withdrawal_discount,omitempty"`
+	Assets                 []VaultAssetResponse   `json:"assets"`
+}
+
+// VaultFeesResponse represents the accountant's fees, in basis points
+type VaultFeesResponse struct {
+	PlatformFeeBps    int `json:"platform_fee_bps"`    // Yearly, on assets under management
+	PerformanceFeeBps int `json:"performance_fee_bps"` // On yield above the high-water mark
+}
+
+// VaultPauseResponse reports which vault contracts are paused. A deposit needs the Teller and Accountant,
+// a withdrawal the AtomicQueue and Accountant.
+type VaultPauseResponse struct {
+	Teller      *bool `json:"teller"`
+	Accountant  *bool `json:"accountant"`
+	AtomicQueue *bool `json:"atomic_queue"`
+}
+
+// DiscountRangeResponse represents the AtomicQueue discounts a withdrawal request may set, in basis points
+type DiscountRangeResponse struct {
+	MinBps int64 `json:"min_bps"`
+	MaxBps int64 `json:"max_bps"`
+}
+
+// VaultAssetResponse represents the Teller's settings for one deposit asset
+type VaultAssetResponse struct {
+	Symbol            string `json:"symbol"`
+	Address           string `json:"address"`
+	AllowDeposits     *bool  `json:"allow_deposits"`
+	AllowWithdraws    *bool  `json:"allow_withdraws"`
+	SharePremiumBps   *int   `json:"share_premium_bps,omitempty"`
+	MinimumWithdrawal string `json:"minimum_withdrawal,omitempty"` // In LBTCv
+}
+
+// APYWindowResponse represents the annualized yield of the vault over a trailing window, compounded
+// from the change in the accountant rate between two snapshots
+type APYWindowResponse struct {
+	WindowDays int       `json:"window_days"`
+	APY        string    `json:"apy"` // Percentage with 2 decimal places
+	FromBlock  uint64    `json:"from_block"`
+	ToBlock    uint64    `json:"to_block"`
+	From       time.Time `json:"from"`
+	To         time.Time `json:"to"`
+}
+
+// PositionResponse represents a wallet's LBTCv position and the yield it has earned. Totals add up the
+// lots at par, since every deposit asset is BTC.
+type PositionResponse struct {
+	WalletAddress     string                `json:"wallet_address"`
+	Shares            string                `json:"shares"`        // On-chain LBTCv balance
+	LedgerShares      string                `json:"ledger_shares"` // LBTCv accounted for by the wallet's orders
+	Value             string                `json:"value"`
+	ValueBTC          string                `json:"value_btc,omitempty"` // Valued from the price feeds; omitted when they are unavailable or stale
+	ValueUSD          string                `json:"value_usd,omitempty"`
+	BTCPriceUSD       string                `json:"btc_price_usd,omitempty"`
+	PricedAt          *time.Time            `json:"priced_at,omitempty"` // When the oldest price used was updated
+	CostBasis         string                `json:"cost_basis"`
+	RealizedYield     string                `json:"realized_yield"`
+	UnrealizedYield   string                `json:"unrealized_yield"`
+	Lots              []PositionLotResponse `json:"lots"`
+	UnaccountedOrders int                   `json:"unaccounted_orders,omitempty"` // Orders without a share amount
+}
+
+// PositionLotResponse represents the shares bought with one asset. Everything but shares is in that asset.
+type PositionLotResponse struct {
+	Asset           string `json:"asset"`
+	Shares          string `json:"shares"`
+	CostBasis       string `json:"cost_basis"`
+	SharePrice      string `json:"share_price"` // Current accountant rate in the asset
+	Value           string `json:"value"`
+	ValueBTC        string `json:"value_btc,omitempty"`
+	ValueUSD        string `json:"value_usd,omitempty"`
+	RealizedYield   string `json:"realized_yield"`
+	UnrealizedYield string `json:"unrealized_yield"`
+}
+
+// ExportRecord represents one event in a wallet activity export. Amounts are in whole tokens; BTC values
+// count every BTC asset at par.
+type ExportRecord struct {
+	EventType   string    `json:"event_type"` // "deposit", "withdrawal_requested", "withdrawal_completed", "transfer_in" or "transfer_out"
+	TxHash      string    `json:"tx_hash"`
+	LogIndex    uint      `json:"log_index"`
+	BlockNumber uint64    `json:"block_number"`
+	BlockTime   time.Time `json:"block_time"`
+	FromAsset   string    `json:"from_asset"` // Asset that left the wallet
+	FromAmount  string    `json:"from_amount"`
+	ToAsset     string    `json:"to_asset"`    // Asset that entered the wallet
+	ToAmount    string    `json:"to_amount"`   // Empty for withdrawal requests, which pay out on fulfilment
+	Shares      string    `json:"shares"`      // LBTCv moved by the event
+	SharePrice  string    `json:"share_price"` // BTC per LBTCv from the last rate snapshot at or before the block, if any
+	ValueBTC    string    `json:"value_btc"`
+}
+
+// VaultHistoryResponse represents a vault metric over time, one point per bucket
+type VaultHistoryResponse struct {
+	Metric     string              `json:"metric"`   // "tvl", "share_price" or "apy"
+	Interval   string              `json:"interval"` // "hour" or "day"
+	From       time.Time           `json:"from"`
+	To         time.Time           `json:"to"`
+	WindowDays int                 `json:"window_days,omitempty"` // APY only: the trailing window of each point
+	Points     []VaultHistoryPoint `json:"points"`
+}
+
+// VaultHistoryPoint represents the last snapshot in a bucket. TVL is in LBTCv, share price is one LBTCv
+// in the base asset, and APY is a percentage.
+type VaultHistoryPoint struct {
+	Timestamp   time.Time `json:"timestamp"` // Start of the bucket
+	BlockNumber uint64    `json:"block_number"`
+	Value       string    `json:"value"`
+}
+
+// CreateWebhookRequest represents the request body for registering a webhook subscription
+type CreateWebhookRequest struct {
+	URL           string   `json:"url" validate:"required"`
+	Secret        string   `json:"secret" validate:"required"`
+	WalletAddress string   `json:"wallet_address,omitempty"` // Omit to receive events for every wallet
+	EventTypes    []string `json:"event_types,omitempty"`    // Omit to receive every event type
+}
+
+// WebhookSubscriptionResponse represents a webhook subscription. The secret is never returned.
+type WebhookSubscriptionResponse struct {
+	ID            string    `json:"id"`
+	URL           string    `json:"url"`
+	WalletAddress *string   `json:"wallet_address,omitempty"`
+	EventTypes    []string  `json:"event_types"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// WebhookSubscriptionListResponse represents the list of webhook subscriptions
+type WebhookSubscriptionListResponse struct {
+	Subscriptions []WebhookSubscriptionResponse `json:"subscriptions"`
+}
+
+// WebhookDeliveryResponse represents an entry in a subscription's delivery log
+type WebhookDeliveryResponse struct {
+	ID             string          `json:"id"`
+	EventType      string          `json:"event_type"`
+	TxHash         string          `json:"tx_hash"`
+	LogIndex       uint64          `json:"log_index"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at,omitempty"`
+	LastAttemptAt  *time.Time      `json:"last_attempt_at,omitempty"`
+	ResponseStatus *int            `json:"response_status,omitempty"`
+	LastError      *string         `json:"last_error,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+	Payload        json.RawMessage `json:"payload"`
+}
+
+// WebhookDeliveryListResponse represents a subscription's delivery log
+type WebhookDeliveryListResponse struct {
+	Deliveries []WebhookDeliveryResponse `json:"deliveries"`
 }
 
 // ErrorResponse represents the API error response
 type ErrorResponse struct {
 	Error   string `json:"error"`
 	Message string `json:"message"`
+}
+
+// RelayRecordErrorResponse is returned when a transaction was broadcast but could not be recorded. The
+// transaction may still be mined, so it carries the hash to follow it by.
+type RelayRecordErrorResponse struct {
+	ErrorResponse
+	TxHash string `json:"tx_hash"`
 }
\ No newline at end of file
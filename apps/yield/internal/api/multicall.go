@@ -0,0 +1,144 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Multicall3 is deployed at the same address on every major chain
+const Multicall3Address = "0xcA11bde05977b3631167028862bE2a173976CA11"
+
+// Mainnet block Multicall3 was deployed at. Reads at earlier blocks are made one eth_call at a time.
+const Multicall3DeploymentBlock = 14353601
+
+// Multicall3ABI covers aggregate3, which runs every call even when some of them revert
+const Multicall3ABI = `[{
+	"inputs": [{
+		"components": [
+			{"internalType": "address", "name": "target", "type": "address"},
+			{"internalType": "bool", "name": "allowFailure", "type": "bool"},
+			{"internalType": "bytes", "name": "callData", "type": "bytes"}
+		],
+		"internalType": "struct Multicall3.Call3[]", "name": "calls", "type": "tuple[]"
+	}],
+	"name": "aggregate3",
+	"outputs": [{
+		"components": [
+			{"internalType": "bool", "name": "success", "type": "bool"},
+			{"internalType": "bytes", "name": "returnData", "type": "bytes"}
+		],
+		"internalType": "struct Multicall3.Result[]", "name": "returnData", "type": "tuple[]"
+	}],
+	"stateMutability": "payable",
+	"type": "function"
+}]`
+
+// MulticallCall is one read in a Multicall3 batch
+type MulticallCall struct {
+	Target       common.Address
+	AllowFailure bool
+	CallData     []byte
+}
+
+// MulticallResult is the outcome of one read in a Multicall3 batch
+type MulticallResult struct {
+	Success    bool
+	ReturnData []byte
+}
+
+// Multicaller batches contract reads into a single eth_call, so that they all see the same block
+type Multicaller struct {
+	client       *ethclient.Client
+	multicallABI abi.ABI
+}
+
+// NewMulticaller creates a new Multicaller
+func NewMulticaller(client *ethclient.Client) (*Multicaller, error) {
+	multicallABI, err := abi.JSON(strings.NewReader(Multicall3ABI))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Multicall3 ABI: %w", err)
+	}
+
+	return &Multicaller{
+		client:       client,
+		multicallABI: multicallABI,
+	}, nil
+}
+
+// Aggregate3 runs the calls at the block, or the latest block when block is nil. Results are in the
+// order of the calls; a call that reverts with AllowFailure set has Success false. Before Multicall3 was
+// deployed, the calls are made individually, still pinned to the block, so they see the same state.
+func (m *Multicaller) Aggregate3(ctx context.Context, calls []MulticallCall, block *big.Int) ([]MulticallResult, error) {
+	if block != nil && block.Cmp(big.NewInt(Multicall3DeploymentBlock)) < 0 {
+		return m.callEach(ctx, calls, block)
+	}
+
+	data, err := m.multicallABI.Pack("aggregate3", calls)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack aggregate3 call: %w", err)
+	}
+
+	multicall := common.HexToAddress(Multicall3Address)
+	result, err := m.client.CallContract(ctx, ethereum.CallMsg{To: &multicall, Data: data}, block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call aggregate3: %w", err)
+	}
+
+	values, err := m.multicallABI.Unpack("aggregate3", result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpack aggregate3 result: %w", err)
+	}
+
+	results := *abi.ConvertType(values[0], new([]MulticallResult)).(*[]MulticallResult)
+	if len(results) != len(calls) {
+		return nil, fmt.Errorf("aggregate3 returned %d results for %d calls", len(results), len(calls))
+	}
+
+	return results, nil
+}
+
+// callEach runs the calls one eth_call at a time, with the same results as aggregate3. A call reverting
+// without AllowFailure fails the batch, as it makes aggregate3 revert.
+func (m *Multicaller) callEach(ctx context.Context, calls []MulticallCall, block *big.Int) ([]MulticallResult, error) {
+	results := make([]MulticallResult, len(calls))
+	for i, call := range calls {
+		returnData, err := m.client.CallContract(ctx, ethereum.CallMsg{To: &call.Target, Data: call.CallData}, block)
+		if err != nil {
+			var dataErr rpc.DataError
+			if !errors.As(err, &dataErr) {
+				return nil, fmt.Errorf("failed to call %s: %w", call.Target.Hex(), err)
+			}
+			if !call.AllowFailure {
+				return nil, fmt.Errorf("call to %s reverted: %w", call.Target.Hex(), err)
+			}
+			continue
+		}
+		results[i] = MulticallResult{Success: true, ReturnData: returnData}
+	}
+
+	return results, nil
+}
+
+// unpackUint256 decodes a single uint256 returned by a batched call. It returns nil when the call failed
+// or did not return a uint256.
+func unpackUint256(contractABI abi.ABI, method string, result MulticallResult) *big.Int {
+	if !result.Success {
+		return nil
+	}
+
+	var value *big.Int
+	if err := contractABI.UnpackIntoInterface(&value, method, result.ReturnData); err != nil {
+		return nil
+	}
+
+	return value
+}
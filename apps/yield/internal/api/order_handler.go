@@ -1,26 +1,50 @@
 package api
 
 import (
+	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	"go.uber.org/zap"
+	"yield/apps/yield/internal/assets"
+	"yield/apps/yield/internal/config"
+	"yield/apps/yield/internal/model"
 	"yield/apps/yield/internal/repository"
 )
 
+const (
+	// Default and maximum number of orders returned per page
+	DefaultOrderPageSize = 50
+	MaxOrderPageSize     = 200
+)
+
 // OrderHandler handles order-related API endpoints
 type OrderHandler struct {
 	orderRepository            *repository.OrderRepository
 	monitoredAddressRepository *repository.MonitoredAddressRepository
+	transactionRepository      *repository.TransactionRepository
 	transactionBuilder         *TransactionBuilder
+	policyChecker              *PolicyChecker
 	logger                     *zap.Logger
 }
 
 // NewOrderHandler creates a new OrderHandler
-func NewOrderHandler(orderRepository *repository.OrderRepository, monitoredAddressRepository *repository.MonitoredAddressRepository, rpcURL string, logger *zap.Logger) (*OrderHandler, error) {
-	transactionBuilder, err := NewTransactionBuilder(rpcURL)
+func NewOrderHandler(orderRepository *repository.OrderRepository, monitoredAddressRepository *repository.MonitoredAddressRepository, transactionRepository *repository.TransactionRepository, rpcURL string, feeConfig config.FeeConfig, logger *zap.Logger) (*OrderHandler, error) {
+	transactionBuilder, err := NewTransactionBuilder(rpcURL, feeConfig, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	policyChecker, err := NewPolicyChecker(transactionBuilder.ethClient)
 	if err != nil {
 		return nil, err
 	}
@@ -28,7 +52,9 @@ func NewOrderHandler(orderRepository *repository.OrderRepository, monitoredAddre
 	return &OrderHandler{
 		orderRepository:            orderRepository,
 		monitoredAddressRepository: monitoredAddressRepository,
+		transactionRepository:      transactionRepository,
 		transactionBuilder:         transactionBuilder,
+		policyChecker:              policyChecker,
 		logger:                     logger,
 	}, nil
 }
@@ -56,18 +82,107 @@ func (h *OrderHandler) GetOrder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Convert to API response
-	response := OrderResponse{
-		OrderID:         order.OrderID,
-		TxHash:          order.TxHash,
-		WalletAddress:   order.WalletAddress,
-		FromAssetName:   order.FromAssetName,
-		ToAssetName:     order.ToAssetName,
-		TxDate:          order.TxDate,
-		Status:          order.Status,
-		TransferType:    order.TransferType,
-		Amount:          order.Amount,
-		EstimatedAmount: order.EstimatedAmount,
+	h.writeJSONResponse(w, http.StatusOK, toOrderResponse(*order))
+}
+
+// ListWalletOrders handles GET /api/wallets/{address}/orders
+func (h *OrderHandler) ListWalletOrders(w http.ResponseWriter, r *http.Request) {
+	walletAddress := mux.Vars(r)["address"]
+
+	// Validate Ethereum address format
+	if !common.IsHexAddress(walletAddress) {
+		h.writeErrorResponse(w, http.StatusBadRequest, "invalid_wallet_address", "Invalid Ethereum address format")
+		return
+	}
+
+	query := r.URL.Query()
+	filter := repository.OrderFilter{
+		// Orders are stored with checksummed addresses
+		WalletAddress: common.HexToAddress(walletAddress).Hex(),
+		AssetName:     query.Get("asset"),
+		Limit:         DefaultOrderPageSize,
+	}
+
+	switch transferType := strings.ToLower(query.Get("transfer_type")); transferType {
+	case "", "deposit", "withdrawal", "transfer_in", "transfer_out":
+		filter.TransferType = transferType
+	default:
+		h.writeErrorResponse(w, http.StatusBadRequest, "invalid_transfer_type", "Transfer type must be deposit, withdrawal, transfer_in or transfer_out")
+		return
+	}
+
+	switch status := strings.ToLower(query.Get("status")); status {
+	case "", "completed", "in_progress":
+		filter.Status = status
+	default:
+		h.writeErrorResponse(w, http.StatusBadRequest, "invalid_status", "Status must be completed or in_progress")
+		return
+	}
+
+	switch sortOrder := strings.ToLower(query.Get("sort")); sortOrder {
+	case "", "desc":
+	case "asc":
+		filter.Ascending = true
+	default:
+		h.writeErrorResponse(w, http.StatusBadRequest, "invalid_sort", "Sort must be asc or desc")
+		return
+	}
+
+	if value := query.Get("from"); value != "" {
+		from, err := parseDateParam(value)
+		if err != nil {
+			h.writeErrorResponse(w, http.StatusBadRequest, "invalid_from", "From must be an RFC 3339 timestamp or a YYYY-MM-DD date")
+			return
+		}
+		filter.From = &from
+	}
+
+	if value := query.Get("to"); value != "" {
+		to, err := parseDateParam(value)
+		if err != nil {
+			h.writeErrorResponse(w, http.StatusBadRequest, "invalid_to", "To must be an RFC 3339 timestamp or a YYYY-MM-DD date")
+			return
+		}
+		filter.To = &to
+	}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > MaxOrderPageSize {
+			h.writeErrorResponse(w, http.StatusBadRequest, "invalid_limit", fmt.Sprintf("Limit must be between 1 and %d", MaxOrderPageSize))
+			return
+		}
+		filter.Limit = limit
+	}
+
+	if value := query.Get("cursor"); value != "" {
+		cursor, err := decodeOrderCursor(value)
+		if err != nil {
+			h.writeErrorResponse(w, http.StatusBadRequest, "invalid_cursor", "Cursor is malformed")
+			return
+		}
+		filter.After = cursor
+	}
+
+	// Fetch one extra order to find out whether there is a next page
+	pageSize := filter.Limit
+	filter.Limit++
+	orders, err := h.orderRepository.ListOrdersByWallet(filter)
+	if err != nil {
+		h.logger.Error("Failed to list orders", zap.String("wallet_address", walletAddress), zap.Error(err))
+		h.writeErrorResponse(w, http.StatusInternalServerError, "database_error", "Failed to retrieve orders")
+		return
+	}
+
+	response := OrderListResponse{Orders: make([]OrderResponse, 0, len(orders))}
+	if len(orders) > pageSize {
+		orders = orders[:pageSize]
+		last := orders[len(orders)-1]
+		response.NextCursor = encodeOrderCursor(repository.OrderCursor{TxDate: last.TxDate, OrderID: last.OrderID})
+	}
+
+	for _, order := range orders {
+		response.Orders = append(response.Orders, toOrderResponse(order))
 	}
 
 	h.writeJSONResponse(w, http.StatusOK, response)
@@ -106,6 +221,53 @@ func (h *OrderHandler) CreateDeposit(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	depositAmount, ok := h.parseAmount(w, req.Amount, normalizedAssetName)
+	if !ok {
+		return
+	}
+
+	txOptions, ok := h.parseTransactionOptions(w, req.TxType, req.FeeUrgency)
+	if !ok {
+		return
+	}
+
+	slippageBps, ok := h.parseSlippage(w, req.SlippageBps, DefaultSlippageBps, MaxSlippageBps)
+	if !ok {
+		return
+	}
+
+	txOptions.OutputFormat, ok = h.parseOutputFormat(w, req.OutputFormat)
+	if !ok {
+		return
+	}
+
+	var permit PermitSignature
+	if req.Permit != nil {
+		if txOptions.OutputFormat != OutputFormatTransaction {
+			h.writeErrorResponse(w, http.StatusBadRequest, "permit_not_supported", "Permits can only be used with the transaction output format")
+			return
+		}
+		if !h.supportsPermit(normalizedAssetName) {
+			h.writeErrorResponse(w, http.StatusBadRequest, "permit_not_supported", fmt.Sprintf("%s does not support permit", normalizedAssetName))
+			return
+		}
+		if req.Permit.Deadline <= time.Now().Unix() {
+			h.writeErrorResponse(w, http.StatusBadRequest, "invalid_deadline", "Permit deadline must be in the future")
+			return
+		}
+		signature, err := DecodePermitSignature(req.Permit.Signature)
+		if err != nil {
+			h.writeErrorResponse(w, http.StatusBadRequest, "invalid_permit_signature", "Permit signature must be a 0x-prefixed 65 byte hex string")
+			return
+		}
+		permit = PermitSignature{Signature: signature, Deadline: big.NewInt(req.Permit.Deadline)}
+	}
+
+	asset, _ := assets.GlobalRegistry.GetBySymbol(normalizedAssetName)
+	if !h.checkPolicy(w, h.policyChecker.CheckDeposit(r.Context(), asset, depositAmount), req.WalletAddress) {
+		return
+	}
+
 	// Add wallet address to monitored addresses (chain_id = 1 for Ethereum mainnet)
 	if err := h.monitoredAddressRepository.AddMonitoredAddress(req.WalletAddress, 1); err != nil {
 		h.logger.Error("Failed to add wallet to monitored addresses", zap.Error(err))
@@ -113,30 +275,57 @@ func (h *OrderHandler) CreateDeposit(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Create unsigned transaction
-	unsignedTx, err := h.transactionBuilder.BuildDepositTransaction(normalizedAssetName, req.Amount, req.WalletAddress)
+	// Create unsigned transaction, a single depositWithPermit when the wallet signed a permit
+	var steps []TransactionStep
+	var quote *Quote
+	var err error
+	if req.Permit != nil {
+		steps, quote, err = h.transactionBuilder.BuildDepositWithPermitTransaction(normalizedAssetName, req.Amount, req.WalletAddress, permit, slippageBps, txOptions)
+	} else {
+		steps, quote, err = h.transactionBuilder.BuildDepositTransaction(normalizedAssetName, req.Amount, req.WalletAddress, slippageBps, txOptions)
+	}
 	if err != nil {
+		if errors.Is(err, ErrInvalidPermitSignature) {
+			h.writeErrorResponse(w, http.StatusBadRequest, "invalid_permit_signature", "Permit signature was not signed by the wallet for this amount and deadline")
+			return
+		}
+		if errors.Is(err, ErrNotSmartAccount) {
+			h.writeErrorResponse(w, http.StatusBadRequest, "not_smart_account", fmt.Sprintf("Wallet is not a %s account", txOptions.OutputFormat))
+			return
+		}
+		var simulationErr *SimulationError
+		if errors.As(err, &simulationErr) {
+			h.logger.Info("Deposit transaction would revert", zap.String("wallet_address", req.WalletAddress), zap.String("code", simulationErr.Code), zap.String("reason", simulationErr.Reason))
+			h.writeErrorResponse(w, http.StatusUnprocessableEntity, simulationErr.Code, "Transaction would revert: "+simulationErr.Reason)
+			return
+		}
 		h.logger.Error("Failed to build deposit transaction", zap.Error(err))
 		h.writeErrorResponse(w, http.StatusInternalServerError, "transaction_build_error", "Failed to build transaction")
 		return
 	}
 
-	// Serialize unsigned transaction
-	unsignedTxJSON, err := json.Marshal(unsignedTx)
-	if err != nil {
-		h.logger.Error("Failed to marshal unsigned transaction", zap.Error(err))
-		h.writeErrorResponse(w, http.StatusInternalServerError, "serialization_error", "Failed to serialize transaction")
+	if !h.recordBuiltTransactions(w, req.WalletAddress, steps) {
+		return
+	}
+
+	unsignedTxJSON, ok := h.marshalActionTransaction(w, steps)
+	if !ok {
 		return
 	}
 
 	response := DepositResponse{
-		UnsignedTransaction: string(unsignedTxJSON),
+		UnsignedTransaction: unsignedTxJSON,
+		ApprovalRequired:    approvalRequired(steps),
+		Transactions:        steps,
+		PriceProtection:     toPriceProtectionResponse(&quote.PriceProtection),
 	}
 
 	h.logger.Info("Built deposit transaction",
 		zap.String("wallet_address", req.WalletAddress),
 		zap.String("from_asset", normalizedAssetName),
-		zap.String("amount", req.Amount))
+		zap.String("amount", req.Amount),
+		zap.String("minimum_mint", quote.MinimumAmount.String()),
+		zap.Bool("permit", req.Permit != nil))
 
 	h.writeJSONResponse(w, http.StatusCreated, response)
 }
@@ -174,6 +363,31 @@ func (h *OrderHandler) CreateWithdrawal(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	// The amount is the LBTCv withdrawn
+	if _, ok := h.parseAmount(w, req.Amount, "LBTCv"); !ok {
+		return
+	}
+
+	txOptions, ok := h.parseTransactionOptions(w, req.TxType, req.FeeUrgency)
+	if !ok {
+		return
+	}
+
+	slippageBps, ok := h.parseSlippage(w, req.SlippageBps, DefaultWithdrawalSlippageBps, MaxWithdrawalSlippageBps)
+	if !ok {
+		return
+	}
+
+	txOptions.OutputFormat, ok = h.parseOutputFormat(w, req.OutputFormat)
+	if !ok {
+		return
+	}
+
+	asset, _ := assets.GlobalRegistry.GetBySymbol(normalizedAssetName)
+	if !h.checkPolicy(w, h.policyChecker.CheckWithdrawal(r.Context(), asset, common.HexToAddress(req.WalletAddress)), req.WalletAddress) {
+		return
+	}
+
 	// Add wallet address to monitored addresses (chain_id = 1 for Ethereum mainnet)
 	if err := h.monitoredAddressRepository.AddMonitoredAddress(req.WalletAddress, 1); err != nil {
 		h.logger.Error("Failed to add wallet to monitored addresses", zap.Error(err))
@@ -182,14 +396,112 @@ func (h *OrderHandler) CreateWithdrawal(w http.ResponseWriter, r *http.Request)
 	}
 
 	// Create unsigned transaction for withdrawal
-	unsignedTx, err := h.transactionBuilder.BuildWithdrawalTransaction(normalizedAssetName, req.Amount, req.WalletAddress)
+	steps, quote, err := h.transactionBuilder.BuildWithdrawalTransaction(normalizedAssetName, req.Amount, req.WalletAddress, slippageBps, txOptions)
 	if err != nil {
+		if errors.Is(err, ErrNotSmartAccount) {
+			h.writeErrorResponse(w, http.StatusBadRequest, "not_smart_account", fmt.Sprintf("Wallet is not a %s account", txOptions.OutputFormat))
+			return
+		}
+		var simulationErr *SimulationError
+		if errors.As(err, &simulationErr) {
+			h.logger.Info("Withdrawal transaction would revert", zap.String("wallet_address", req.WalletAddress), zap.String("code", simulationErr.Code), zap.String("reason", simulationErr.Reason))
+			h.writeErrorResponse(w, http.StatusUnprocessableEntity, simulationErr.Code, "Transaction would revert: "+simulationErr.Reason)
+			return
+		}
 		h.logger.Error("Failed to build withdrawal transaction", zap.Error(err))
 		h.writeErrorResponse(w, http.StatusInternalServerError, "transaction_build_error", "Failed to build transaction")
 		return
 	}
 
-	// Serialize unsigned transaction
+	if !h.recordBuiltTransactions(w, req.WalletAddress, steps) {
+		return
+	}
+
+	unsignedTxJSON, ok := h.marshalActionTransaction(w, steps)
+	if !ok {
+		return
+	}
+
+	response := WithdrawalResponse{
+		UnsignedTransaction: unsignedTxJSON,
+		ApprovalRequired:    approvalRequired(steps),
+		Transactions:        steps,
+		PriceProtection:     toPriceProtectionResponse(&quote.PriceProtection),
+	}
+
+	h.logger.Info("Built withdrawal transaction",
+		zap.String("wallet_address", req.WalletAddress),
+		zap.String("to_asset", normalizedAssetName),
+		zap.String("amount", req.Amount),
+		zap.String("minimum_amount", quote.MinimumAmount.String()))
+
+	h.writeJSONResponse(w, http.StatusCreated, response)
+}
+
+// CreateApproval handles POST /api/approvals
+func (h *OrderHandler) CreateApproval(w http.ResponseWriter, r *http.Request) {
+	var req ApprovalRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "invalid_request_body", "Invalid JSON in request body")
+		return
+	}
+
+	if req.AssetName == "" {
+		h.writeErrorResponse(w, http.StatusBadRequest, "missing_asset_name", "Asset name is required")
+		return
+	}
+
+	if req.WalletAddress == "" {
+		h.writeErrorResponse(w, http.StatusBadRequest, "missing_wallet_address", "Wallet address is required")
+		return
+	}
+
+	if !common.IsHexAddress(req.WalletAddress) {
+		h.writeErrorResponse(w, http.StatusBadRequest, "invalid_wallet_address", "Invalid Ethereum address format")
+		return
+	}
+
+	// Approvals cover the deposit assets and the vault token itself
+	asset, exists := assets.GlobalRegistry.GetBySymbol(strings.ToUpper(req.AssetName))
+	if !exists && strings.EqualFold(req.AssetName, "LBTCv") {
+		asset, exists = assets.GlobalRegistry.GetBySymbol("LBTCv")
+	}
+	if !exists {
+		h.writeErrorResponse(w, http.StatusBadRequest, "unsupported_asset", "Asset not supported. Supported assets: LBTC, CBTC, WBTC, LBTCv")
+		return
+	}
+
+	var amount *big.Int
+	if req.Amount != "" && !strings.EqualFold(req.Amount, "max") {
+		parsed, err := h.transactionBuilder.parseAmount(req.Amount, asset.Symbol)
+		if err != nil {
+			h.writeErrorResponse(w, http.StatusBadRequest, "invalid_amount", fmt.Sprintf("Invalid amount: %s. Omit it or use \"max\" for an unlimited approval", err))
+			return
+		}
+		amount = parsed
+	}
+
+	txOptions, ok := h.parseTransactionOptions(w, req.TxType, req.FeeUrgency)
+	if !ok {
+		return
+	}
+
+	unsignedTx, spender, allowance, err := h.transactionBuilder.BuildApprovalTransaction(asset.Symbol, amount, req.WalletAddress, txOptions)
+	if err != nil {
+		var simulationErr *SimulationError
+		if errors.As(err, &simulationErr) {
+			h.writeErrorResponse(w, http.StatusUnprocessableEntity, simulationErr.Code, "Transaction would revert: "+simulationErr.Reason)
+			return
+		}
+		h.logger.Error("Failed to build approval transaction", zap.Error(err))
+		h.writeErrorResponse(w, http.StatusInternalServerError, "transaction_build_error", "Failed to build transaction")
+		return
+	}
+
+	if !h.recordBuiltTransactions(w, req.WalletAddress, []TransactionStep{{Type: StepApprove, UnsignedTransaction: unsignedTx}}) {
+		return
+	}
+
 	unsignedTxJSON, err := json.Marshal(unsignedTx)
 	if err != nil {
 		h.logger.Error("Failed to marshal unsigned transaction", zap.Error(err))
@@ -197,18 +509,312 @@ func (h *OrderHandler) CreateWithdrawal(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	response := WithdrawalResponse{
+	response := ApprovalResponse{
 		UnsignedTransaction: string(unsignedTxJSON),
+		Spender:             spender.Hex(),
+		CurrentAllowance:    allowance.String(),
 	}
 
-	h.logger.Info("Built withdrawal transaction",
+	h.logger.Info("Built approval transaction",
 		zap.String("wallet_address", req.WalletAddress),
-		zap.String("to_asset", normalizedAssetName),
-		zap.String("amount", req.Amount))
+		zap.String("asset", asset.Symbol),
+		zap.String("spender", spender.Hex()))
 
 	h.writeJSONResponse(w, http.StatusCreated, response)
 }
 
+// CreatePermit handles POST /api/permits
+func (h *OrderHandler) CreatePermit(w http.ResponseWriter, r *http.Request) {
+	var req PermitRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "invalid_request_body", "Invalid JSON in request body")
+		return
+	}
+
+	if req.AssetName == "" {
+		h.writeErrorResponse(w, http.StatusBadRequest, "missing_asset_name", "Asset name is required")
+		return
+	}
+
+	if req.Amount == "" {
+		h.writeErrorResponse(w, http.StatusBadRequest, "missing_amount", "Amount is required")
+		return
+	}
+
+	if req.WalletAddress == "" {
+		h.writeErrorResponse(w, http.StatusBadRequest, "missing_wallet_address", "Wallet address is required")
+		return
+	}
+
+	if !common.IsHexAddress(req.WalletAddress) {
+		h.writeErrorResponse(w, http.StatusBadRequest, "invalid_wallet_address", "Invalid Ethereum address format")
+		return
+	}
+
+	normalizedAssetName := strings.ToUpper(req.AssetName)
+	if !h.transactionBuilder.IsAssetSupported(normalizedAssetName) {
+		h.writeErrorResponse(w, http.StatusBadRequest, "unsupported_asset", "Asset not supported. Supported assets: LBTC, CBTC, WBTC")
+		return
+	}
+
+	if !h.supportsPermit(normalizedAssetName) {
+		h.writeErrorResponse(w, http.StatusBadRequest, "permit_not_supported", fmt.Sprintf("%s does not support permit, use an approval transaction instead", normalizedAssetName))
+		return
+	}
+
+	amount, ok := h.parseAmount(w, req.Amount, normalizedAssetName)
+	if !ok {
+		return
+	}
+
+	deadline := req.Deadline
+	if deadline == 0 {
+		deadline = time.Now().Add(DefaultPermitValidity).Unix()
+	} else if deadline <= time.Now().Unix() {
+		h.writeErrorResponse(w, http.StatusBadRequest, "invalid_deadline", "Permit deadline must be in the future")
+		return
+	}
+
+	typedData, err := h.transactionBuilder.BuildPermitTypedData(normalizedAssetName, amount, req.WalletAddress, big.NewInt(deadline))
+	if err != nil {
+		h.logger.Error("Failed to build permit typed data", zap.Error(err))
+		h.writeErrorResponse(w, http.StatusInternalServerError, "permit_build_error", "Failed to build permit")
+		return
+	}
+
+	response := PermitResponse{
+		TypedData: typedData.Map(),
+		Spender:   assets.LBTCVAddress.Hex(),
+		Deadline:  deadline,
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, response)
+}
+
+// supportsPermit reports whether the deposit asset implements EIP-2612 permits
+func (h *OrderHandler) supportsPermit(assetName string) bool {
+	asset, exists := assets.GlobalRegistry.GetBySymbol(assetName)
+	return exists && asset.SupportsPermit
+}
+
+// recordBuiltTransactions remembers the transactions returned to a wallet so that they can be relayed
+// once signed, writing an error response if they cannot be recorded
+func (h *OrderHandler) recordBuiltTransactions(w http.ResponseWriter, walletAddress string, steps []TransactionStep) bool {
+	chainID, _ := strconv.ParseInt(EthereumChainID, 10, 64)
+
+	for _, step := range steps {
+		// Smart account steps are executed by the account, not relayed
+		if step.UnsignedTransaction == nil {
+			continue
+		}
+
+		err := h.transactionRepository.RecordBuiltTransaction(model.BuiltTransaction{
+			WalletAddress: common.HexToAddress(walletAddress).Hex(),
+			StepType:      step.Type,
+			ToAddress:     step.UnsignedTransaction.To,
+			Data:          step.UnsignedTransaction.Data,
+			ChainID:       chainID,
+		})
+		if err != nil {
+			h.logger.Error("Failed to record built transaction", zap.String("wallet_address", walletAddress), zap.Error(err))
+			h.writeErrorResponse(w, http.StatusInternalServerError, "database_error", "Failed to record transaction")
+			return false
+		}
+	}
+
+	return true
+}
+
+// parseTransactionOptions validates the transaction type and fee urgency of a build request, writing
+// an error response if either is invalid. The fee urgency is ignored for legacy transactions.
+func (h *OrderHandler) parseTransactionOptions(w http.ResponseWriter, txType, feeUrgency string) (TransactionOptions, bool) {
+	opts := TransactionOptions{
+		TxType:     strings.ToLower(txType),
+		FeeUrgency: strings.ToLower(feeUrgency),
+	}
+
+	if opts.TxType == "" {
+		opts.TxType = TxTypeLegacy
+	}
+	if opts.TxType != TxTypeLegacy && opts.TxType != TxTypeEIP1559 {
+		h.writeErrorResponse(w, http.StatusBadRequest, "invalid_tx_type", "Transaction type must be legacy or eip1559")
+		return opts, false
+	}
+
+	if opts.FeeUrgency == "" {
+		opts.FeeUrgency = FeeUrgencyNormal
+	}
+	if !IsValidFeeUrgency(opts.FeeUrgency) {
+		h.writeErrorResponse(w, http.StatusBadRequest, "invalid_fee_urgency", "Fee urgency must be slow, normal or fast")
+		return opts, false
+	}
+
+	return opts, true
+}
+
+// checkPolicy writes an error response when a pre-flight policy check rejected the request. When the vault
+// contracts could not be read the request fails with 503 rather than building a transaction that a paused
+// or capped vault might revert.
+func (h *OrderHandler) checkPolicy(w http.ResponseWriter, err error, walletAddress string) bool {
+	if err == nil {
+		return true
+	}
+
+	var policyErr *PolicyError
+	if errors.As(err, &policyErr) {
+		h.logger.Info("Request rejected by vault policy", zap.String("wallet_address", walletAddress), zap.String("code", policyErr.Code), zap.String("reason", policyErr.Reason))
+		h.writeErrorResponse(w, http.StatusUnprocessableEntity, policyErr.Code, "Transaction would revert: "+policyErr.Reason)
+		return false
+	}
+
+	h.logger.Error("Failed to run pre-flight policy checks", zap.String("wallet_address", walletAddress), zap.Error(err))
+	h.writeErrorResponse(w, http.StatusServiceUnavailable, "policy_unavailable", "Vault state could not be read to check the request, please retry")
+	return false
+}
+
+// parseAmount converts a decimal amount of the asset to its smallest units, writing an error response
+// that explains why the amount is invalid
+func (h *OrderHandler) parseAmount(w http.ResponseWriter, value, symbol string) (*big.Int, bool) {
+	amount, err := h.transactionBuilder.parseAmount(value, symbol)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "invalid_amount", "Invalid amount: "+err.Error())
+		return nil, false
+	}
+
+	return amount, true
+}
+
+// parseOutputFormat validates the output format of a build request, writing an error response if it is
+// not supported. Requests without a format get unsigned transactions.
+func (h *OrderHandler) parseOutputFormat(w http.ResponseWriter, outputFormat string) (string, bool) {
+	outputFormat = strings.ToLower(outputFormat)
+	if outputFormat == "" {
+		return OutputFormatTransaction, true
+	}
+
+	if !IsValidOutputFormat(outputFormat) {
+		h.writeErrorResponse(w, http.StatusBadRequest, "invalid_output_format", "Output format must be transaction, safe or erc4337")
+		return "", false
+	}
+
+	return outputFormat, true
+}
+
+// marshalActionTransaction serializes the unsigned action transaction, which is always the last step,
+// writing an error response if it cannot be serialized. Smart account steps have no unsigned
+// transaction, so it is empty for them.
+func (h *OrderHandler) marshalActionTransaction(w http.ResponseWriter, steps []TransactionStep) (string, bool) {
+	actionTx := steps[len(steps)-1].UnsignedTransaction
+	if actionTx == nil {
+		return "", true
+	}
+
+	unsignedTxJSON, err := json.Marshal(actionTx)
+	if err != nil {
+		h.logger.Error("Failed to marshal unsigned transaction", zap.Error(err))
+		h.writeErrorResponse(w, http.StatusInternalServerError, "serialization_error", "Failed to serialize transaction")
+		return "", false
+	}
+
+	return string(unsignedTxJSON), true
+}
+
+// approvalRequired reports whether the steps approve the asset, either as a separate transaction or
+// batched into a smart account step
+func approvalRequired(steps []TransactionStep) bool {
+	for _, step := range steps {
+		if step.Type == StepApprove {
+			return true
+		}
+		for _, call := range step.Calls {
+			if call == StepApprove {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parseSlippage validates the slippage tolerance of a build request, writing an error response if it is
+// out of range. Requests without a tolerance get defaultBps.
+func (h *OrderHandler) parseSlippage(w http.ResponseWriter, slippageBps *int64, defaultBps, maxBps int64) (int64, bool) {
+	if slippageBps == nil {
+		return defaultBps, true
+	}
+
+	if !IsValidSlippage(*slippageBps, maxBps) {
+		h.writeErrorResponse(w, http.StatusBadRequest, "invalid_slippage", fmt.Sprintf("Slippage must be between 0 and %d basis points", maxBps))
+		return 0, false
+	}
+
+	return *slippageBps, true
+}
+
+// toPriceProtectionResponse converts price protection to its API representation
+func toPriceProtectionResponse(protection *PriceProtection) PriceProtectionResponse {
+	return PriceProtectionResponse{
+		Rate:           formatUnits(protection.Rate, protection.RateDecimals),
+		ExpectedAmount: formatUnits(protection.ExpectedAmount, protection.OutputDecimals),
+		MinimumAmount:  formatUnits(protection.MinimumAmount, protection.OutputDecimals),
+		SlippageBps:    protection.SlippageBps,
+	}
+}
+
+// toOrderResponse converts an order to its API representation
+func toOrderResponse(order model.Order) OrderResponse {
+	return OrderResponse{
+		OrderID:         order.OrderID,
+		TxHash:          order.TxHash,
+		WalletAddress:   order.WalletAddress,
+		FromAssetName:   order.FromAssetName,
+		ToAssetName:     order.ToAssetName,
+		TxDate:          order.TxDate,
+		Status:          order.Status,
+		TransferType:    order.TransferType,
+		Amount:          order.Amount,
+		EstimatedAmount: order.EstimatedAmount,
+		ShareAmount:     order.ShareAmount,
+	}
+}
+
+// parseDateParam parses a query parameter given either as an RFC 3339 timestamp or as a date
+func parseDateParam(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
+
+// encodeOrderCursor encodes the position of an order as an opaque pagination cursor
+func encodeOrderCursor(cursor repository.OrderCursor) string {
+	raw := cursor.TxDate.UTC().Format(time.RFC3339Nano) + "|" + cursor.OrderID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeOrderCursor decodes a pagination cursor produced by encodeOrderCursor
+func decodeOrderCursor(value string) (*repository.OrderCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := strings.SplitN(string(raw), "|", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+
+	txDate, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := uuid.Parse(parts[1]); err != nil {
+		return nil, err
+	}
+
+	return &repository.OrderCursor{TxDate: txDate, OrderID: parts[1]}, nil
+}
+
 // writeJSONResponse writes a JSON response with the specified status code
 func (h *OrderHandler) writeJSONResponse(w http.ResponseWriter, statusCode int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
@@ -0,0 +1,177 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+	"yield/apps/yield/internal/model"
+	"yield/apps/yield/internal/order_stream"
+)
+
+const (
+	// Interval between keep-alive comments on an idle stream
+	streamHeartbeatInterval = 15 * time.Second
+
+	// Number of missed updates read from the update log per query when resuming a stream
+	streamReplayPageSize = 500
+
+	// Reconnection delay suggested to EventSource clients, in milliseconds
+	streamRetryMillis = 3000
+)
+
+// OrderStreamHandler streams order updates to clients over Server-Sent Events
+type OrderStreamHandler struct {
+	broker *order_stream.Broker
+	logger *zap.Logger
+}
+
+// NewOrderStreamHandler creates a new OrderStreamHandler
+func NewOrderStreamHandler(broker *order_stream.Broker, logger *zap.Logger) *OrderStreamHandler {
+	return &OrderStreamHandler{
+		broker: broker,
+		logger: logger,
+	}
+}
+
+// StreamWalletOrders handles GET /api/wallets/{address}/orders/stream
+//
+// Every update is sent as an SSE event whose id is the update's position in the update log. Clients
+// that reconnect with a Last-Event-ID header (or last_event_id query parameter) first receive the
+// updates they missed, then live updates.
+func (h *OrderStreamHandler) StreamWalletOrders(w http.ResponseWriter, r *http.Request) {
+	walletAddress := mux.Vars(r)["address"]
+
+	// Validate Ethereum address format
+	if !common.IsHexAddress(walletAddress) {
+		h.writeErrorResponse(w, http.StatusBadRequest, "invalid_wallet_address", "Invalid Ethereum address format")
+		return
+	}
+	// Orders are stored with checksummed addresses
+	walletAddress = common.HexToAddress(walletAddress).Hex()
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+
+	var lastSentID int64
+	resume := lastEventID != ""
+	if resume {
+		parsed, err := strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil || parsed < 0 {
+			h.writeErrorResponse(w, http.StatusBadRequest, "invalid_last_event_id", "Last event ID must be a non-negative integer")
+			return
+		}
+		lastSentID = parsed
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		h.writeErrorResponse(w, http.StatusInternalServerError, "streaming_unsupported", "Streaming is not supported")
+		return
+	}
+
+	// Streams outlive the server's write timeout
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		h.logger.Warn("Failed to clear write deadline for order stream", zap.Error(err))
+	}
+
+	// Subscribe before replaying so that no update falls between the replay and the live stream
+	subscription := h.broker.Subscribe(walletAddress)
+	defer h.broker.Unsubscribe(subscription)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", streamRetryMillis)
+	flusher.Flush()
+
+	if resume {
+		for {
+			updates, err := h.broker.Replay(walletAddress, lastSentID, streamReplayPageSize)
+			if err != nil {
+				h.logger.Error("Failed to replay order updates", zap.String("wallet_address", walletAddress), zap.Error(err))
+				return
+			}
+
+			for _, update := range updates {
+				if err := h.writeUpdate(w, update); err != nil {
+					return
+				}
+				lastSentID = update.ID
+			}
+			flusher.Flush()
+
+			if len(updates) < streamReplayPageSize {
+				break
+			}
+		}
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case update, open := <-subscription.Updates:
+			if !open {
+				// Dropped for falling behind; the client resumes from the log on reconnect
+				return
+			}
+			if update.ID <= lastSentID {
+				continue // Already sent during replay
+			}
+			if err := h.writeUpdate(w, update); err != nil {
+				return
+			}
+			lastSentID = update.ID
+			flusher.Flush()
+		}
+	}
+}
+
+// writeUpdate writes a single order update as an SSE event
+func (h *OrderStreamHandler) writeUpdate(w http.ResponseWriter, update model.OrderUpdate) error {
+	data, err := json.Marshal(toOrderResponse(update.Order))
+	if err != nil {
+		h.logger.Error("Failed to encode order update", zap.Int64("id", update.ID), zap.Error(err))
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", update.ID, update.UpdateType, data)
+	return err
+}
+
+// writeJSONResponse writes a JSON response with the specified status code
+func (h *OrderStreamHandler) writeJSONResponse(w http.ResponseWriter, statusCode int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		h.logger.Error("Failed to encode JSON response", zap.Error(err))
+	}
+}
+
+// writeErrorResponse writes an error response
+func (h *OrderStreamHandler) writeErrorResponse(w http.ResponseWriter, statusCode int, errorCode, message string) {
+	errorResponse := ErrorResponse{
+		Error:   errorCode,
+		Message: message,
+	}
+	h.writeJSONResponse(w, statusCode, errorResponse)
+}
@@ -0,0 +1,266 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"yield/apps/yield/internal/assets"
+)
+
+// How long a permit stays valid when the client does not choose a deadline
+const DefaultPermitValidity = time.Hour
+
+// PermitABI covers the EIP-2612 and EIP-5267 views needed to build permit typed data
+const PermitABI = `[
+	{"type": "function", "name": "nonces", "stateMutability": "view",
+		"inputs": [{"name": "owner", "type": "address"}],
+		"outputs": [{"name": "", "type": "uint256"}]},
+	{"type": "function", "name": "name", "stateMutability": "view",
+		"inputs": [],
+		"outputs": [{"name": "", "type": "string"}]},
+	{"type": "function", "name": "DOMAIN_SEPARATOR", "stateMutability": "view",
+		"inputs": [],
+		"outputs": [{"name": "", "type": "bytes32"}]},
+	{"type": "function", "name": "eip712Domain", "stateMutability": "view",
+		"inputs": [],
+		"outputs": [
+			{"name": "fields", "type": "bytes1"},
+			{"name": "name", "type": "string"},
+			{"name": "version", "type": "string"},
+			{"name": "chainId", "type": "uint256"},
+			{"name": "verifyingContract", "type": "address"},
+			{"name": "salt", "type": "bytes32"},
+			{"name": "extensions", "type": "uint256[]"}
+		]}
+]`
+
+// ErrInvalidPermitSignature is returned when a permit signature is malformed or was not made by the
+// depositing wallet
+var ErrInvalidPermitSignature = errors.New("permit signature does not match wallet")
+
+// PermitSignature is an EIP-2612 permit signed by the depositing wallet
+type PermitSignature struct {
+	Signature []byte // 65 byte r || s || v signature
+	Deadline  *big.Int
+}
+
+// BuildPermitTypedData returns the EIP-712 typed data of a permit allowing the vault to pull the amount
+// of the asset from the wallet until the deadline
+func (tb *TransactionBuilder) BuildPermitTypedData(assetName string, amount *big.Int, walletAddress string, deadline *big.Int) (*apitypes.TypedData, error) {
+	asset, err := tb.getPermitAsset(assetName)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	owner := common.HexToAddress(walletAddress)
+
+	domain, err := tb.getPermitDomain(ctx, asset.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce, err := tb.callPermitView(ctx, asset.Address, "nonces", owner)
+	if err != nil {
+		return nil, err
+	}
+
+	typedData := &apitypes.TypedData{
+		Types: apitypes.Types{
+			"EIP712Domain": {
+				{Name: "name", Type: "string"},
+				{Name: "version", Type: "string"},
+				{Name: "chainId", Type: "uint256"},
+				{Name: "verifyingContract", Type: "address"},
+			},
+			"Permit": {
+				{Name: "owner", Type: "address"},
+				{Name: "spender", Type: "address"},
+				{Name: "value", Type: "uint256"},
+				{Name: "nonce", Type: "uint256"},
+				{Name: "deadline", Type: "uint256"},
+			},
+		},
+		PrimaryType: "Permit",
+		Domain:      *domain,
+		Message: apitypes.TypedDataMessage{
+			"owner":    owner.Hex(),
+			"spender":  assets.LBTCVAddress.Hex(), // The vault pulls deposits
+			"value":    amount.String(),
+			"nonce":    nonce[0].(*big.Int).String(),
+			"deadline": deadline.String(),
+		},
+	}
+
+	// Make sure the domain we assembled is the one the token verifies signatures against
+	domainSeparator, err := typedData.HashStruct("EIP712Domain", typedData.Domain.Map())
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash permit domain: %w", err)
+	}
+	onChainSeparator, err := tb.callPermitView(ctx, asset.Address, "DOMAIN_SEPARATOR")
+	if err != nil {
+		return nil, err
+	}
+	if separator := onChainSeparator[0].([32]byte); !bytes.Equal(domainSeparator, separator[:]) {
+		return nil, fmt.Errorf("permit domain for %s does not match its DOMAIN_SEPARATOR", asset.Symbol)
+	}
+
+	return typedData, nil
+}
+
+// BuildDepositWithPermitTransaction creates a single depositWithPermit transaction. The permit signature
+// is checked against the wallet before the transaction is built, so no approval transaction is needed.
+func (tb *TransactionBuilder) BuildDepositWithPermitTransaction(assetName, amount, walletAddress string, permit PermitSignature, slippageBps int64, opts TransactionOptions) ([]TransactionStep, *Quote, error) {
+	asset, err := tb.getPermitAsset(assetName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	amountBig, err := tb.parseAmount(amount, asset.Symbol)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(permit.Signature) != crypto.SignatureLength {
+		return nil, nil, ErrInvalidPermitSignature
+	}
+
+	typedData, err := tb.BuildPermitTypedData(asset.Symbol, amountBig, walletAddress, permit.Deadline)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	hash, _, err := apitypes.TypedDataAndHash(*typedData)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to hash permit: %w", err)
+	}
+
+	// Wallets return v as 27/28; ecrecover expects 0/1
+	signature := common.CopyBytes(permit.Signature)
+	v := signature[crypto.RecoveryIDOffset]
+	if v >= 27 {
+		signature[crypto.RecoveryIDOffset] = v - 27
+	}
+
+	publicKey, err := crypto.SigToPub(hash, signature)
+	if err != nil || crypto.PubkeyToAddress(*publicKey) != common.HexToAddress(walletAddress) {
+		return nil, nil, ErrInvalidPermitSignature
+	}
+
+	var r, s [32]byte
+	copy(r[:], signature[:32])
+	copy(s[:], signature[32:64])
+
+	quote, err := tb.quoter.QuoteDeposit(context.Background(), asset.Address, amountBig, slippageBps)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data, err := tb.tellerABI.Pack("depositWithPermit", asset.Address, amountBig, quote.MinimumAmount, permit.Deadline,
+		signature[crypto.RecoveryIDOffset]+27, r, s)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to pack depositWithPermit method: %w", err)
+	}
+
+	call := SimulatedCall{
+		From:    common.HexToAddress(walletAddress),
+		To:      common.HexToAddress(assets.TellerContractAddress),
+		Data:    data,
+		Token:   asset.Address,
+		Spender: assets.LBTCVAddress,
+		Amount:  amountBig,
+	}
+
+	nonce, err := tb.ethClient.PendingNonceAt(context.Background(), call.From)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get nonce from blockchain: %w", err)
+	}
+
+	depositTx, err := tb.buildTransaction(call, DefaultGasLimit, nonce, true, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return []TransactionStep{{Type: StepDeposit, UnsignedTransaction: depositTx}}, quote, nil
+}
+
+// getPermitAsset returns the deposit asset, checking that it supports EIP-2612 permits
+func (tb *TransactionBuilder) getPermitAsset(assetName string) (*assets.Asset, error) {
+	if _, err := tb.getAssetAddress(assetName); err != nil {
+		return nil, err
+	}
+
+	asset, _ := assets.GlobalRegistry.GetBySymbol(strings.ToUpper(assetName))
+	if !asset.SupportsPermit {
+		return nil, fmt.Errorf("asset does not support permit: %s", asset.Symbol)
+	}
+
+	return asset, nil
+}
+
+// getPermitDomain reads the token's EIP-712 domain, from eip712Domain() when the token implements
+// EIP-5267 and from name() with version "1" otherwise
+func (tb *TransactionBuilder) getPermitDomain(ctx context.Context, token common.Address) (*apitypes.TypedDataDomain, error) {
+	chainID, _ := new(big.Int).SetString(EthereumChainID, 10)
+
+	if domain, err := tb.callPermitView(ctx, token, "eip712Domain"); err == nil {
+		return &apitypes.TypedDataDomain{
+			Name:              domain[1].(string),
+			Version:           domain[2].(string),
+			ChainId:           (*math.HexOrDecimal256)(domain[3].(*big.Int)),
+			VerifyingContract: domain[4].(common.Address).Hex(),
+		}, nil
+	}
+
+	name, err := tb.callPermitView(ctx, token, "name")
+	if err != nil {
+		return nil, err
+	}
+
+	return &apitypes.TypedDataDomain{
+		Name:              name[0].(string),
+		Version:           "1",
+		ChainId:           (*math.HexOrDecimal256)(chainID),
+		VerifyingContract: token.Hex(),
+	}, nil
+}
+
+// callPermitView calls one of the PermitABI view functions on a token
+func (tb *TransactionBuilder) callPermitView(ctx context.Context, token common.Address, method string, args ...interface{}) ([]interface{}, error) {
+	data, err := tb.permitABI.Pack(method, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack %s: %w", method, err)
+	}
+
+	result, err := tb.ethClient.CallContract(ctx, ethereum.CallMsg{To: &token, Data: data}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call %s: %w", method, err)
+	}
+
+	values, err := tb.permitABI.Unpack(method, result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpack %s: %w", method, err)
+	}
+
+	return values, nil
+}
+
+// DecodePermitSignature parses a 0x-prefixed 65 byte signature
+func DecodePermitSignature(signature string) ([]byte, error) {
+	decoded, err := hexutil.Decode(signature)
+	if err != nil || len(decoded) != crypto.SignatureLength {
+		return nil, ErrInvalidPermitSignature
+	}
+	return decoded, nil
+}
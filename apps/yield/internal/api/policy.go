@@ -0,0 +1,267 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"yield/apps/yield/internal/amount"
+	"yield/apps/yield/internal/assets"
+)
+
+// Policy error code for deposits the Teller's cap would reject. Paused contracts, unsupported assets
+// and locked shares reuse the simulation error codes.
+const PolicyDepositCapExceeded = "deposit_cap_exceeded"
+
+// The Teller's depositCap when no cap is set, type(uint112).max
+var noDepositCap = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 112), big.NewInt(1))
+
+// PolicyError is returned when the vault's contract state rules out a deposit or withdrawal
+type PolicyError struct {
+	Code   string // PolicyDepositCapExceeded or one of the Simulation* codes
+	Reason string
+}
+
+func (e *PolicyError) Error() string {
+	return fmt.Sprintf("rejected by vault policy (%s): %s", e.Code, e.Reason)
+}
+
+// PolicyChecker checks a deposit or withdrawal against the Teller, Accountant and AtomicQueue state before
+// a transaction is built, reading everything it needs in one Multicall3 call. Unlike simulation it
+// also covers actions that depend on an approval that has not been mined yet.
+type PolicyChecker struct {
+	multicaller   *Multicaller
+	tellerABI     abi.ABI
+	accountantABI abi.ABI // accountantState
+	rateABI       abi.ABI // getRateInQuoteSafe
+	queueABI      abi.ABI
+	vaultABI      abi.ABI
+}
+
+// NewPolicyChecker creates a new PolicyChecker
+func NewPolicyChecker(client *ethclient.Client) (*PolicyChecker, error) {
+	multicaller, err := NewMulticaller(client)
+	if err != nil {
+		return nil, err
+	}
+
+	tellerABI, err := abi.JSON(strings.NewReader(TellerStateABI))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse teller state ABI: %w", err)
+	}
+
+	accountantABI, err := abi.JSON(strings.NewReader(AccountantStateABI))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse accountant state ABI: %w", err)
+	}
+
+	rateABI, err := abi.JSON(strings.NewReader(AccountantABI))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse accountant ABI: %w", err)
+	}
+
+	queueABI, err := abi.JSON(strings.NewReader(AtomicQueueStateABI))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse atomic queue state ABI: %w", err)
+	}
+
+	vaultABI, err := abi.JSON(strings.NewReader(VaultABI))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse vault ABI: %w", err)
+	}
+
+	return &PolicyChecker{
+		multicaller:   multicaller,
+		tellerABI:     tellerABI,
+		accountantABI: accountantABI,
+		rateABI:       rateABI,
+		queueABI:      queueABI,
+		vaultABI:      vaultABI,
+	}, nil
+}
+
+// CheckDeposit checks that the Teller and Accountant are unpaused, that the Teller accepts deposits of
+// the asset and that the shares minted for amount, in the asset's smallest units, fit under the deposit
+// cap. Checks whose state cannot be read, for example on Teller versions without a cap, are skipped.
+func (p *PolicyChecker) CheckDeposit(ctx context.Context, asset *assets.Asset, depositAmount *big.Int) error {
+	teller := common.HexToAddress(assets.TellerContractAddress)
+	accountant := common.HexToAddress(assets.AccountantContractAddress)
+
+	assetData, err := p.tellerABI.Pack("assetData", asset.Address)
+	if err != nil {
+		return fmt.Errorf("failed to pack assetData call: %w", err)
+	}
+	isSupported, err := p.tellerABI.Pack("isSupported", asset.Address)
+	if err != nil {
+		return fmt.Errorf("failed to pack isSupported call: %w", err)
+	}
+	rateData, err := p.rateABI.Pack("getRateInQuoteSafe", asset.Address)
+	if err != nil {
+		return fmt.Errorf("failed to pack getRateInQuoteSafe call: %w", err)
+	}
+
+	results, err := p.read(ctx, []MulticallCall{
+		{Target: teller, CallData: p.tellerABI.Methods["isPaused"].ID},
+		{Target: accountant, CallData: p.accountantABI.Methods["accountantState"].ID},
+		{Target: teller, CallData: assetData},
+		{Target: teller, CallData: isSupported},
+		{Target: teller, CallData: p.tellerABI.Methods["depositCap"].ID},
+		{Target: assets.LBTCVAddress, CallData: p.vaultABI.Methods["totalSupply"].ID},
+		{Target: accountant, CallData: rateData},
+	})
+	if err != nil {
+		return err
+	}
+
+	if paused := unpackBoolResult(p.tellerABI, "isPaused", results[0]); paused != nil && *paused {
+		return &PolicyError{Code: SimulationVaultPaused, Reason: "the Teller is paused"}
+	}
+	if err := p.checkAccountant(results[1]); err != nil {
+		return err
+	}
+
+	allowDeposits, _, sharePremium := p.assetSettings(results[2], results[3])
+	if allowDeposits != nil && !*allowDeposits {
+		return &PolicyError{Code: SimulationAssetNotSupported, Reason: fmt.Sprintf("the Teller does not accept %s deposits", asset.Symbol)}
+	}
+
+	depositCap := unpackUint256(p.tellerABI, "depositCap", results[4])
+	totalSupply := unpackUint256(p.vaultABI, "totalSupply", results[5])
+	rate := unpackUint256(p.rateABI, "getRateInQuoteSafe", results[6])
+	if depositCap == nil || depositCap.Cmp(noDepositCap) == 0 || totalSupply == nil || rate == nil || rate.Sign() == 0 {
+		return nil
+	}
+
+	// The same share calculation as the quote, net of the share premium
+	shares := new(big.Int).Mul(depositAmount, oneShare())
+	shares = applyBps(shares.Div(shares, rate), sharePremium)
+	if new(big.Int).Add(totalSupply, shares).Cmp(depositCap) > 0 {
+		remaining := new(big.Int).Sub(depositCap, totalSupply)
+		if remaining.Sign() < 0 {
+			remaining.SetInt64(0)
+		}
+		return &PolicyError{
+			Code:   PolicyDepositCapExceeded,
+			Reason: fmt.Sprintf("the deposit would mint %s LBTCv but only %s remain under the deposit cap", amount.Format(shares, 8), amount.Format(remaining, 8)),
+		}
+	}
+
+	return nil
+}
+
+// CheckWithdrawal checks that the AtomicQueue and Accountant are unpaused, that withdrawals to the asset
+// are enabled and that the wallet's shares are past the Teller's share lock
+func (p *PolicyChecker) CheckWithdrawal(ctx context.Context, asset *assets.Asset, wallet common.Address) error {
+	teller := common.HexToAddress(assets.TellerContractAddress)
+	accountant := common.HexToAddress(assets.AccountantContractAddress)
+	queue := common.HexToAddress(assets.AtomicRequestContractAddress)
+
+	assetData, err := p.tellerABI.Pack("assetData", asset.Address)
+	if err != nil {
+		return fmt.Errorf("failed to pack assetData call: %w", err)
+	}
+	isSupported, err := p.tellerABI.Pack("isSupported", asset.Address)
+	if err != nil {
+		return fmt.Errorf("failed to pack isSupported call: %w", err)
+	}
+	unlockData, err := p.tellerABI.Pack("shareUnlockTime", wallet)
+	if err != nil {
+		return fmt.Errorf("failed to pack shareUnlockTime call: %w", err)
+	}
+
+	results, err := p.read(ctx, []MulticallCall{
+		{Target: queue, CallData: p.queueABI.Methods["isPaused"].ID},
+		{Target: accountant, CallData: p.accountantABI.Methods["accountantState"].ID},
+		{Target: teller, CallData: assetData},
+		{Target: teller, CallData: isSupported},
+		{Target: teller, CallData: unlockData},
+	})
+	if err != nil {
+		return err
+	}
+
+	if paused := unpackBoolResult(p.queueABI, "isPaused", results[0]); paused != nil && *paused {
+		return &PolicyError{Code: SimulationVaultPaused, Reason: "the AtomicQueue is paused"}
+	}
+	if err := p.checkAccountant(results[1]); err != nil {
+		return err
+	}
+
+	if _, allowWithdraws, _ := p.assetSettings(results[2], results[3]); allowWithdraws != nil && !*allowWithdraws {
+		return &PolicyError{Code: SimulationAssetNotSupported, Reason: fmt.Sprintf("withdrawals to %s are disabled", asset.Symbol)}
+	}
+
+	// Shares received from a deposit cannot leave the wallet until the lock period has passed
+	if unlockTime := unpackUint256(p.tellerABI, "shareUnlockTime", results[4]); unlockTime != nil && unlockTime.IsInt64() {
+		if unlockAt := time.Unix(unlockTime.Int64(), 0); unlockAt.After(time.Now()) {
+			return &PolicyError{Code: SimulationSharesLocked, Reason: fmt.Sprintf("the wallet's shares are locked until %s", unlockAt.UTC().Format(time.RFC3339))}
+		}
+	}
+
+	return nil
+}
+
+// read runs the calls at the latest block, letting each of them fail on its own
+func (p *PolicyChecker) read(ctx context.Context, calls []MulticallCall) ([]MulticallResult, error) {
+	for i := range calls {
+		calls[i].AllowFailure = true
+	}
+
+	results, err := p.multicaller.Aggregate3(ctx, calls, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vault policy state: %w", err)
+	}
+
+	return results, nil
+}
+
+// checkAccountant rejects the action when the accountant is paused, since the Teller and the AtomicQueue
+// both price through it
+func (p *PolicyChecker) checkAccountant(result MulticallResult) error {
+	if !result.Success {
+		return nil
+	}
+
+	values, err := p.accountantABI.Unpack("accountantState", result.ReturnData)
+	if err != nil {
+		return nil
+	}
+
+	if values[8].(bool) {
+		return &PolicyError{Code: SimulationVaultPaused, Reason: "the accountant is paused"}
+	}
+	return nil
+}
+
+// assetSettings decodes the Teller's assetData for an asset, falling back to isSupported on Teller
+// versions without it. Settings that cannot be read are nil, and the premium is zero.
+func (p *PolicyChecker) assetSettings(assetData, isSupported MulticallResult) (allowDeposits, allowWithdraws *bool, sharePremium int64) {
+	if assetData.Success {
+		if values, err := p.tellerABI.Unpack("assetData", assetData.ReturnData); err == nil {
+			deposits, withdraws := values[0].(bool), values[1].(bool)
+			return &deposits, &withdraws, int64(values[2].(uint16))
+		}
+	}
+
+	supported := unpackBoolResult(p.tellerABI, "isSupported", isSupported)
+	return supported, supported, 0
+}
+
+// unpackBoolResult decodes a single bool returned by a batched call, or returns nil when the call failed
+func unpackBoolResult(contractABI abi.ABI, method string, result MulticallResult) *bool {
+	if !result.Success {
+		return nil
+	}
+
+	var value bool
+	if err := contractABI.UnpackIntoInterface(&value, method, result.ReturnData); err != nil {
+		return nil
+	}
+
+	return &value
+}
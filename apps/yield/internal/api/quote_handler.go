@@ -0,0 +1,141 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+	"yield/apps/yield/internal/assets"
+)
+
+// QuoteHandler handles deposit and withdrawal quote requests
+type QuoteHandler struct {
+	transactionBuilder *TransactionBuilder
+	logger             *zap.Logger
+}
+
+// NewQuoteHandler creates a new QuoteHandler. Quotes come from the transaction builder's quoter so that
+// they match the transactions built for the same request.
+func NewQuoteHandler(transactionBuilder *TransactionBuilder, logger *zap.Logger) *QuoteHandler {
+	return &QuoteHandler{
+		transactionBuilder: transactionBuilder,
+		logger:             logger,
+	}
+}
+
+// GetDepositQuote handles GET /api/quotes/deposit
+func (h *QuoteHandler) GetDepositQuote(w http.ResponseWriter, r *http.Request) {
+	h.getQuote(w, r, DefaultSlippageBps, MaxSlippageBps, false)
+}
+
+// GetWithdrawalQuote handles GET /api/quotes/withdrawal
+func (h *QuoteHandler) GetWithdrawalQuote(w http.ResponseWriter, r *http.Request) {
+	h.getQuote(w, r, DefaultWithdrawalSlippageBps, MaxWithdrawalSlippageBps, true)
+}
+
+// getQuote validates the asset, amount and slippage_bps query parameters and writes the quote
+func (h *QuoteHandler) getQuote(w http.ResponseWriter, r *http.Request, defaultSlippageBps, maxSlippageBps int64, withdrawal bool) {
+	query := r.URL.Query()
+
+	assetName := strings.ToUpper(query.Get("asset"))
+	if assetName == "" {
+		h.writeErrorResponse(w, http.StatusBadRequest, "missing_asset", "Asset is required")
+		return
+	}
+
+	if !h.transactionBuilder.IsAssetSupported(assetName) {
+		h.writeErrorResponse(w, http.StatusBadRequest, "unsupported_asset", "Asset not supported. Supported assets: LBTC, CBTC, WBTC")
+		return
+	}
+
+	amount := query.Get("amount")
+	if amount == "" {
+		h.writeErrorResponse(w, http.StatusBadRequest, "missing_amount", "Amount is required")
+		return
+	}
+
+	// Withdrawals are quoted for an amount of LBTCv
+	amountAsset := assetName
+	if withdrawal {
+		amountAsset = "LBTCv"
+	}
+	amountBig, err := h.transactionBuilder.parseAmount(amount, amountAsset)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "invalid_amount", "Invalid amount: "+err.Error())
+		return
+	}
+	// parseAmount has checked that the asset is registered
+	amountAssetInfo, _ := assets.GlobalRegistry.GetBySymbol(amountAsset)
+
+	slippageBps := defaultSlippageBps
+	if value := query.Get("slippage_bps"); value != "" {
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || !IsValidSlippage(parsed, maxSlippageBps) {
+			h.writeErrorResponse(w, http.StatusBadRequest, "invalid_slippage", fmt.Sprintf("Slippage must be between 0 and %d basis points", maxSlippageBps))
+			return
+		}
+		slippageBps = parsed
+	}
+
+	assetAddress, err := h.transactionBuilder.getAssetAddress(assetName)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "unsupported_asset", "Asset not supported. Supported assets: LBTC, CBTC, WBTC")
+		return
+	}
+
+	quoter := h.transactionBuilder.Quoter()
+	var quote *Quote
+	if withdrawal {
+		quote, err = quoter.QuoteWithdrawal(r.Context(), assetAddress, amountBig, slippageBps)
+	} else {
+		quote, err = quoter.QuoteDeposit(r.Context(), assetAddress, amountBig, slippageBps)
+	}
+	if err != nil {
+		var simulationErr *SimulationError
+		if errors.As(err, &simulationErr) {
+			h.writeErrorResponse(w, http.StatusUnprocessableEntity, simulationErr.Code, "Rate unavailable: "+simulationErr.Reason)
+			return
+		}
+		h.logger.Error("Failed to compute quote", zap.String("asset", assetName), zap.Bool("withdrawal", withdrawal), zap.Error(err))
+		h.writeErrorResponse(w, http.StatusInternalServerError, "quote_error", "Failed to compute quote")
+		return
+	}
+
+	response := QuoteResponse{
+		Asset:                   assetName,
+		Amount:                  formatUnits(amountBig, amountAssetInfo.Decimals),
+		PriceProtectionResponse: toPriceProtectionResponse(&quote.PriceProtection),
+		FeeBps:                  quote.FeeBps,
+		BlockNumber:             quote.BlockNumber,
+		ExpiresAt:               quote.ExpiresAt,
+	}
+	if quote.AtomicPrice != nil {
+		atomicPrice := formatUnits(quote.AtomicPrice, quote.RateDecimals)
+		response.AtomicPrice = &atomicPrice
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, response)
+}
+
+// writeJSONResponse writes a JSON response with the specified status code
+func (h *QuoteHandler) writeJSONResponse(w http.ResponseWriter, statusCode int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		h.logger.Error("Failed to encode JSON response", zap.Error(err))
+	}
+}
+
+// writeErrorResponse writes an error response
+func (h *QuoteHandler) writeErrorResponse(w http.ResponseWriter, statusCode int, errorCode, message string) {
+	errorResponse := ErrorResponse{
+		Error:   errorCode,
+		Message: message,
+	}
+	h.writeJSONResponse(w, statusCode, errorResponse)
+}
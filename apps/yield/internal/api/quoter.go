@@ -0,0 +1,231 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"yield/apps/yield/internal/assets"
+)
+
+// How long a quote is honoured before clients should request a new one. The accountant rate only
+// moves when the vault is updated, so this mostly bounds how stale the block number gets.
+const QuoteValidity = 30 * time.Second
+
+// TellerAssetDataABI reads the per-asset deposit settings of the Teller
+const TellerAssetDataABI = `[{
+	"inputs": [{"internalType": "address", "name": "asset", "type": "address"}],
+	"name": "assetData",
+	"outputs": [
+		{"internalType": "bool", "name": "allowDeposits", "type": "bool"},
+		{"internalType": "bool", "name": "allowWithdraws", "type": "bool"},
+		{"internalType": "uint16", "name": "sharePremium", "type": "uint16"}
+	],
+	"stateMutability": "view",
+	"type": "function"
+}]`
+
+// Quote prices a deposit or withdrawal against the accountant rate at a block. Deposits are quoted in
+// LBTCv shares and withdrawals in the withdrawn asset, in the output token's smallest units.
+type Quote struct {
+	PriceProtection
+	BlockNumber uint64
+	FeeBps      *int64   // Deposits only: the Teller share premium. Withdrawals pay no fee beyond the discount.
+	AtomicPrice *big.Int // Withdrawals only: the price the request is filled at
+	Discount    *big.Int // Withdrawals only: the AtomicQueue discount, in millionths
+	ExpiresAt   time.Time
+}
+
+// Quoter computes expected deposit and withdrawal outcomes from on-chain rates. The transaction
+// builder uses the same quotes to set minimumMint and the atomic price.
+type Quoter struct {
+	ethClient     *ethclient.Client
+	accountantABI abi.ABI
+	tellerABI     abi.ABI
+	simulator     *TransactionSimulator
+}
+
+// NewQuoter creates a new Quoter
+func NewQuoter(ethClient *ethclient.Client, simulator *TransactionSimulator) (*Quoter, error) {
+	accountantABI, err := abi.JSON(strings.NewReader(AccountantABI))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse accountant ABI: %w", err)
+	}
+
+	tellerABI, err := abi.JSON(strings.NewReader(TellerAssetDataABI))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse teller asset data ABI: %w", err)
+	}
+
+	return &Quoter{
+		ethClient:     ethClient,
+		accountantABI: accountantABI,
+		tellerABI:     tellerABI,
+		simulator:     simulator,
+	}, nil
+}
+
+// QuoteDeposit computes the shares minted for a deposit of the asset, net of the Teller's share
+// premium, and the minimumMint that tolerates the given slippage
+func (q *Quoter) QuoteDeposit(ctx context.Context, asset common.Address, amount *big.Int, slippageBps int64) (*Quote, error) {
+	blockNumber, err := q.ethClient.BlockNumber(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get block number from blockchain: %w", err)
+	}
+	block := new(big.Int).SetUint64(blockNumber)
+
+	assetDecimals, err := decimalsOf(asset)
+	if err != nil {
+		return nil, err
+	}
+	lbtcvAsset, _ := assets.GlobalRegistry.GetBySymbol("LBTCv")
+
+	rate, err := q.getRateInQuoteSafe(ctx, asset, block)
+	if err != nil {
+		return nil, err
+	}
+
+	sharePremium, err := q.getSharePremium(ctx, asset, block)
+	if err != nil {
+		return nil, err
+	}
+
+	// The Teller mints depositAmount * ONE_SHARE / rate shares, less the share premium
+	expectedShares := new(big.Int).Mul(amount, oneShare())
+	expectedShares.Div(expectedShares, rate)
+	expectedShares = applyBps(expectedShares, sharePremium)
+
+	return &Quote{
+		PriceProtection: PriceProtection{
+			Rate:           rate,
+			ExpectedAmount: expectedShares,
+			MinimumAmount:  applyBps(expectedShares, slippageBps),
+			SlippageBps:    slippageBps,
+			RateDecimals:   assetDecimals,
+			OutputDecimals: lbtcvAsset.Decimals,
+		},
+		BlockNumber: blockNumber,
+		FeeBps:      &sharePremium,
+		ExpiresAt:   time.Now().Add(QuoteValidity),
+	}, nil
+}
+
+// QuoteWithdrawal computes the assets received for a withdrawal of shares. The slippage tolerance is
+// the AtomicQueue discount, so the request is filled at the rate less the slippage, and that discounted
+// amount is both the expected and the minimum amount.
+func (q *Quoter) QuoteWithdrawal(ctx context.Context, want common.Address, shares *big.Int, slippageBps int64) (*Quote, error) {
+	blockNumber, err := q.ethClient.BlockNumber(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get block number from blockchain: %w", err)
+	}
+
+	assetDecimals, err := decimalsOf(want)
+	if err != nil {
+		return nil, err
+	}
+
+	rate, err := q.getRateInQuoteSafe(ctx, want, new(big.Int).SetUint64(blockNumber))
+	if err != nil {
+		return nil, err
+	}
+
+	discount := big.NewInt(slippageBps * discountDenominator / bpsDenominator)
+
+	// safeUpdateAtomicRequest prices the request at rate * (1e6 - discount) / 1e6
+	atomicPrice := new(big.Int).Mul(rate, new(big.Int).Sub(big.NewInt(discountDenominator), discount))
+	atomicPrice.Div(atomicPrice, big.NewInt(discountDenominator))
+
+	// A solver fills the request at the atomic price or not at all
+	receivedAmount := new(big.Int).Mul(shares, atomicPrice)
+	receivedAmount.Div(receivedAmount, oneShare())
+
+	return &Quote{
+		PriceProtection: PriceProtection{
+			Rate:           rate,
+			ExpectedAmount: receivedAmount,
+			MinimumAmount:  receivedAmount,
+			SlippageBps:    slippageBps,
+			RateDecimals:   assetDecimals,
+			OutputDecimals: assetDecimals,
+		},
+		BlockNumber: blockNumber,
+		AtomicPrice: atomicPrice,
+		Discount:    discount,
+		ExpiresAt:   time.Now().Add(QuoteValidity),
+	}, nil
+}
+
+// getRateInQuoteSafe reads the price of one vault share in the quote asset. The accountant reverts
+// while paused, which is returned as a *SimulationError.
+func (q *Quoter) getRateInQuoteSafe(ctx context.Context, quote common.Address, block *big.Int) (*big.Int, error) {
+	data, err := q.accountantABI.Pack("getRateInQuoteSafe", quote)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack getRateInQuoteSafe call: %w", err)
+	}
+
+	accountant := common.HexToAddress(assets.AccountantContractAddress)
+	result, err := q.ethClient.CallContract(ctx, ethereum.CallMsg{To: &accountant, Data: data}, block)
+	if err != nil {
+		return nil, q.simulator.toSimulationError(ctx, SimulatedCall{To: accountant}, err)
+	}
+
+	var rate *big.Int
+	if err := q.accountantABI.UnpackIntoInterface(&rate, "getRateInQuoteSafe", result); err != nil {
+		return nil, fmt.Errorf("failed to unpack getRateInQuoteSafe result: %w", err)
+	}
+
+	if rate.Sign() == 0 {
+		return nil, fmt.Errorf("accountant returned a zero rate for %s", quote.Hex())
+	}
+
+	return rate, nil
+}
+
+// getSharePremium reads the premium, in basis points, the Teller withholds from shares minted for
+// deposits of the asset. Teller versions without per-asset settings charge no premium.
+func (q *Quoter) getSharePremium(ctx context.Context, asset common.Address, block *big.Int) (int64, error) {
+	data, err := q.tellerABI.Pack("assetData", asset)
+	if err != nil {
+		return 0, fmt.Errorf("failed to pack assetData call: %w", err)
+	}
+
+	teller := common.HexToAddress(assets.TellerContractAddress)
+	result, err := q.ethClient.CallContract(ctx, ethereum.CallMsg{To: &teller, Data: data}, block)
+	if err != nil {
+		var dataErr rpc.DataError
+		if errors.As(err, &dataErr) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to call assetData: %w", err)
+	}
+
+	values, err := q.tellerABI.Unpack("assetData", result)
+	if err != nil || len(values) != 3 {
+		return 0, nil
+	}
+
+	return int64(values[2].(uint16)), nil
+}
+
+// decimalsOf returns the decimals of a registered asset
+func decimalsOf(address common.Address) (int, error) {
+	asset, exists := assets.GlobalRegistry.GetByAddress(address)
+	if !exists {
+		return 0, fmt.Errorf("asset %s is not registered", address.Hex())
+	}
+	return asset.Decimals, nil
+}
+
+// applyBps reduces an amount by the given number of basis points, rounding down
+func applyBps(amount *big.Int, bps int64) *big.Int {
+	reduced := new(big.Int).Mul(amount, big.NewInt(bpsDenominator-bps))
+	return reduced.Div(reduced, big.NewInt(bpsDenominator))
+}
@@ -0,0 +1,216 @@
+package api
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+	"yield/apps/yield/internal/model"
+	"yield/apps/yield/internal/repository"
+)
+
+// How long after it was built a transaction can still be relayed
+const BuiltTransactionValidity = 24 * time.Hour
+
+const (
+	// Attempts at recording a broadcast transaction, the delay growing by recordRetryDelay each time
+	recordAttempts   = 3
+	recordRetryDelay = 200 * time.Millisecond
+)
+
+// RelayHandler broadcasts signed transactions and reports their on-chain status
+type RelayHandler struct {
+	transactionRepository *repository.TransactionRepository
+	ethClient             *ethclient.Client
+	logger                *zap.Logger
+}
+
+// NewRelayHandler creates a new RelayHandler
+func NewRelayHandler(transactionRepository *repository.TransactionRepository, rpcURL string, logger *zap.Logger) (*RelayHandler, error) {
+	ethClient, err := ethclient.Dial(rpcURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Ethereum client: %w", err)
+	}
+
+	return &RelayHandler{
+		transactionRepository: transactionRepository,
+		ethClient:             ethClient,
+		logger:                logger,
+	}, nil
+}
+
+// RelayTransaction handles POST /api/transactions
+func (h *RelayHandler) RelayTransaction(w http.ResponseWriter, r *http.Request) {
+	var req RelayTransactionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "invalid_request_body", "Invalid JSON in request body")
+		return
+	}
+
+	raw, err := hexutil.Decode(req.SignedTransaction)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "invalid_signed_transaction", "Signed transaction must be 0x-prefixed hex")
+		return
+	}
+
+	var signedTx types.Transaction
+	if err := signedTx.UnmarshalBinary(raw); err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "invalid_signed_transaction", "Signed transaction could not be decoded")
+		return
+	}
+
+	chainID, _ := strconv.ParseInt(EthereumChainID, 10, 64)
+	if !signedTx.ChainId().IsInt64() || signedTx.ChainId().Int64() != chainID {
+		h.writeErrorResponse(w, http.StatusBadRequest, "invalid_chain_id", "Transaction must be signed for chain "+EthereumChainID)
+		return
+	}
+
+	sender, err := types.Sender(types.LatestSignerForChainID(signedTx.ChainId()), &signedTx)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "invalid_signature", "Transaction signature is invalid")
+		return
+	}
+
+	txHash := signedTx.Hash().Hex()
+
+	// Relaying the same transaction twice returns its current status
+	existing, err := h.transactionRepository.GetRelayedTransaction(txHash)
+	if err != nil {
+		h.logger.Error("Failed to get relayed transaction", zap.String("tx_hash", txHash), zap.Error(err))
+		h.writeErrorResponse(w, http.StatusInternalServerError, "database_error", "Failed to relay transaction")
+		return
+	}
+	if existing != nil {
+		h.writeJSONResponse(w, http.StatusOK, toRelayedTransactionResponse(*existing))
+		return
+	}
+
+	if signedTx.To() == nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "unknown_transaction", "Transaction does not match a transaction built by this service")
+		return
+	}
+
+	built, err := h.transactionRepository.FindBuiltTransaction(sender.Hex(), signedTx.To().Hex(),
+		"0x"+hex.EncodeToString(signedTx.Data()), chainID, time.Now().Add(-BuiltTransactionValidity))
+	if err != nil {
+		h.logger.Error("Failed to find built transaction", zap.String("tx_hash", txHash), zap.Error(err))
+		h.writeErrorResponse(w, http.StatusInternalServerError, "database_error", "Failed to relay transaction")
+		return
+	}
+	if built == nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "unknown_transaction", "Transaction does not match a transaction built by this service")
+		return
+	}
+
+	if err := h.ethClient.SendTransaction(context.Background(), &signedTx); err != nil {
+		h.logger.Info("Failed to broadcast transaction", zap.String("tx_hash", txHash), zap.String("wallet_address", sender.Hex()), zap.Error(err))
+		h.writeErrorResponse(w, http.StatusUnprocessableEntity, "broadcast_failed", "Node rejected the transaction: "+err.Error())
+		return
+	}
+
+	now := time.Now().UTC()
+	relayed := model.RelayedTransaction{
+		TxHash:             txHash,
+		BuiltTransactionID: built.ID,
+		WalletAddress:      sender.Hex(),
+		Nonce:              signedTx.Nonce(),
+		ToAddress:          built.ToAddress,
+		StepType:           built.StepType,
+		Status:             model.TransactionPendingOnchain,
+		BroadcastAt:        now,
+		LastSeenAt:         now,
+		UpdatedAt:          now,
+	}
+	if err := h.recordRelayedTransaction(relayed); err != nil {
+		// Already broadcast, so the client must follow this hash rather than sign a new transaction
+		h.logger.Error("Failed to record relayed transaction", zap.String("tx_hash", txHash), zap.Error(err))
+		h.writeJSONResponse(w, http.StatusInternalServerError, RelayRecordErrorResponse{
+			ErrorResponse: ErrorResponse{
+				Error:   "relay_record_failed",
+				Message: "Transaction was broadcast but could not be recorded; track it on chain by its hash and do not sign it again",
+			},
+			TxHash: txHash,
+		})
+		return
+	}
+
+	h.writeJSONResponse(w, http.StatusAccepted, toRelayedTransactionResponse(relayed))
+}
+
+// recordRelayedTransaction records a broadcast transaction, retrying briefly since the broadcast cannot be
+// undone if the record is lost
+func (h *RelayHandler) recordRelayedTransaction(relayed model.RelayedTransaction) error {
+	var err error
+	for attempt := 1; attempt <= recordAttempts; attempt++ {
+		if err = h.transactionRepository.CreateRelayedTransaction(relayed); err == nil {
+			return nil
+		}
+		if attempt < recordAttempts {
+			h.logger.Warn("Retrying record of relayed transaction", zap.String("tx_hash", relayed.TxHash), zap.Int("attempt", attempt), zap.Error(err))
+			time.Sleep(time.Duration(attempt) * recordRetryDelay)
+		}
+	}
+	return err
+}
+
+// GetTransaction handles GET /api/transactions/{tx_hash}
+func (h *RelayHandler) GetTransaction(w http.ResponseWriter, r *http.Request) {
+	txHash := mux.Vars(r)["tx_hash"]
+
+	transaction, err := h.transactionRepository.GetRelayedTransaction(txHash)
+	if err != nil {
+		h.logger.Error("Failed to get relayed transaction", zap.String("tx_hash", txHash), zap.Error(err))
+		h.writeErrorResponse(w, http.StatusInternalServerError, "database_error", "Failed to retrieve transaction")
+		return
+	}
+
+	if transaction == nil {
+		h.writeErrorResponse(w, http.StatusNotFound, "transaction_not_found", "Transaction not found")
+		return
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, toRelayedTransactionResponse(*transaction))
+}
+
+func toRelayedTransactionResponse(transaction model.RelayedTransaction) RelayedTransactionResponse {
+	return RelayedTransactionResponse{
+		TxHash:        transaction.TxHash,
+		WalletAddress: transaction.WalletAddress,
+		Nonce:         transaction.Nonce,
+		StepType:      transaction.StepType,
+		Status:        transaction.Status,
+		BlockNumber:   transaction.BlockNumber,
+		FailureReason: transaction.FailureReason,
+		ReplacedBy:    transaction.ReplacedBy,
+		BroadcastAt:   transaction.BroadcastAt,
+		UpdatedAt:     transaction.UpdatedAt,
+	}
+}
+
+// writeJSONResponse writes a JSON response with the specified status code
+func (h *RelayHandler) writeJSONResponse(w http.ResponseWriter, statusCode int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		h.logger.Error("Failed to encode JSON response", zap.Error(err))
+	}
+}
+
+// writeErrorResponse writes an error response
+func (h *RelayHandler) writeErrorResponse(w http.ResponseWriter, statusCode int, errorCode, message string) {
+	errorResponse := ErrorResponse{
+		Error:   errorCode,
+		Message: message,
+	}
+	h.writeJSONResponse(w, statusCode, errorResponse)
+}
@@ -0,0 +1,131 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Most responses kept in memory. Expired entries are dropped when the cache fills up, and the cache is
+// cleared if that is not enough.
+const maxCachedResponses = 10000
+
+// CachedResponse is an encoded JSON response along with the block it was read at
+type CachedResponse struct {
+	Body        []byte
+	ETag        string
+	BlockNumber uint64
+	TTL         time.Duration
+	expiresAt   time.Time
+}
+
+// ResponseCache holds encoded responses of on-chain read endpoints. Keys include the block number the
+// response was read at, so a new block never serves a stale response. Concurrent requests for a key
+// that is not cached yet are coalesced into a single computation.
+type ResponseCache struct {
+	mu      sync.Mutex
+	entries map[string]*CachedResponse
+	group   singleflight.Group
+}
+
+// NewResponseCache creates an empty ResponseCache
+func NewResponseCache() *ResponseCache {
+	return &ResponseCache{entries: make(map[string]*CachedResponse)}
+}
+
+// Get returns the response cached under key, or computes, encodes and caches it for ttl. A TTL of zero
+// still coalesces concurrent requests but caches nothing. Errors are never cached. The returned bool
+// reports whether the response came from the cache.
+func (c *ResponseCache) Get(key string, blockNumber uint64, ttl time.Duration, compute func() (interface{}, error)) (*CachedResponse, bool, error) {
+	c.mu.Lock()
+	entry, cached := c.entries[key]
+	c.mu.Unlock()
+	if cached && time.Now().Before(entry.expiresAt) {
+		return entry, true, nil
+	}
+
+	value, err, _ := c.group.Do(key, func() (interface{}, error) {
+		data, err := compute()
+		if err != nil {
+			return nil, err
+		}
+
+		body, err := json.Marshal(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode response: %w", err)
+		}
+
+		sum := sha256.Sum256(body)
+		entry := &CachedResponse{
+			Body:        body,
+			ETag:        fmt.Sprintf(`"%d-%s"`, blockNumber, hex.EncodeToString(sum[:8])),
+			BlockNumber: blockNumber,
+			TTL:         ttl,
+			expiresAt:   time.Now().Add(ttl),
+		}
+
+		if ttl > 0 {
+			c.store(key, entry)
+		}
+		return entry, nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	return value.(*CachedResponse), false, nil
+}
+
+func (c *ResponseCache) store(key string, entry *CachedResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.entries) >= maxCachedResponses {
+		now := time.Now()
+		for cachedKey, cached := range c.entries {
+			if !now.Before(cached.expiresAt) {
+				delete(c.entries, cachedKey)
+			}
+		}
+		if len(c.entries) >= maxCachedResponses {
+			c.entries = make(map[string]*CachedResponse)
+		}
+	}
+
+	c.entries[key] = entry
+}
+
+// writeCachedResponse writes a cached response with its caching headers, or 304 Not Modified when the
+// client already holds it
+func writeCachedResponse(w http.ResponseWriter, r *http.Request, entry *CachedResponse, hit bool) error {
+	w.Header().Set("ETag", entry.ETag)
+	if entry.TTL > 0 {
+		w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(entry.TTL.Seconds())))
+	} else {
+		w.Header().Set("Cache-Control", "no-cache")
+	}
+	if hit {
+		w.Header().Set("X-Cache", "HIT")
+	} else {
+		w.Header().Set("X-Cache", "MISS")
+	}
+
+	if r.Header.Get("If-None-Match") == entry.ETag {
+		w.WriteHeader(http.StatusNotModified)
+		return nil
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(entry.Body); err != nil {
+		return err
+	}
+	_, err := w.Write([]byte("\n"))
+	return err
+}
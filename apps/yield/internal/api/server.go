@@ -10,40 +10,59 @@ import (
 
 	"github.com/gorilla/mux"
 	"go.uber.org/zap"
+	"yield/apps/yield/internal/config"
+	"yield/apps/yield/internal/order_stream"
 	"yield/apps/yield/internal/repository"
 )
 
 // Server represents the API server
 type Server struct {
-	orderHandler   *OrderHandler
-	balanceHandler *BalanceHandler
-	infoHandler    *InfoHandler
-	logger         *zap.Logger
-	server         *http.Server
+	orderHandler       *OrderHandler
+	orderStreamHandler *OrderStreamHandler
+	quoteHandler       *QuoteHandler
+	decodeHandler      *DecodeHandler
+	exportHandler      *ExportHandler
+	relayHandler       *RelayHandler
+	webhookHandler     *WebhookHandler
+	balanceHandler     *BalanceHandler
+	infoHandler        *InfoHandler
+	logger             *zap.Logger
+	server             *http.Server
 }
 
 // NewServer creates a new API server
-func NewServer(port int, orderRepository *repository.OrderRepository, monitoredAddressRepository *repository.MonitoredAddressRepository, rpcURL string, logger *zap.Logger) (*Server, error) {
-	orderHandler, err := NewOrderHandler(orderRepository, monitoredAddressRepository, rpcURL, logger)
+func NewServer(port int, orderRepository *repository.OrderRepository, monitoredAddressRepository *repository.MonitoredAddressRepository, orderBroker *order_stream.Broker, webhookRepository *repository.WebhookRepository, transactionRepository *repository.TransactionRepository, rateSnapshotRepository *repository.RateSnapshotRepository, activityRepository *repository.ActivityRepository, rpcURL string, feeConfig config.FeeConfig, cacheConfig config.CacheConfig, vaultConfig config.VaultConfig, logger *zap.Logger) (*Server, error) {
+	orderHandler, err := NewOrderHandler(orderRepository, monitoredAddressRepository, transactionRepository, rpcURL, feeConfig, logger)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create order handler: %w", err)
 	}
 
-	balanceHandler, err := NewBalanceHandler(rpcURL, logger)
+	relayHandler, err := NewRelayHandler(transactionRepository, rpcURL, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create relay handler: %w", err)
+	}
+
+	balanceHandler, err := NewBalanceHandler(rpcURL, orderRepository, rateSnapshotRepository, cacheConfig, logger)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create balance handler: %w", err)
 	}
 
-	infoHandler, err := NewInfoHandler(rpcURL, logger)
+	infoHandler, err := NewInfoHandler(rpcURL, rateSnapshotRepository, cacheConfig, vaultConfig, logger)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create info handler: %w", err)
 	}
 
 	return &Server{
-		orderHandler:   orderHandler,
-		balanceHandler: balanceHandler,
-		infoHandler:    infoHandler,
-		logger:         logger,
+		orderHandler:       orderHandler,
+		orderStreamHandler: NewOrderStreamHandler(orderBroker, logger),
+		quoteHandler:       NewQuoteHandler(orderHandler.transactionBuilder, logger),
+		decodeHandler:      NewDecodeHandler(orderHandler.transactionBuilder, logger),
+		exportHandler:      NewExportHandler(activityRepository, logger),
+		relayHandler:       relayHandler,
+		webhookHandler:     NewWebhookHandler(webhookRepository, logger),
+		balanceHandler:     balanceHandler,
+		infoHandler:        infoHandler,
+		logger:             logger,
 		server: &http.Server{
 			Addr:         fmt.Sprintf(":%d", port),
 			ReadTimeout:  15 * time.Second,
@@ -88,12 +107,40 @@ func (s *Server) setupRoutes() *mux.Router {
 	api.HandleFunc("/orders/{tx_hash}", s.orderHandler.GetOrder).Methods("GET")
 	api.HandleFunc("/orders/deposit", s.orderHandler.CreateDeposit).Methods("POST")
 	api.HandleFunc("/orders/withdrawal", s.orderHandler.CreateWithdrawal).Methods("POST")
+	api.HandleFunc("/approvals", s.orderHandler.CreateApproval).Methods("POST")
+	api.HandleFunc("/permits", s.orderHandler.CreatePermit).Methods("POST")
+
+	// Relay endpoints
+	api.HandleFunc("/transactions", s.relayHandler.RelayTransaction).Methods("POST")
+	api.HandleFunc("/transactions/{tx_hash}", s.relayHandler.GetTransaction).Methods("GET")
+
+	// Quote endpoints
+	api.HandleFunc("/quotes/deposit", s.quoteHandler.GetDepositQuote).Methods("GET")
+	api.HandleFunc("/quotes/withdrawal", s.quoteHandler.GetWithdrawalQuote).Methods("GET")
+
+	// Decode endpoint
+	api.HandleFunc("/decode", s.decodeHandler.DecodeCalldata).Methods("POST")
+
+	// Wallet endpoints
+	api.HandleFunc("/wallets/{address}/orders", s.orderHandler.ListWalletOrders).Methods("GET")
+	api.HandleFunc("/wallets/{address}/orders/stream", s.orderStreamHandler.StreamWalletOrders).Methods("GET")
+	api.HandleFunc("/wallets/{address}/position", s.balanceHandler.GetPosition).Methods("GET")
+	api.HandleFunc("/wallets/{address}/export", s.exportHandler.ExportWalletActivity).Methods("GET")
+
+	// Webhook endpoints
+	api.HandleFunc("/webhooks", s.webhookHandler.CreateWebhook).Methods("POST")
+	api.HandleFunc("/webhooks", s.webhookHandler.ListWebhooks).Methods("GET")
+	api.HandleFunc("/webhooks/{id}", s.webhookHandler.GetWebhook).Methods("GET")
+	api.HandleFunc("/webhooks/{id}", s.webhookHandler.DeleteWebhook).Methods("DELETE")
+	api.HandleFunc("/webhooks/{id}/deliveries", s.webhookHandler.ListWebhookDeliveries).Methods("GET")
 
 	// Balance endpoints
 	api.HandleFunc("/balance/{wallet_address}", s.balanceHandler.GetBalance).Methods("GET")
+	api.HandleFunc("/balances", s.balanceHandler.GetBalances).Methods("POST")
 
 	// Info endpoint
 	api.HandleFunc("/info", s.infoHandler.GetInfo).Methods("GET")
+	api.HandleFunc("/vault/history", s.infoHandler.GetVaultHistory).Methods("GET")
 
 	// Health check endpoint
 	api.HandleFunc("/health", s.healthCheck).Methods("GET")
@@ -124,7 +171,7 @@ func (s *Server) corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Last-Event-ID")
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"math/big"
+
+	"yield/apps/yield/internal/assets"
+)
+
+const (
+	// Slippage tolerance applied when the client does not choose one, and the largest accepted
+	DefaultSlippageBps = 50
+	MaxSlippageBps     = 1000
+
+	// Withdrawals without a tolerance keep the AtomicQueue discount of 100 millionths (0.01%) that
+	// requests were built with before the tolerance could be chosen. The AtomicQueue rejects discounts
+	// above 1% (MAX_DISCOUNT), so withdrawals accept less slippage.
+	DefaultWithdrawalSlippageBps = 1
+	MaxWithdrawalSlippageBps     = 100
+
+	bpsDenominator = 10000
+
+	// AtomicQueue discounts are expressed in millionths
+	discountDenominator = 1_000_000
+)
+
+// PriceProtection describes the price a deposit or withdrawal was built against and the worst
+// outcome the transaction accepts. Amounts are in the output token's smallest units.
+type PriceProtection struct {
+	Rate           *big.Int // Accountant rate: one vault share priced in the asset
+	ExpectedAmount *big.Int // Output the transaction is expected to produce
+	MinimumAmount  *big.Int // Worst output the transaction accepts
+	SlippageBps    int64
+	RateDecimals   int // Decimals of the asset, which the rate is priced in
+	OutputDecimals int // Decimals of the output token: LBTCv for deposits, the asset for withdrawals
+}
+
+// IsValidSlippage checks that a slippage tolerance in basis points is within the accepted range
+func IsValidSlippage(slippageBps, maxBps int64) bool {
+	return slippageBps >= 0 && slippageBps <= maxBps
+}
+
+// oneShare returns one vault share in its smallest units
+func oneShare() *big.Int {
+	lbtcvAsset, _ := assets.GlobalRegistry.GetBySymbol("LBTCv")
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(lbtcvAsset.Decimals)), nil)
+}
+
+// formatUnits converts token units to a decimal string with every decimal place of the token
+func formatUnits(value *big.Int, decimals int) string {
+	return new(big.Rat).SetFrac(value, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)).FloatString(decimals)
+}
@@ -0,0 +1,294 @@
+package api
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"go.uber.org/zap"
+)
+
+// Output formats accepted in the output_format request field
+const (
+	OutputFormatTransaction = "transaction" // Unsigned transactions for an externally owned account
+	OutputFormatSafe        = "safe"        // A Safe transaction service proposal
+	OutputFormatERC4337     = "erc4337"     // An ERC-4337 UserOperation for a smart account
+)
+
+const (
+	// MultiSendCallOnly v1.3.0, delegatecalled by a Safe to batch calls
+	MultiSendCallOnlyAddress = "0x40A2aCCbd92BCA938b02010E17A5b8929b49130D"
+
+	// ERC-4337 EntryPoint v0.7
+	EntryPointAddress = "0x0000000071727De22E5E9d8BAf0edAc6f37da032"
+
+	safeOperationCall         = 0
+	safeOperationDelegateCall = 1
+)
+
+// SmartAccountABI covers the Safe, MultiSend, EntryPoint and SimpleAccount-compatible account methods
+// used to build smart account payloads
+const SmartAccountABI = `[
+	{"type": "function", "name": "nonce", "stateMutability": "view",
+		"inputs": [],
+		"outputs": [{"name": "", "type": "uint256"}]},
+	{"type": "function", "name": "multiSend", "stateMutability": "payable",
+		"inputs": [{"name": "transactions", "type": "bytes"}],
+		"outputs": []},
+	{"type": "function", "name": "getNonce", "stateMutability": "view",
+		"inputs": [{"name": "sender", "type": "address"}, {"name": "key", "type": "uint192"}],
+		"outputs": [{"name": "nonce", "type": "uint256"}]},
+	{"type": "function", "name": "execute", "stateMutability": "nonpayable",
+		"inputs": [{"name": "dest", "type": "address"}, {"name": "value", "type": "uint256"}, {"name": "func", "type": "bytes"}],
+		"outputs": []},
+	{"type": "function", "name": "executeBatch", "stateMutability": "nonpayable",
+		"inputs": [{"name": "dest", "type": "address[]"}, {"name": "value", "type": "uint256[]"}, {"name": "func", "type": "bytes[]"}],
+		"outputs": []}
+]`
+
+// ErrNotSmartAccount is returned when a smart account format is requested for a wallet that is not one
+var ErrNotSmartAccount = errors.New("wallet is not a smart account")
+
+// IsValidOutputFormat checks if the given output format is supported
+func IsValidOutputFormat(format string) bool {
+	return format == OutputFormatTransaction || format == OutputFormatSafe || format == OutputFormatERC4337
+}
+
+// buildSmartAccountSteps builds a single step executing the action from a smart account, batched with
+// an approval of exactly the amount it transfers when the account's allowance is too low. The step
+// carries a Safe transaction or a UserOperation, depending on the output format, instead of an
+// unsigned transaction. As with EOAs, the action is only simulated when no approval is needed.
+func (tb *TransactionBuilder) buildSmartAccountSteps(ctx context.Context, action SimulatedCall, actionStep string, approvalRequired bool, opts TransactionOptions) ([]TransactionStep, error) {
+	if err := tb.requireContract(ctx, action.From); err != nil {
+		return nil, err
+	}
+
+	calls := []SimulatedCall{action}
+	batched := []string{actionStep}
+	if approvalRequired {
+		data, err := tb.erc20ABI.Pack("approve", action.Spender, action.Amount)
+		if err != nil {
+			return nil, fmt.Errorf("failed to pack approve method: %w", err)
+		}
+		calls = []SimulatedCall{{From: action.From, To: action.Token, Data: data}, action}
+		batched = []string{StepApprove, actionStep}
+	} else if _, err := tb.simulator.Simulate(ctx, action); err != nil {
+		var simulationErr *SimulationError
+		if errors.As(err, &simulationErr) {
+			return nil, err
+		}
+		tb.logger.Warn("Failed to simulate smart account call", zap.String("to", action.To.Hex()), zap.Error(err))
+	}
+
+	step := TransactionStep{Type: actionStep, Calls: batched}
+	var err error
+	if opts.OutputFormat == OutputFormatSafe {
+		step.SafeTransaction, err = tb.buildSafeTransaction(ctx, action.From, calls)
+	} else {
+		step.UserOperation, err = tb.buildUserOperation(ctx, action.From, calls)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return []TransactionStep{step}, nil
+}
+
+// buildSafeTransaction creates a Safe transaction service proposal executing the calls from the Safe.
+// Several calls are batched through MultiSendCallOnly. The proposal still needs the owners' signatures
+// over its contractTransactionHash. The nonce is the Safe's on-chain nonce, so proposals already queued
+// in the transaction service must be executed or replaced first.
+func (tb *TransactionBuilder) buildSafeTransaction(ctx context.Context, safe common.Address, calls []SimulatedCall) (*SafeTransaction, error) {
+	nonce, err := tb.callSmartAccountView(ctx, safe, "nonce")
+	if err != nil {
+		return nil, err
+	}
+
+	to := calls[0].To
+	data := calls[0].Data
+	operation := safeOperationCall
+	if len(calls) > 1 {
+		data, err = tb.packMultiSend(calls)
+		if err != nil {
+			return nil, err
+		}
+		to = common.HexToAddress(MultiSendCallOnlyAddress)
+		operation = safeOperationDelegateCall
+	}
+
+	safeTx := &SafeTransaction{
+		Safe:           safe.Hex(),
+		To:             to.Hex(),
+		Value:          "0",
+		Data:           hexutil.Encode(data),
+		Operation:      operation,
+		SafeTxGas:      "0", // Safe 1.3+ forwards all available gas
+		BaseGas:        "0",
+		GasPrice:       "0",
+		GasToken:       common.Address{}.Hex(),
+		RefundReceiver: common.Address{}.Hex(),
+		Nonce:          nonce.Uint64(),
+	}
+
+	hash, err := safeTransactionHash(safe, safeTx)
+	if err != nil {
+		return nil, err
+	}
+	safeTx.ContractTransactionHash = hexutil.Encode(hash)
+
+	return safeTx, nil
+}
+
+// buildUserOperation creates the ERC-4337 UserOperation fields that depend on the calls: the account's
+// EntryPoint nonce and execute (or executeBatch) call data. Gas limits, fees, paymaster data and the
+// signature are left to the client's bundler.
+func (tb *TransactionBuilder) buildUserOperation(ctx context.Context, account common.Address, calls []SimulatedCall) (*UserOperation, error) {
+	entryPoint := common.HexToAddress(EntryPointAddress)
+	nonce, err := tb.callSmartAccountView(ctx, entryPoint, "getNonce", account, big.NewInt(0))
+	if err != nil {
+		return nil, err
+	}
+
+	var callData []byte
+	if len(calls) == 1 {
+		callData, err = tb.smartAccountABI.Pack("execute", calls[0].To, big.NewInt(0), calls[0].Data)
+	} else {
+		dests := make([]common.Address, len(calls))
+		values := make([]*big.Int, len(calls))
+		funcs := make([][]byte, len(calls))
+		for i, call := range calls {
+			dests[i] = call.To
+			values[i] = big.NewInt(0)
+			funcs[i] = call.Data
+		}
+		callData, err = tb.smartAccountABI.Pack("executeBatch", dests, values, funcs)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack account call: %w", err)
+	}
+
+	return &UserOperation{
+		Sender:     account.Hex(),
+		Nonce:      hexutil.EncodeBig(nonce),
+		CallData:   hexutil.Encode(callData),
+		EntryPoint: entryPoint.Hex(),
+	}, nil
+}
+
+// requireContract returns ErrNotSmartAccount if the wallet has no code
+func (tb *TransactionBuilder) requireContract(ctx context.Context, wallet common.Address) error {
+	code, err := tb.ethClient.CodeAt(ctx, wallet, nil)
+	if err != nil {
+		return fmt.Errorf("failed to get code from blockchain: %w", err)
+	}
+	if len(code) == 0 {
+		return ErrNotSmartAccount
+	}
+	return nil
+}
+
+// packMultiSend encodes calls as MultiSend transactions: operation (uint8), to (address), value
+// (uint256), data length (uint256) and data, tightly packed
+func (tb *TransactionBuilder) packMultiSend(calls []SimulatedCall) ([]byte, error) {
+	var packed []byte
+	for _, call := range calls {
+		packed = append(packed, safeOperationCall)
+		packed = append(packed, call.To.Bytes()...)
+		packed = append(packed, make([]byte, 32)...) // Zero value
+		length := make([]byte, 32)
+		binary.BigEndian.PutUint64(length[24:], uint64(len(call.Data)))
+		packed = append(packed, length...)
+		packed = append(packed, call.Data...)
+	}
+
+	data, err := tb.smartAccountABI.Pack("multiSend", packed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack multiSend method: %w", err)
+	}
+	return data, nil
+}
+
+// callSmartAccountView calls one of the SmartAccountABI view functions returning a uint256. A contract
+// without the function reverts or returns nothing, which is reported as ErrNotSmartAccount.
+func (tb *TransactionBuilder) callSmartAccountView(ctx context.Context, contract common.Address, method string, args ...interface{}) (*big.Int, error) {
+	data, err := tb.smartAccountABI.Pack(method, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack %s: %w", method, err)
+	}
+
+	result, err := tb.ethClient.CallContract(ctx, ethereum.CallMsg{To: &contract, Data: data}, nil)
+	if err != nil {
+		var dataErr rpc.DataError
+		if errors.As(err, &dataErr) {
+			return nil, ErrNotSmartAccount
+		}
+		return nil, fmt.Errorf("failed to call %s: %w", method, err)
+	}
+	if len(result) == 0 {
+		return nil, ErrNotSmartAccount
+	}
+
+	var value *big.Int
+	if err := tb.smartAccountABI.UnpackIntoInterface(&value, method, result); err != nil {
+		return nil, fmt.Errorf("failed to unpack %s: %w", method, err)
+	}
+
+	return value, nil
+}
+
+// safeTransactionHash returns the EIP-712 hash of a Safe transaction, which the owners sign
+func safeTransactionHash(safe common.Address, safeTx *SafeTransaction) ([]byte, error) {
+	chainID, _ := new(big.Int).SetString(EthereumChainID, 10)
+
+	typedData := apitypes.TypedData{
+		Types: apitypes.Types{
+			"EIP712Domain": {
+				{Name: "chainId", Type: "uint256"},
+				{Name: "verifyingContract", Type: "address"},
+			},
+			"SafeTx": {
+				{Name: "to", Type: "address"},
+				{Name: "value", Type: "uint256"},
+				{Name: "data", Type: "bytes"},
+				{Name: "operation", Type: "uint8"},
+				{Name: "safeTxGas", Type: "uint256"},
+				{Name: "baseGas", Type: "uint256"},
+				{Name: "gasPrice", Type: "uint256"},
+				{Name: "gasToken", Type: "address"},
+				{Name: "refundReceiver", Type: "address"},
+				{Name: "nonce", Type: "uint256"},
+			},
+		},
+		PrimaryType: "SafeTx",
+		Domain: apitypes.TypedDataDomain{
+			ChainId:           (*math.HexOrDecimal256)(chainID),
+			VerifyingContract: safe.Hex(),
+		},
+		Message: apitypes.TypedDataMessage{
+			"to":             safeTx.To,
+			"value":          safeTx.Value,
+			"data":           safeTx.Data,
+			"operation":      fmt.Sprint(safeTx.Operation),
+			"safeTxGas":      safeTx.SafeTxGas,
+			"baseGas":        safeTx.BaseGas,
+			"gasPrice":       safeTx.GasPrice,
+			"gasToken":       safeTx.GasToken,
+			"refundReceiver": safeTx.RefundReceiver,
+			"nonce":          fmt.Sprint(safeTx.Nonce),
+		},
+	}
+
+	hash, _, err := apitypes.TypedDataAndHash(typedData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash Safe transaction: %w", err)
+	}
+	return hash, nil
+}
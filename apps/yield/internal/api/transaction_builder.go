@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"math/big"
 	"strconv"
@@ -11,20 +12,27 @@ import (
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rlp"
+	"go.uber.org/zap"
+	"yield/apps/yield/internal/amount"
 	"yield/apps/yield/internal/assets"
+	"yield/apps/yield/internal/config"
 )
 
 const (
-	// Default gas limit for transactions
+	// Gas limits used when the node cannot estimate gas for a transaction, or when the transaction
+	// cannot be simulated because it depends on an approval that has not been mined yet
 	DefaultGasLimit    = "200000"
 	WithdrawalGasLimit = "300000"
+	ApprovalGasLimit   = "100000"
 
 	// Ethereum mainnet chain ID
 	EthereumChainID = "1"
 )
 
-// TellerWithMultiAssetSupport ABI for the deposit method
+// TellerWithMultiAssetSupport ABI for the deposit and depositWithPermit methods
 const TellerABI = `[{
 	"inputs": [
 		{"internalType": "address", "name": "depositAsset", "type": "address"},
@@ -37,6 +45,22 @@ const TellerABI = `[{
 	],
 	"stateMutability": "nonpayable",
 	"type": "function"
+}, {
+	"inputs": [
+		{"internalType": "address", "name": "depositAsset", "type": "address"},
+		{"internalType": "uint256", "name": "depositAmount", "type": "uint256"},
+		{"internalType": "uint256", "name": "minimumMint", "type": "uint256"},
+		{"internalType": "uint256", "name": "deadline", "type": "uint256"},
+		{"internalType": "uint8", "name": "v", "type": "uint8"},
+		{"internalType": "bytes32", "name": "r", "type": "bytes32"},
+		{"internalType": "bytes32", "name": "s", "type": "bytes32"}
+	],
+	"name": "depositWithPermit",
+	"outputs": [
+		{"internalType": "uint256", "name": "shares", "type": "uint256"}
+	],
+	"stateMutability": "nonpayable",
+	"type": "function"
 }]`
 
 // AtomicRequest ABI for the safeUpdateAtomicRequest method
@@ -59,15 +83,37 @@ const AtomicRequestABI = `[{
 	"type": "function"
 }]`
 
+// TransactionOptions selects the type of transaction to build, how aggressively to price EIP-1559
+// transactions, and whether to build them for a smart account instead
+type TransactionOptions struct {
+	TxType       string // TxTypeLegacy (default) or TxTypeEIP1559
+	FeeUrgency   string // FeeUrgencySlow, FeeUrgencyNormal (default) or FeeUrgencyFast
+	OutputFormat string // OutputFormatTransaction (default), OutputFormatSafe or OutputFormatERC4337
+}
+
+// Transaction step types, in the order they must be sent
+const (
+	StepApprove    = "approve"
+	StepDeposit    = "deposit"
+	StepWithdrawal = "withdrawal"
+)
+
 // TransactionBuilder handles creation of unsigned Ethereum transactions
 type TransactionBuilder struct {
 	tellerABI        abi.ABI
 	atomicRequestABI abi.ABI
+	erc20ABI         abi.ABI
+	permitABI        abi.ABI
+	smartAccountABI  abi.ABI
 	ethClient        *ethclient.Client
+	feeEstimator     *FeeEstimator
+	simulator        *TransactionSimulator
+	quoter           *Quoter
+	logger           *zap.Logger
 }
 
 // NewTransactionBuilder creates a new transaction builder
-func NewTransactionBuilder(rpcURL string) (*TransactionBuilder, error) {
+func NewTransactionBuilder(rpcURL string, feeConfig config.FeeConfig, logger *zap.Logger) (*TransactionBuilder, error) {
 	tellerABI, err := abi.JSON(strings.NewReader(TellerABI))
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse teller ABI: %w", err)
@@ -78,62 +124,93 @@ func NewTransactionBuilder(rpcURL string) (*TransactionBuilder, error) {
 		return nil, fmt.Errorf("failed to parse atomic request ABI: %w", err)
 	}
 
+	erc20ABI, err := abi.JSON(strings.NewReader(ERC20ABI))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ERC20 ABI: %w", err)
+	}
+
+	permitABI, err := abi.JSON(strings.NewReader(PermitABI))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse permit ABI: %w", err)
+	}
+
+	smartAccountABI, err := abi.JSON(strings.NewReader(SmartAccountABI))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse smart account ABI: %w", err)
+	}
+
 	ethClient, err := ethclient.Dial(rpcURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to Ethereum client: %w", err)
 	}
 
+	simulator, err := NewTransactionSimulator(ethClient)
+	if err != nil {
+		return nil, err
+	}
+
+	quoter, err := NewQuoter(ethClient, simulator)
+	if err != nil {
+		return nil, err
+	}
+
 	return &TransactionBuilder{
 		tellerABI:        tellerABI,
 		atomicRequestABI: atomicRequestABI,
+		erc20ABI:         erc20ABI,
+		permitABI:        permitABI,
+		smartAccountABI:  smartAccountABI,
 		ethClient:        ethClient,
+		feeEstimator:     NewFeeEstimator(ethClient, feeConfig),
+		simulator:        simulator,
+		quoter:           quoter,
+		logger:           logger,
 	}, nil
 }
 
-// BuildDepositTransaction creates an unsigned transaction for depositing assets
-func (tb *TransactionBuilder) BuildDepositTransaction(assetName, amount, walletAddress string) (*UnsignedTransaction, error) {
+// BuildDepositTransaction creates the unsigned transactions for depositing assets: an approval of the
+// deposit asset to the vault if the current allowance is too low, followed by the deposit. The deposit
+// mints at least the shares expected at the accountant's current rate less the slippage tolerance.
+func (tb *TransactionBuilder) BuildDepositTransaction(assetName, amount, walletAddress string, slippageBps int64, opts TransactionOptions) ([]TransactionStep, *Quote, error) {
 	// Get asset address
 	assetAddress, err := tb.getAssetAddress(assetName)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	// Convert decimal amount to proper token units (LBTC uses 8 decimals)
-	amountBig, err := tb.convertTo8Decimals(amount)
+	// Convert decimal amount to the asset's smallest units
+	amountBig, err := tb.parseAmount(amount, strings.ToUpper(assetName))
 	if err != nil {
-		return nil, fmt.Errorf("invalid amount format: %s", amount)
+		return nil, nil, err
 	}
 
-	// Set minimum mint to 0 (no slippage protection as requested)
-	minimumMint := big.NewInt(0)
-
-	// Get current nonce from blockchain
-	nonce, err := tb.ethClient.PendingNonceAt(context.Background(), common.HexToAddress(walletAddress))
+	quote, err := tb.quoter.QuoteDeposit(context.Background(), assetAddress, amountBig, slippageBps)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get nonce from blockchain: %w", err)
+		return nil, nil, err
 	}
 
-	// Get current gas price from blockchain
-	gasPrice, err := tb.ethClient.SuggestGasPrice(context.Background())
+	// Encode the function call
+	data, err := tb.tellerABI.Pack("deposit", assetAddress, amountBig, quote.MinimumAmount)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get gas price from blockchain: %w", err)
+		return nil, nil, fmt.Errorf("failed to pack deposit method: %w", err)
 	}
 
-	// Encode the function call
-	data, err := tb.tellerABI.Pack("deposit", assetAddress, amountBig, minimumMint)
+	// The vault pulls the deposit asset from the wallet
+	call := SimulatedCall{
+		From:    common.HexToAddress(walletAddress),
+		To:      common.HexToAddress(assets.TellerContractAddress),
+		Data:    data,
+		Token:   assetAddress,
+		Spender: assets.LBTCVAddress,
+		Amount:  amountBig,
+	}
+
+	steps, err := tb.buildWithApproval(call, StepDeposit, DefaultGasLimit, opts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to pack deposit method: %w", err)
+		return nil, nil, err
 	}
 
-	return &UnsignedTransaction{
-		To:       assets.TellerContractAddress,
-		Data:     "0x" + hex.EncodeToString(data),
-		Value:    "0x0", // No ETH value for ERC20 deposits
-		GasLimit: DefaultGasLimit,
-		GasPrice: "0x" + gasPrice.Text(16),
-		ChainID:  EthereumChainID,
-		Nonce:    "0x" + strconv.FormatUint(nonce, 16),
-	}, nil
+	return steps, quote, nil
 }
 
 // getAssetAddress returns the Ethereum address for the given asset name
@@ -176,68 +253,42 @@ func (tb *TransactionBuilder) IsAssetSupported(assetName string) bool {
 	return false
 }
 
-// convertToWei converts a decimal amount string to wei (multiply by 10^18)
-func (tb *TransactionBuilder) convertToWei(amount string) (*big.Int, error) {
-	// Parse the decimal string
-	amountFloat, ok := new(big.Float).SetString(amount)
-	if !ok {
-		return nil, fmt.Errorf("invalid decimal format")
-	}
-
-	// Multiply by 10^18 to convert to wei
-	weiMultiplier := new(big.Float).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil))
-	weiAmount := new(big.Float).Mul(amountFloat, weiMultiplier)
-
-	// Convert to big.Int (truncate any fractional wei)
-	weiInt, _ := weiAmount.Int(nil)
-	return weiInt, nil
-}
-
-// BuildWithdrawalTransaction creates an unsigned transaction for withdrawing LBTCv assets
-func (tb *TransactionBuilder) BuildWithdrawalTransaction(toAssetName, amount, walletAddress string) (*UnsignedTransaction, error) {
+// BuildWithdrawalTransaction creates the unsigned transactions for withdrawing LBTCv assets: an approval
+// of LBTCv to the atomic queue if the current allowance is too low, followed by the withdrawal request.
+// The request is priced at the accountant's current rate less the slippage tolerance.
+func (tb *TransactionBuilder) BuildWithdrawalTransaction(toAssetName, amount, walletAddress string, slippageBps int64, opts TransactionOptions) ([]TransactionStep, *Quote, error) {
 	// Get target asset address
 	wantAddress, err := tb.getAssetAddress(toAssetName)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	// Offer is always LBTCv
 	lbtcvAsset, _ := assets.GlobalRegistry.GetBySymbol("LBTCv")
 	offerAddress := lbtcvAsset.Address
 
-	// Convert decimal amount to wei (LBTCv uses 8 decimals like other BTC tokens)
-	amountBig, err := tb.convertTo8Decimals(amount)
+	// Convert decimal amount to LBTCv's smallest units
+	amountBig, err := tb.parseAmount(amount, lbtcvAsset.Symbol)
 	if err != nil {
-		return nil, fmt.Errorf("invalid amount format: %s", amount)
+		return nil, nil, err
 	}
 
 	// Set deadline to 3 days from now
 	deadline := big.NewInt(time.Now().Add(3 * 24 * time.Hour).Unix())
 
-	// Atomic price is 0
-	atomicPrice := big.NewInt(0)
+	// The atomic queue recomputes the atomic price from the accountant's safe rate and the discount;
+	// the same price is set here so the request states what the wallet is signing up for
+	quote, err := tb.quoter.QuoteWithdrawal(context.Background(), wantAddress, amountBig, slippageBps)
+	if err != nil {
+		return nil, nil, err
+	}
 
 	// Accountant address
 	accountant := common.HexToAddress(assets.AccountantContractAddress)
 
-	// Discount is 100 (as uint256, not uint16)
-	discount := big.NewInt(100)
-
 	// inSolve is false
 	inSolve := false
 
-	// Get current nonce from blockchain
-	nonce, err := tb.ethClient.PendingNonceAt(context.Background(), common.HexToAddress(walletAddress))
-	if err != nil {
-		return nil, fmt.Errorf("failed to get nonce from blockchain: %w", err)
-	}
-
-	// Get current gas price from blockchain
-	gasPrice, err := tb.ethClient.SuggestGasPrice(context.Background())
-	if err != nil {
-		return nil, fmt.Errorf("failed to get gas price from blockchain: %w", err)
-	}
-
 	// Create the userRequest tuple struct with correct types and order for ABI
 	userRequest := struct {
 		OfferAmount *big.Int // uint96 - maps to *big.Int (first in struct)
@@ -247,41 +298,257 @@ func (tb *TransactionBuilder) BuildWithdrawalTransaction(toAssetName, amount, wa
 	}{
 		OfferAmount: amountBig,
 		Deadline:    uint64(deadline.Int64()),
-		AtomicPrice: atomicPrice,
+		AtomicPrice: quote.AtomicPrice,
 		InSolve:     inSolve,
 	}
 
 	// Encode the function call
 	data, err := tb.atomicRequestABI.Pack("safeUpdateAtomicRequest",
-		offerAddress, wantAddress, userRequest, accountant, discount)
+		offerAddress, wantAddress, userRequest, accountant, quote.Discount)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to pack safeUpdateAtomicRequest method: %w", err)
+	}
+
+	// The atomic queue checks the wallet's LBTCv balance and allowance
+	call := SimulatedCall{
+		From:    common.HexToAddress(walletAddress),
+		To:      common.HexToAddress(assets.AtomicRequestContractAddress),
+		Data:    data,
+		Token:   offerAddress,
+		Spender: common.HexToAddress(assets.AtomicRequestContractAddress),
+		Amount:  amountBig,
+	}
+
+	steps, err := tb.buildWithApproval(call, StepWithdrawal, WithdrawalGasLimit, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return steps, quote, nil
+}
+
+// BuildApprovalTransaction creates an unsigned approval of the asset to the contract that pulls it:
+// the vault for deposit assets, the atomic queue for LBTCv. A nil amount approves the maximum.
+// It returns the transaction along with the spender and the wallet's current allowance.
+func (tb *TransactionBuilder) BuildApprovalTransaction(assetName string, amount *big.Int, walletAddress string, opts TransactionOptions) (*UnsignedTransaction, common.Address, *big.Int, error) {
+	asset, exists := assets.GlobalRegistry.GetBySymbol(assetName)
+	if !exists {
+		return nil, common.Address{}, nil, fmt.Errorf("unsupported asset: %s", assetName)
+	}
+
+	if amount == nil {
+		amount = abi.MaxUint256
+	}
+
+	ctx := context.Background()
+	owner := common.HexToAddress(walletAddress)
+	spender := ApprovalSpender(asset)
+
+	allowance, err := tb.simulator.callUint256(ctx, asset.Address, "allowance", owner, spender)
 	if err != nil {
-		return nil, fmt.Errorf("failed to pack safeUpdateAtomicRequest method: %w", err)
+		return nil, common.Address{}, nil, err
 	}
 
-	return &UnsignedTransaction{
-		To:       assets.AtomicRequestContractAddress,
+	nonce, err := tb.ethClient.PendingNonceAt(ctx, owner)
+	if err != nil {
+		return nil, common.Address{}, nil, fmt.Errorf("failed to get nonce from blockchain: %w", err)
+	}
+
+	approveTx, err := tb.buildApproval(owner, asset.Address, spender, amount, nonce, opts)
+	if err != nil {
+		return nil, common.Address{}, nil, err
+	}
+
+	return approveTx, spender, allowance, nil
+}
+
+// Quoter returns the quoter the builder prices deposits and withdrawals with
+func (tb *TransactionBuilder) Quoter() *Quoter {
+	return tb.quoter
+}
+
+// ApprovalSpender returns the contract that transfers the asset from the wallet: the atomic queue for
+// LBTCv withdrawals, and the BoringVault (the LBTCv token itself) for deposits
+func ApprovalSpender(asset *assets.Asset) common.Address {
+	if asset.Symbol == "LBTCv" {
+		return common.HexToAddress(assets.AtomicRequestContractAddress)
+	}
+	return assets.LBTCVAddress
+}
+
+// buildWithApproval builds the action transaction, preceded by an approval of exactly the amount it
+// transfers when the wallet's allowance is too low. The action cannot be simulated before the approval
+// is mined, so in that case it uses the fallback gas limit and only the balance is checked up front.
+// Smart account output formats batch the approval and the action into a single step.
+func (tb *TransactionBuilder) buildWithApproval(action SimulatedCall, actionStep, fallbackGasLimit string, opts TransactionOptions) ([]TransactionStep, error) {
+	ctx := context.Background()
+
+	allowance, err := tb.simulator.callUint256(ctx, action.Token, "allowance", action.From, action.Spender)
+	if err != nil {
+		return nil, err
+	}
+
+	approvalRequired := allowance.Cmp(action.Amount) < 0
+	if approvalRequired {
+		balance, err := tb.simulator.callUint256(ctx, action.Token, "balanceOf", action.From)
+		if err != nil {
+			return nil, err
+		}
+		if balance.Cmp(action.Amount) < 0 {
+			return nil, &SimulationError{
+				Code:   SimulationInsufficientBalance,
+				Reason: fmt.Sprintf("balance %s is less than amount %s", balance, action.Amount),
+			}
+		}
+	}
+
+	if opts.OutputFormat == OutputFormatSafe || opts.OutputFormat == OutputFormatERC4337 {
+		return tb.buildSmartAccountSteps(ctx, action, actionStep, approvalRequired, opts)
+	}
+
+	// Get current nonce from blockchain; the steps use consecutive nonces
+	nonce, err := tb.ethClient.PendingNonceAt(ctx, action.From)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get nonce from blockchain: %w", err)
+	}
+
+	if !approvalRequired {
+		actionTx, err := tb.buildTransaction(action, fallbackGasLimit, nonce, true, opts)
+		if err != nil {
+			return nil, err
+		}
+		return []TransactionStep{{Type: actionStep, UnsignedTransaction: actionTx}}, nil
+	}
+
+	approveTx, err := tb.buildApproval(action.From, action.Token, action.Spender, action.Amount, nonce, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	actionTx, err := tb.buildTransaction(action, fallbackGasLimit, nonce+1, false, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return []TransactionStep{
+		{Type: StepApprove, UnsignedTransaction: approveTx},
+		{Type: actionStep, UnsignedTransaction: actionTx},
+	}, nil
+}
+
+// buildApproval creates an unsigned ERC20 approve transaction
+func (tb *TransactionBuilder) buildApproval(owner, token, spender common.Address, amount *big.Int, nonce uint64, opts TransactionOptions) (*UnsignedTransaction, error) {
+	data, err := tb.erc20ABI.Pack("approve", spender, amount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack approve method: %w", err)
+	}
+
+	call := SimulatedCall{
+		From: owner,
+		To:   token,
+		Data: data,
+	}
+
+	return tb.buildTransaction(call, ApprovalGasLimit, nonce, true, opts)
+}
+
+// buildTransaction fills in the gas limit and fees for a contract call from the wallet and serializes it
+// as an unsigned transaction of the requested type. When simulate is set the call is simulated first:
+// a call that would revert returns a *SimulationError, and the gas limit is estimated. The fallback
+// gas limit is used when the call is not simulated or the node cannot estimate gas.
+func (tb *TransactionBuilder) buildTransaction(call SimulatedCall, fallbackGasLimit string, nonce uint64, simulate bool, opts TransactionOptions) (*UnsignedTransaction, error) {
+	ctx := context.Background()
+
+	gas, err := strconv.ParseUint(fallbackGasLimit, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid gas limit: %s", fallbackGasLimit)
+	}
+
+	if simulate {
+		estimatedGas, err := tb.simulator.Simulate(ctx, call)
+		if err != nil {
+			var simulationErr *SimulationError
+			if errors.As(err, &simulationErr) {
+				return nil, err
+			}
+
+			tb.logger.Warn("Failed to simulate transaction, using fallback gas limit",
+				zap.String("to", call.To.Hex()),
+				zap.String("fallback_gas_limit", fallbackGasLimit),
+				zap.Error(err))
+		} else {
+			gas = estimatedGas
+		}
+	}
+
+	chainID, _ := new(big.Int).SetString(EthereumChainID, 10)
+	toAddress := call.To
+	data := call.Data
+	value := big.NewInt(0) // No ETH value for ERC20 calls
+
+	unsignedTx := &UnsignedTransaction{
+		To:       toAddress.Hex(),
 		Data:     "0x" + hex.EncodeToString(data),
-		Value:    "0x0", // No ETH value
-		GasLimit: WithdrawalGasLimit,
-		GasPrice: "0x" + gasPrice.Text(16),
+		Value:    "0x" + value.Text(16),
+		GasLimit: strconv.FormatUint(gas, 10),
 		ChainID:  EthereumChainID,
 		Nonce:    "0x" + strconv.FormatUint(nonce, 16),
-	}, nil
-}
+	}
+
+	var serialized []byte
+	if opts.TxType == TxTypeEIP1559 {
+		urgency := opts.FeeUrgency
+		if urgency == "" {
+			urgency = FeeUrgencyNormal
+		}
+
+		fees, err := tb.feeEstimator.EstimateFees(ctx, urgency)
+		if err != nil {
+			return nil, err
+		}
+
+		unsignedTx.Type = "0x2"
+		unsignedTx.MaxFeePerGas = "0x" + fees.MaxFeePerGas.Text(16)
+		unsignedTx.MaxPriorityFeePerGas = "0x" + fees.MaxPriorityFeePerGas.Text(16)
 
-// convertTo8Decimals converts a decimal amount string to 8 decimal places (for BTC tokens)
-func (tb *TransactionBuilder) convertTo8Decimals(amount string) (*big.Int, error) {
-	// Parse the decimal string
-	amountFloat, ok := new(big.Float).SetString(amount)
-	if !ok {
-		return nil, fmt.Errorf("invalid decimal format")
+		// 0x02 || rlp([chainId, nonce, maxPriorityFeePerGas, maxFeePerGas, gasLimit, to, value, data, accessList])
+		payload, err := rlp.EncodeToBytes([]interface{}{
+			chainID, nonce, fees.MaxPriorityFeePerGas, fees.MaxFeePerGas, gas, toAddress, value, data, types.AccessList{},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize transaction: %w", err)
+		}
+		serialized = append([]byte{types.DynamicFeeTxType}, payload...)
+	} else {
+		// Get current gas price from blockchain
+		gasPrice, err := tb.ethClient.SuggestGasPrice(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get gas price from blockchain: %w", err)
+		}
+
+		unsignedTx.Type = "0x0"
+		unsignedTx.GasPrice = "0x" + gasPrice.Text(16)
+
+		// EIP-155 signing payload: rlp([nonce, gasPrice, gasLimit, to, value, data, chainId, 0, 0])
+		serialized, err = rlp.EncodeToBytes([]interface{}{
+			nonce, gasPrice, gas, toAddress, value, data, chainID, uint(0), uint(0),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize transaction: %w", err)
+		}
 	}
+	unsignedTx.Serialized = "0x" + hex.EncodeToString(serialized)
 
-	// Multiply by 10^8 to convert to 8 decimal places
-	multiplier := new(big.Float).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(8), nil))
-	scaledAmount := new(big.Float).Mul(amountFloat, multiplier)
+	return unsignedTx, nil
+}
+
+// parseAmount converts a decimal amount of the asset to its smallest units. Invalid amounts return
+// one of the amount package errors.
+func (tb *TransactionBuilder) parseAmount(value, symbol string) (*big.Int, error) {
+	asset, exists := assets.GlobalRegistry.GetBySymbol(symbol)
+	if !exists {
+		return nil, fmt.Errorf("unsupported asset: %s", symbol)
+	}
 
-	// Convert to big.Int (truncate any fractional units)
-	scaledInt, _ := scaledAmount.Int(nil)
-	return scaledInt, nil
+	return amount.Parse(value, asset.Decimals)
 }
@@ -0,0 +1,273 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Percentage added on top of the estimated gas so that small state changes between simulation and
+// inclusion do not make the transaction run out of gas
+const GasLimitSafetyMarginPercent = 20
+
+// Simulation error codes returned to API clients
+const (
+	SimulationInsufficientAllowance = "insufficient_allowance"
+	SimulationInsufficientBalance   = "insufficient_balance"
+	SimulationVaultPaused           = "vault_paused"
+	SimulationAssetNotSupported     = "asset_not_supported"
+	SimulationMinimumMintNotMet     = "minimum_mint_not_met"
+	SimulationSharesLocked          = "shares_locked"
+	SimulationZeroAmount            = "zero_amount"
+	SimulationDeadlineExceeded      = "request_deadline_exceeded"
+	SimulationDiscountTooLarge      = "discount_too_large"
+	SimulationTransferFailed        = "transfer_failed"
+	SimulationReverted              = "transaction_reverted"
+)
+
+// RevertErrorsABI declares the custom errors the Teller, AtomicQueue, Accountant and OpenZeppelin ERC20
+// tokens revert with, used to decode simulation failures
+const RevertErrorsABI = `[
+	{"type": "error", "name": "TellerWithMultiAssetSupport__Paused", "inputs": []},
+	{"type": "error", "name": "TellerWithMultiAssetSupport__AssetNotSupported", "inputs": []},
+	{"type": "error", "name": "TellerWithMultiAssetSupport__ZeroAssets", "inputs": []},
+	{"type": "error", "name": "TellerWithMultiAssetSupport__ZeroShares", "inputs": []},
+	{"type": "error", "name": "TellerWithMultiAssetSupport__MinimumMintNotMet", "inputs": []},
+	{"type": "error", "name": "TellerWithMultiAssetSupport__MinimumAssetsNotMet", "inputs": []},
+	{"type": "error", "name": "TellerWithMultiAssetSupport__SharesAreLocked", "inputs": []},
+	{"type": "error", "name": "TellerWithMultiAssetSupport__PermitFailedAndAllowanceTooLow", "inputs": []},
+	{"type": "error", "name": "AtomicQueue__Paused", "inputs": []},
+	{"type": "error", "name": "AtomicQueue__SafeRequestOfferAmountZero", "inputs": []},
+	{"type": "error", "name": "AtomicQueue__SafeRequestDiscountTooLarge", "inputs": []},
+	{"type": "error", "name": "AtomicQueue__SafeRequestAccountantOfferMismatch", "inputs": []},
+	{"type": "error", "name": "AtomicQueue__SafeRequestCannotCastToUint88", "inputs": []},
+	{"type": "error", "name": "AtomicQueue__SafeRequestDeadlineExceeded", "inputs": [
+		{"name": "deadline", "type": "uint256"}
+	]},
+	{"type": "error", "name": "AtomicQueue__SafeRequestInsufficientOfferAllowance", "inputs": [
+		{"name": "offerAmount", "type": "uint256"},
+		{"name": "offerAllowance", "type": "uint256"}
+	]},
+	{"type": "error", "name": "AtomicQueue__SafeRequestOfferAmountGreaterThanOfferBalance", "inputs": [
+		{"name": "offerAmount", "type": "uint256"},
+		{"name": "offerBalance", "type": "uint256"}
+	]},
+	{"type": "error", "name": "ERC20InsufficientAllowance", "inputs": [
+		{"name": "spender", "type": "address"},
+		{"name": "allowance", "type": "uint256"},
+		{"name": "needed", "type": "uint256"}
+	]},
+	{"type": "error", "name": "ERC20InsufficientBalance", "inputs": [
+		{"name": "sender", "type": "address"},
+		{"name": "balance", "type": "uint256"},
+		{"name": "needed", "type": "uint256"}
+	]},
+	{"type": "error", "name": "EnforcedPause", "inputs": []},
+	{"type": "error", "name": "AccountantWithRateProviders__Paused", "inputs": []}
+]`
+
+// revertErrorCodes maps decoded custom errors to simulation error codes
+var revertErrorCodes = map[string]string{
+	"TellerWithMultiAssetSupport__Paused":                         SimulationVaultPaused,
+	"TellerWithMultiAssetSupport__AssetNotSupported":              SimulationAssetNotSupported,
+	"TellerWithMultiAssetSupport__ZeroAssets":                     SimulationZeroAmount,
+	"TellerWithMultiAssetSupport__ZeroShares":                     SimulationZeroAmount,
+	"TellerWithMultiAssetSupport__MinimumMintNotMet":              SimulationMinimumMintNotMet,
+	"TellerWithMultiAssetSupport__MinimumAssetsNotMet":            SimulationMinimumMintNotMet,
+	"TellerWithMultiAssetSupport__SharesAreLocked":                SimulationSharesLocked,
+	"TellerWithMultiAssetSupport__PermitFailedAndAllowanceTooLow": SimulationInsufficientAllowance,
+	"AtomicQueue__Paused":                                         SimulationVaultPaused,
+	"AtomicQueue__SafeRequestOfferAmountZero":                     SimulationZeroAmount,
+	"AtomicQueue__SafeRequestDiscountTooLarge":                    SimulationDiscountTooLarge,
+	"AtomicQueue__SafeRequestAccountantOfferMismatch":             SimulationAssetNotSupported,
+	"AtomicQueue__SafeRequestCannotCastToUint88":                  SimulationReverted,
+	"AtomicQueue__SafeRequestDeadlineExceeded":                    SimulationDeadlineExceeded,
+	"AtomicQueue__SafeRequestInsufficientOfferAllowance":          SimulationInsufficientAllowance,
+	"AtomicQueue__SafeRequestOfferAmountGreaterThanOfferBalance":  SimulationInsufficientBalance,
+	"ERC20InsufficientAllowance":                                  SimulationInsufficientAllowance,
+	"ERC20InsufficientBalance":                                    SimulationInsufficientBalance,
+	"EnforcedPause":                                               SimulationVaultPaused,
+	"AccountantWithRateProviders__Paused":                         SimulationVaultPaused,
+}
+
+// SimulationError is returned when a transaction would revert on chain
+type SimulationError struct {
+	Code   string // One of the Simulation* codes
+	Reason string // Decoded revert reason
+}
+
+func (e *SimulationError) Error() string {
+	return fmt.Sprintf("transaction would revert (%s): %s", e.Code, e.Reason)
+}
+
+// SimulatedCall describes a contract call to simulate, along with the token transfer it makes so
+// that generic transfer failures can be attributed to a missing allowance or balance
+type SimulatedCall struct {
+	From    common.Address
+	To      common.Address
+	Data    []byte
+	Token   common.Address // Token pulled from the sender
+	Spender common.Address // Contract that pulls the token
+	Amount  *big.Int       // Amount of the token pulled
+}
+
+// TransactionSimulator checks transactions against the latest chain state before they are returned
+type TransactionSimulator struct {
+	ethClient *ethclient.Client
+	revertABI abi.ABI
+	erc20ABI  abi.ABI
+}
+
+// NewTransactionSimulator creates a new TransactionSimulator
+func NewTransactionSimulator(ethClient *ethclient.Client) (*TransactionSimulator, error) {
+	revertABI, err := abi.JSON(strings.NewReader(RevertErrorsABI))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse revert errors ABI: %w", err)
+	}
+
+	erc20ABI, err := abi.JSON(strings.NewReader(ERC20ABI))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ERC20 ABI: %w", err)
+	}
+
+	return &TransactionSimulator{
+		ethClient: ethClient,
+		revertABI: revertABI,
+		erc20ABI:  erc20ABI,
+	}, nil
+}
+
+// Simulate executes the call with eth_call and returns the gas limit to use, including the safety
+// margin. A call that would revert returns a *SimulationError.
+func (ts *TransactionSimulator) Simulate(ctx context.Context, call SimulatedCall) (uint64, error) {
+	msg := ethereum.CallMsg{
+		From: call.From,
+		To:   &call.To,
+		Data: call.Data,
+	}
+
+	if _, err := ts.ethClient.CallContract(ctx, msg, nil); err != nil {
+		return 0, ts.toSimulationError(ctx, call, err)
+	}
+
+	gas, err := ts.ethClient.EstimateGas(ctx, msg)
+	if err != nil {
+		return 0, ts.toSimulationError(ctx, call, err)
+	}
+
+	return gas + gas*GasLimitSafetyMarginPercent/100, nil
+}
+
+// toSimulationError decodes the revert data carried by an RPC error. Errors without revert data,
+// such as network failures, are returned unchanged.
+func (ts *TransactionSimulator) toSimulationError(ctx context.Context, call SimulatedCall, err error) error {
+	var dataErr rpc.DataError
+	if !errors.As(err, &dataErr) {
+		return fmt.Errorf("failed to simulate transaction: %w", err)
+	}
+
+	var revertData []byte
+	if hexData, ok := dataErr.ErrorData().(string); ok {
+		revertData, _ = hexutil.Decode(hexData)
+	}
+
+	simulationErr := ts.decodeRevert(revertData)
+	if simulationErr.Code == SimulationTransferFailed || simulationErr.Code == SimulationReverted {
+		// Tokens such as WBTC and SafeTransferLib revert without saying why a transfer failed
+		if diagnosed := ts.diagnoseTransfer(ctx, call); diagnosed != nil {
+			return diagnosed
+		}
+	}
+
+	return simulationErr
+}
+
+// decodeRevert maps revert data to a simulation error
+func (ts *TransactionSimulator) decodeRevert(revertData []byte) *SimulationError {
+	if len(revertData) == 0 {
+		return &SimulationError{Code: SimulationReverted, Reason: "execution reverted without a reason"}
+	}
+
+	// Error(string) and Panic(uint256)
+	if reason, err := abi.UnpackRevert(revertData); err == nil {
+		code := SimulationReverted
+		if strings.Contains(strings.ToUpper(reason), "TRANSFER_FROM_FAILED") {
+			code = SimulationTransferFailed
+		} else if strings.Contains(strings.ToLower(reason), "paused") {
+			code = SimulationVaultPaused
+		}
+		return &SimulationError{Code: code, Reason: reason}
+	}
+
+	if len(revertData) >= 4 {
+		for name, abiError := range ts.revertABI.Errors {
+			if !bytes.Equal(abiError.ID[:4], revertData[:4]) {
+				continue
+			}
+
+			reason := name
+			if args, err := abiError.Unpack(revertData); err == nil {
+				reason = fmt.Sprintf("%s%v", name, args)
+			}
+			return &SimulationError{Code: revertErrorCodes[name], Reason: reason}
+		}
+	}
+
+	return &SimulationError{Code: SimulationReverted, Reason: "execution reverted with unknown error 0x" + common.Bytes2Hex(revertData)}
+}
+
+// diagnoseTransfer checks the sender's balance and allowance of the transferred token, returning
+// an error if either is too low
+func (ts *TransactionSimulator) diagnoseTransfer(ctx context.Context, call SimulatedCall) *SimulationError {
+	if call.Amount == nil || call.Token == (common.Address{}) {
+		return nil
+	}
+
+	balance, err := ts.callUint256(ctx, call.Token, "balanceOf", call.From)
+	if err == nil && balance.Cmp(call.Amount) < 0 {
+		return &SimulationError{
+			Code:   SimulationInsufficientBalance,
+			Reason: fmt.Sprintf("balance %s is less than amount %s", balance, call.Amount),
+		}
+	}
+
+	allowance, err := ts.callUint256(ctx, call.Token, "allowance", call.From, call.Spender)
+	if err == nil && allowance.Cmp(call.Amount) < 0 {
+		return &SimulationError{
+			Code:   SimulationInsufficientAllowance,
+			Reason: fmt.Sprintf("allowance %s for spender %s is less than amount %s", allowance, call.Spender.Hex(), call.Amount),
+		}
+	}
+
+	return nil
+}
+
+// callUint256 calls a view function on an ERC20 token that returns a single uint256
+func (ts *TransactionSimulator) callUint256(ctx context.Context, token common.Address, method string, args ...interface{}) (*big.Int, error) {
+	data, err := ts.erc20ABI.Pack(method, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack %s: %w", method, err)
+	}
+
+	result, err := ts.ethClient.CallContract(ctx, ethereum.CallMsg{To: &token, Data: data}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call %s: %w", method, err)
+	}
+
+	var value *big.Int
+	if err := ts.erc20ABI.UnpackIntoInterface(&value, method, result); err != nil {
+		return nil, fmt.Errorf("failed to unpack %s: %w", method, err)
+	}
+
+	return value, nil
+}
@@ -0,0 +1,236 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"go.uber.org/zap"
+	"yield/apps/yield/internal/amount"
+	"yield/apps/yield/internal/assets"
+	"yield/apps/yield/internal/config"
+)
+
+// TellerStateABI reads the Teller's pause flag, share lock period and deposit cap, a wallet's share
+// unlock time and the per-asset deposit settings. isSupported is the per-asset switch of Teller versions
+// without assetData.
+const TellerStateABI = `[
+	{"inputs": [], "name": "isPaused", "outputs": [{"internalType": "bool", "name": "", "type": "bool"}], "stateMutability": "view", "type": "function"},
+	{"inputs": [], "name": "shareLockPeriod", "outputs": [{"internalType": "uint64", "name": "", "type": "uint64"}], "stateMutability": "view", "type": "function"},
+	{"inputs": [], "name": "depositCap", "outputs": [{"internalType": "uint112", "name": "", "type": "uint112"}], "stateMutability": "view", "type": "function"},
+	{"inputs": [{"internalType": "address", "name": "", "type": "address"}], "name": "shareUnlockTime", "outputs": [{"internalType": "uint256", "name": "", "type": "uint256"}], "stateMutability": "view", "type": "function"},
+	{"inputs": [{"internalType": "address", "name": "asset", "type": "address"}], "name": "assetData", "outputs": [
+		{"internalType": "bool", "name": "allowDeposits", "type": "bool"},
+		{"internalType": "bool", "name": "allowWithdraws", "type": "bool"},
+		{"internalType": "uint16", "name": "sharePremium", "type": "uint16"}
+	], "stateMutability": "view", "type": "function"},
+	{"inputs": [{"internalType": "address", "name": "asset", "type": "address"}], "name": "isSupported", "outputs": [{"internalType": "bool", "name": "", "type": "bool"}], "stateMutability": "view", "type": "function"}
+]`
+
+// AccountantStateABI reads the accountant's state struct, which holds its fees and pause flag
+const AccountantStateABI = `[{
+	"inputs": [],
+	"name": "accountantState",
+	"outputs": [
+		{"internalType": "address", "name": "payoutAddress", "type": "address"},
+		{"internalType": "uint96", "name": "highwaterMark", "type": "uint96"},
+		{"internalType": "uint128", "name": "feesOwedInBase", "type": "uint128"},
+		{"internalType": "uint128", "name": "totalSharesLastUpdate", "type": "uint128"},
+		{"internalType": "uint96", "name": "exchangeRate", "type": "uint96"},
+		{"internalType": "uint16", "name": "allowedExchangeRateChangeUpper", "type": "uint16"},
+		{"internalType": "uint16", "name": "allowedExchangeRateChangeLower", "type": "uint16"},
+		{"internalType": "uint64", "name": "lastUpdateTimestamp", "type": "uint64"},
+		{"internalType": "bool", "name": "isPaused", "type": "bool"},
+		{"internalType": "uint24", "name": "minimumUpdateDelayInSeconds", "type": "uint24"},
+		{"internalType": "uint16", "name": "platformFee", "type": "uint16"},
+		{"internalType": "uint16", "name": "performanceFee", "type": "uint16"}
+	],
+	"stateMutability": "view",
+	"type": "function"
+}]`
+
+// AtomicQueueStateABI reads the AtomicQueue's pause flag and largest accepted discount
+const AtomicQueueStateABI = `[
+	{"inputs": [], "name": "isPaused", "outputs": [{"internalType": "bool", "name": "", "type": "bool"}], "stateMutability": "view", "type": "function"},
+	{"inputs": [], "name": "MAX_DISCOUNT", "outputs": [{"internalType": "uint256", "name": "", "type": "uint256"}], "stateMutability": "view", "type": "function"}
+]`
+
+// VaultSettingsReader reads the Teller, Accountant and AtomicQueue settings that decide whether a
+// deposit or withdrawal can go through, in one Multicall3 call
+type VaultSettingsReader struct {
+	multicaller   *Multicaller
+	tellerABI     abi.ABI
+	accountantABI abi.ABI
+	queueABI      abi.ABI
+	vaultConfig   config.VaultConfig
+	logger        *zap.Logger
+}
+
+// NewVaultSettingsReader creates a new VaultSettingsReader. Settings the contracts do not store, such as
+// the minimum withdrawal, come from vaultConfig.
+func NewVaultSettingsReader(client *ethclient.Client, vaultConfig config.VaultConfig, logger *zap.Logger) (*VaultSettingsReader, error) {
+	multicaller, err := NewMulticaller(client)
+	if err != nil {
+		return nil, err
+	}
+
+	tellerABI, err := abi.JSON(strings.NewReader(TellerStateABI))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse teller state ABI: %w", err)
+	}
+
+	accountantABI, err := abi.JSON(strings.NewReader(AccountantStateABI))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse accountant state ABI: %w", err)
+	}
+
+	queueABI, err := abi.JSON(strings.NewReader(AtomicQueueStateABI))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse atomic queue state ABI: %w", err)
+	}
+
+	return &VaultSettingsReader{
+		multicaller:   multicaller,
+		tellerABI:     tellerABI,
+		accountantABI: accountantABI,
+		queueABI:      queueABI,
+		vaultConfig:   vaultConfig,
+		logger:        logger,
+	}, nil
+}
+
+// Read reads the vault settings at the block. A setting whose read reverts, for example on a contract
+// version without it, is left out rather than failing the whole read.
+func (v *VaultSettingsReader) Read(ctx context.Context, block *big.Int) (*VaultSettingsResponse, error) {
+	teller := common.HexToAddress(assets.TellerContractAddress)
+	accountant := common.HexToAddress(assets.AccountantContractAddress)
+	queue := common.HexToAddress(assets.AtomicRequestContractAddress)
+
+	depositAssets := make([]*assets.Asset, 0)
+	for _, asset := range assets.GlobalRegistry.GetAllAsArray() {
+		if asset.Address != assets.LBTCVAddress {
+			depositAssets = append(depositAssets, asset)
+		}
+	}
+
+	calls := []MulticallCall{
+		{Target: teller, CallData: v.tellerABI.Methods["isPaused"].ID},
+		{Target: teller, CallData: v.tellerABI.Methods["shareLockPeriod"].ID},
+		{Target: accountant, CallData: v.accountantABI.Methods["accountantState"].ID},
+		{Target: queue, CallData: v.queueABI.Methods["isPaused"].ID},
+		{Target: queue, CallData: v.queueABI.Methods["MAX_DISCOUNT"].ID},
+		{Target: teller, CallData: v.tellerABI.Methods["depositCap"].ID},
+	}
+	for _, asset := range depositAssets {
+		assetData, err := v.tellerABI.Pack("assetData", asset.Address)
+		if err != nil {
+			return nil, fmt.Errorf("failed to pack assetData call: %w", err)
+		}
+		isSupported, err := v.tellerABI.Pack("isSupported", asset.Address)
+		if err != nil {
+			return nil, fmt.Errorf("failed to pack isSupported call: %w", err)
+		}
+		calls = append(calls,
+			MulticallCall{Target: teller, CallData: assetData},
+			MulticallCall{Target: teller, CallData: isSupported})
+	}
+	for i := range calls {
+		calls[i].AllowFailure = true
+	}
+
+	results, err := v.multicaller.Aggregate3(ctx, calls, block)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := &VaultSettingsResponse{
+		Paused: VaultPauseResponse{
+			Teller:      v.unpackBool(v.tellerABI, "isPaused", results[0]),
+			AtomicQueue: v.unpackBool(v.queueABI, "isPaused", results[3]),
+		},
+		Assets: make([]VaultAssetResponse, 0, len(depositAssets)),
+	}
+
+	if values := v.unpack(v.tellerABI, "shareLockPeriod", results[1]); values != nil {
+		period := values[0].(uint64)
+		settings.ShareLockPeriodSeconds = &period
+	}
+
+	if values := v.unpack(v.accountantABI, "accountantState", results[2]); values != nil {
+		paused := values[8].(bool)
+		settings.Paused.Accountant = &paused
+		settings.Fees = &VaultFeesResponse{
+			PlatformFeeBps:    int(values[10].(uint16)),
+			PerformanceFeeBps: int(values[11].(uint16)),
+		}
+	}
+
+	// Discounts are in millionths; the AtomicQueue accepts anything from no discount up to MAX_DISCOUNT
+	if maxDiscount := unpackUint256(v.queueABI, "MAX_DISCOUNT", results[4]); maxDiscount != nil {
+		settings.WithdrawalDiscount = &DiscountRangeResponse{
+			MinBps: 0,
+			MaxBps: maxDiscount.Int64() * bpsDenominator / discountDenominator,
+		}
+	}
+
+	// The cap is in shares; type(uint112).max means no cap is set
+	if depositCap := unpackUint256(v.tellerABI, "depositCap", results[5]); depositCap != nil && depositCap.Cmp(noDepositCap) != 0 {
+		settings.DepositCap = amount.Format(depositCap, 8)
+	}
+
+	minimumWithdrawal := amount.Format(new(big.Int).SetUint64(v.vaultConfig.MinimumWithdrawalShares), 8)
+	for i, asset := range depositAssets {
+		assetSettings := VaultAssetResponse{
+			Symbol:  asset.Symbol,
+			Address: asset.Address.Hex(),
+		}
+
+		if values := v.unpack(v.tellerABI, "assetData", results[6+2*i]); values != nil {
+			allowDeposits, allowWithdraws, sharePremium := values[0].(bool), values[1].(bool), int(values[2].(uint16))
+			assetSettings.AllowDeposits, assetSettings.AllowWithdraws = &allowDeposits, &allowWithdraws
+			assetSettings.SharePremiumBps = &sharePremium
+		} else if supported := v.unpackBool(v.tellerABI, "isSupported", results[7+2*i]); supported != nil {
+			// Older Tellers have one switch for both directions and no premium
+			assetSettings.AllowDeposits, assetSettings.AllowWithdraws = supported, supported
+		}
+
+		if assetSettings.AllowWithdraws == nil || *assetSettings.AllowWithdraws {
+			assetSettings.MinimumWithdrawal = minimumWithdrawal
+		}
+
+		settings.Assets = append(settings.Assets, assetSettings)
+	}
+
+	return settings, nil
+}
+
+// unpack decodes a batched call's return values, or returns nil when the call reverted or the contract
+// returned something else
+func (v *VaultSettingsReader) unpack(contractABI abi.ABI, method string, result MulticallResult) []interface{} {
+	if !result.Success {
+		v.logger.Warn("Vault setting read reverted", zap.String("method", method))
+		return nil
+	}
+
+	values, err := contractABI.Unpack(method, result.ReturnData)
+	if err != nil {
+		v.logger.Warn("Failed to decode vault setting", zap.String("method", method), zap.Error(err))
+		return nil
+	}
+
+	return values
+}
+
+func (v *VaultSettingsReader) unpackBool(contractABI abi.ABI, method string, result MulticallResult) *bool {
+	values := v.unpack(contractABI, method, result)
+	if values == nil {
+		return nil
+	}
+
+	value := values[0].(bool)
+	return &value
+}
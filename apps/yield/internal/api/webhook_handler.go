@@ -0,0 +1,263 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+	"yield/apps/yield/internal/model"
+	"yield/apps/yield/internal/repository"
+	"yield/apps/yield/internal/webhook"
+)
+
+const (
+	// MinWebhookSecretLength is the shortest secret accepted for signing webhook payloads
+	MinWebhookSecretLength = 16
+
+	DefaultDeliveryPageSize = 50
+	MaxDeliveryPageSize     = 200
+)
+
+var webhookEventTypes = map[string]bool{
+	model.WebhookEventDeposit:             true,
+	model.WebhookEventWithdrawalRequested: true,
+	model.WebhookEventWithdrawalCompleted: true,
+	model.WebhookEventTransferIn:          true,
+	model.WebhookEventTransferOut:         true,
+}
+
+// WebhookHandler handles webhook subscription requests
+type WebhookHandler struct {
+	webhookRepository *repository.WebhookRepository
+	logger            *zap.Logger
+}
+
+// NewWebhookHandler creates a new WebhookHandler
+func NewWebhookHandler(webhookRepository *repository.WebhookRepository, logger *zap.Logger) *WebhookHandler {
+	return &WebhookHandler{
+		webhookRepository: webhookRepository,
+		logger:            logger,
+	}
+}
+
+// CreateWebhook handles POST /api/webhooks
+func (h *WebhookHandler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var req CreateWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "invalid_request_body", "Invalid JSON in request body")
+		return
+	}
+
+	if err := webhook.ValidateURL(req.URL); err != nil {
+		message := "URL must be an absolute https URL"
+		if errors.Is(err, webhook.ErrDisallowedTarget) {
+			message = "URL must resolve to a public address, not a loopback, private or link-local one"
+		}
+		h.writeErrorResponse(w, http.StatusBadRequest, "invalid_url", message)
+		return
+	}
+
+	if len(req.Secret) < MinWebhookSecretLength {
+		h.writeErrorResponse(w, http.StatusBadRequest, "invalid_secret", fmt.Sprintf("Secret must be at least %d characters", MinWebhookSecretLength))
+		return
+	}
+
+	subscription := model.WebhookSubscription{
+		URL:        req.URL,
+		Secret:     req.Secret,
+		EventTypes: []string{},
+	}
+
+	if req.WalletAddress != "" {
+		if !common.IsHexAddress(req.WalletAddress) {
+			h.writeErrorResponse(w, http.StatusBadRequest, "invalid_wallet_address", "Invalid Ethereum address format")
+			return
+		}
+		// Events carry checksummed addresses
+		walletAddress := common.HexToAddress(req.WalletAddress).Hex()
+		subscription.WalletAddress = &walletAddress
+	}
+
+	for _, eventType := range req.EventTypes {
+		eventType = strings.ToLower(eventType)
+		if !webhookEventTypes[eventType] {
+			h.writeErrorResponse(w, http.StatusBadRequest, "invalid_event_type", "Event types must be deposit, withdrawal_requested, withdrawal_completed, transfer_in or transfer_out")
+			return
+		}
+		subscription.EventTypes = append(subscription.EventTypes, eventType)
+	}
+
+	created, err := h.webhookRepository.CreateSubscription(subscription)
+	if err != nil {
+		h.logger.Error("Failed to create webhook subscription", zap.Error(err))
+		h.writeErrorResponse(w, http.StatusInternalServerError, "database_error", "Failed to create webhook subscription")
+		return
+	}
+
+	h.writeJSONResponse(w, http.StatusCreated, toWebhookSubscriptionResponse(*created))
+}
+
+// ListWebhooks handles GET /api/webhooks
+func (h *WebhookHandler) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	subscriptions, err := h.webhookRepository.ListSubscriptions()
+	if err != nil {
+		h.logger.Error("Failed to list webhook subscriptions", zap.Error(err))
+		h.writeErrorResponse(w, http.StatusInternalServerError, "database_error", "Failed to retrieve webhook subscriptions")
+		return
+	}
+
+	response := WebhookSubscriptionListResponse{Subscriptions: make([]WebhookSubscriptionResponse, 0, len(subscriptions))}
+	for _, subscription := range subscriptions {
+		response.Subscriptions = append(response.Subscriptions, toWebhookSubscriptionResponse(subscription))
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, response)
+}
+
+// GetWebhook handles GET /api/webhooks/{id}
+func (h *WebhookHandler) GetWebhook(w http.ResponseWriter, r *http.Request) {
+	subscription, ok := h.lookupSubscription(w, r)
+	if !ok {
+		return
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, toWebhookSubscriptionResponse(*subscription))
+}
+
+// DeleteWebhook handles DELETE /api/webhooks/{id}
+func (h *WebhookHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if _, err := uuid.Parse(id); err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "invalid_subscription_id", "Subscription ID must be a UUID")
+		return
+	}
+
+	deleted, err := h.webhookRepository.DeleteSubscription(id)
+	if err != nil {
+		h.logger.Error("Failed to delete webhook subscription", zap.String("subscription_id", id), zap.Error(err))
+		h.writeErrorResponse(w, http.StatusInternalServerError, "database_error", "Failed to delete webhook subscription")
+		return
+	}
+
+	if !deleted {
+		h.writeErrorResponse(w, http.StatusNotFound, "subscription_not_found", "Webhook subscription not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListWebhookDeliveries handles GET /api/webhooks/{id}/deliveries
+func (h *WebhookHandler) ListWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	limit := DefaultDeliveryPageSize
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > MaxDeliveryPageSize {
+			h.writeErrorResponse(w, http.StatusBadRequest, "invalid_limit", fmt.Sprintf("Limit must be between 1 and %d", MaxDeliveryPageSize))
+			return
+		}
+		limit = parsed
+	}
+
+	subscription, ok := h.lookupSubscription(w, r)
+	if !ok {
+		return
+	}
+
+	deliveries, err := h.webhookRepository.ListDeliveries(subscription.ID, limit)
+	if err != nil {
+		h.logger.Error("Failed to list webhook deliveries", zap.String("subscription_id", subscription.ID), zap.Error(err))
+		h.writeErrorResponse(w, http.StatusInternalServerError, "database_error", "Failed to retrieve webhook deliveries")
+		return
+	}
+
+	response := WebhookDeliveryListResponse{Deliveries: make([]WebhookDeliveryResponse, 0, len(deliveries))}
+	for _, delivery := range deliveries {
+		deliveryResponse := WebhookDeliveryResponse{
+			ID:             delivery.ID,
+			EventType:      delivery.EventType,
+			TxHash:         delivery.TxHash,
+			LogIndex:       delivery.LogIndex,
+			Status:         delivery.Status,
+			Attempts:       delivery.Attempts,
+			LastAttemptAt:  delivery.LastAttemptAt,
+			ResponseStatus: delivery.ResponseStatus,
+			LastError:      delivery.LastError,
+			CreatedAt:      delivery.CreatedAt,
+			DeliveredAt:    delivery.DeliveredAt,
+			Payload:        delivery.Payload,
+		}
+		// The next attempt is only meaningful while the delivery is still being retried
+		if delivery.Status == model.WebhookDeliveryPending {
+			nextAttemptAt := delivery.NextAttemptAt
+			deliveryResponse.NextAttemptAt = &nextAttemptAt
+		}
+		response.Deliveries = append(response.Deliveries, deliveryResponse)
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, response)
+}
+
+// lookupSubscription loads the subscription named in the path, writing an error response if it cannot
+func (h *WebhookHandler) lookupSubscription(w http.ResponseWriter, r *http.Request) (*model.WebhookSubscription, bool) {
+	id := mux.Vars(r)["id"]
+	if _, err := uuid.Parse(id); err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "invalid_subscription_id", "Subscription ID must be a UUID")
+		return nil, false
+	}
+
+	subscription, err := h.webhookRepository.GetSubscription(id)
+	if err != nil {
+		h.logger.Error("Failed to get webhook subscription", zap.String("subscription_id", id), zap.Error(err))
+		h.writeErrorResponse(w, http.StatusInternalServerError, "database_error", "Failed to retrieve webhook subscription")
+		return nil, false
+	}
+
+	if subscription == nil {
+		h.writeErrorResponse(w, http.StatusNotFound, "subscription_not_found", "Webhook subscription not found")
+		return nil, false
+	}
+
+	return subscription, true
+}
+
+func toWebhookSubscriptionResponse(subscription model.WebhookSubscription) WebhookSubscriptionResponse {
+	eventTypes := subscription.EventTypes
+	if eventTypes == nil {
+		eventTypes = []string{}
+	}
+
+	return WebhookSubscriptionResponse{
+		ID:            subscription.ID,
+		URL:           subscription.URL,
+		WalletAddress: subscription.WalletAddress,
+		EventTypes:    eventTypes,
+		CreatedAt:     subscription.CreatedAt,
+	}
+}
+
+// writeJSONResponse writes a JSON response with the specified status code
+func (h *WebhookHandler) writeJSONResponse(w http.ResponseWriter, statusCode int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		h.logger.Error("Failed to encode JSON response", zap.Error(err))
+	}
+}
+
+// writeErrorResponse writes an error response
+func (h *WebhookHandler) writeErrorResponse(w http.ResponseWriter, statusCode int, errorCode, message string) {
+	errorResponse := ErrorResponse{
+		Error:   errorCode,
+		Message: message,
+	}
+	h.writeJSONResponse(w, statusCode, errorResponse)
+}
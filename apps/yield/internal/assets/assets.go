@@ -4,16 +4,18 @@ import "github.com/ethereum/go-ethereum/common"
 
 // Asset represents a cryptocurrency asset with its properties
 type Asset struct {
-	Symbol   string         `json:"symbol"`
-	Name     string         `json:"name"`
-	Address  common.Address `json:"address"`
-	Decimals int            `json:"decimals"`
+	Symbol         string         `json:"symbol"`
+	Name           string         `json:"name"`
+	Address        common.Address `json:"address"`
+	Decimals       int            `json:"decimals"`
+	SupportsPermit bool           `json:"supports_permit"` // Implements EIP-2612 permit
 }
 
 // AssetRegistry holds all supported assets
 type AssetRegistry struct {
-	assets map[string]*Asset
-	byAddress map[common.Address]*Asset
+	assets     map[string]*Asset
+	byAddress  map[common.Address]*Asset
+	priceFeeds map[uint64]*PriceFeeds // By chain ID
 }
 
 // NewAssetRegistry creates a new asset registry with all supported assets
@@ -21,15 +23,19 @@ func NewAssetRegistry() *AssetRegistry {
 	registry := &AssetRegistry{
 		assets:    make(map[string]*Asset),
 		byAddress: make(map[common.Address]*Asset),
+		priceFeeds: map[uint64]*PriceFeeds{
+			1: mainnetPriceFeeds,
+		},
 	}
 
 	// Define all supported assets
 	supportedAssets := []*Asset{
 		{
-			Symbol:   "LBTC",
-			Name:     "Lombard Staked BTC",
-			Address:  common.HexToAddress("0x8236a87084f8b84306f72007f36f2618a5634494"),
-			Decimals: 8,
+			Symbol:         "LBTC",
+			Name:           "Lombard Staked BTC",
+			Address:        common.HexToAddress("0x8236a87084f8b84306f72007f36f2618a5634494"),
+			Decimals:       8,
+			SupportsPermit: true,
 		},
 		{
 			Symbol:   "WBTC",
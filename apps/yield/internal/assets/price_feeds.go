@@ -0,0 +1,51 @@
+package assets
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// PriceFeed is a Chainlink aggregator. Answers older than MaxAge, measured against the block they are
+// read at, are considered stale.
+type PriceFeed struct {
+	Address common.Address
+	MaxAge  time.Duration
+}
+
+// PriceFeeds are the aggregators assets are valued with on one chain. BTCPegs holds each asset's price
+// in BTC by symbol; assets without a peg feed are valued at par with BTC.
+type PriceFeeds struct {
+	BTCUSD  PriceFeed
+	BTCPegs map[string]PriceFeed
+}
+
+// Ethereum mainnet feeds. The allowed age is the feed's heartbeat with an hour of slack.
+var mainnetPriceFeeds = &PriceFeeds{
+	BTCUSD: PriceFeed{
+		Address: common.HexToAddress("0xF4030086522a5bEEa4988F8cA5B36dbC97BeE88c"),
+		MaxAge:  2 * time.Hour,
+	},
+	BTCPegs: map[string]PriceFeed{
+		"WBTC": {
+			Address: common.HexToAddress("0xfdFD9C85aD200c506Cf9e21F1FD8dd01932FBB23"),
+			MaxAge:  25 * time.Hour,
+		},
+		"LBTC": {
+			Address: common.HexToAddress("0x5c29868C58b6e15e2b962943278969Ab6a7D3212"),
+			MaxAge:  25 * time.Hour,
+		},
+	},
+}
+
+// GetPriceFeeds returns the price feeds configured for a chain
+func (r *AssetRegistry) GetPriceFeeds(chainID uint64) (*PriceFeeds, bool) {
+	feeds, exists := r.priceFeeds[chainID]
+	return feeds, exists
+}
+
+// SetPriceFeeds configures the price feeds for a chain, replacing any already set. It is meant to be
+// called while setting up, before the registry is shared.
+func (r *AssetRegistry) SetPriceFeeds(chainID uint64, feeds *PriceFeeds) {
+	r.priceFeeds[chainID] = feeds
+}
@@ -1,10 +1,12 @@
 package config
 
 import (
+	"fmt"
 	"github.com/joho/godotenv"
 	"log"
 	"os"
 	"strconv"
+	"time"
 )
 
 type Config struct {
@@ -15,6 +17,35 @@ type Config struct {
 	ChunkSize      uint64
 	FinalityOffset uint64
 	APIPort        int
+	Fees           FeeConfig
+	Cache          CacheConfig
+	Vault          VaultConfig
+}
+
+// FeeConfig controls how EIP-1559 fees are derived from eth_feeHistory. Each urgency tier takes
+// the given percentile of the priority fees paid in recent blocks.
+type FeeConfig struct {
+	HistoryBlocks    uint64
+	SlowPercentile   float64
+	NormalPercentile float64
+	FastPercentile   float64
+}
+
+// CacheConfig controls how long on-chain read responses are served from memory. Responses are keyed by
+// the block they were read at; HeadTTL bounds how long a block number is reused as the latest block.
+// A TTL of zero disables caching for that endpoint.
+type CacheConfig struct {
+	HeadTTL       time.Duration
+	InfoTTL       time.Duration
+	BalanceTTL    time.Duration
+	HistoricalTTL time.Duration // Balances at a past block or timestamp, which never change
+}
+
+// VaultConfig holds vault settings that are not stored on-chain. The AtomicQueue accepts any non-zero
+// offer, but the solver filling requests skips smaller ones, so MinimumWithdrawalShares (in share units)
+// is the vault's published minimum of 0.00010001 LBTCv unless overridden.
+type VaultConfig struct {
+	MinimumWithdrawalShares uint64
 }
 
 // NewConfig loads configuration from environment variables
@@ -24,7 +55,7 @@ func NewConfig() *Config {
 		log.Fatalf("Warning: Could not load .env file: %v", err)
 	}
 
-	return &Config{
+	cfg := &Config{
 		RpcURL:         getEnvOrFatal("RPC_URL"),
 		DbURL:          getEnvOrFatal("DB_URL"),
 		KafkaBroker:    getEnvOrFatal("KAFKA_BROKER"),
@@ -32,7 +63,52 @@ func NewConfig() *Config {
 		ChunkSize:      getEnvUint64("CHUNK_SIZE", 100),
 		FinalityOffset: getEnvUint64("FINALITY_OFFSET", 12),
 		APIPort:        getEnvInt("API_PORT", 8080),
+		Fees: FeeConfig{
+			HistoryBlocks:    getEnvUint64("FEE_HISTORY_BLOCKS", 20),
+			SlowPercentile:   getEnvFloat("FEE_PERCENTILE_SLOW", 10),
+			NormalPercentile: getEnvFloat("FEE_PERCENTILE_NORMAL", 50),
+			FastPercentile:   getEnvFloat("FEE_PERCENTILE_FAST", 90),
+		},
+		Cache: CacheConfig{
+			HeadTTL:       getEnvSeconds("CACHE_HEAD_TTL_SECONDS", 2),
+			InfoTTL:       getEnvSeconds("CACHE_INFO_TTL_SECONDS", 12),
+			BalanceTTL:    getEnvSeconds("CACHE_BALANCE_TTL_SECONDS", 12),
+			HistoricalTTL: getEnvSeconds("CACHE_HISTORICAL_TTL_SECONDS", 3600),
+		},
+		Vault: VaultConfig{
+			MinimumWithdrawalShares: getEnvUint64("MINIMUM_WITHDRAWAL_SHARES", 10001),
+		},
+	}
+
+	if err := cfg.Fees.Validate(); err != nil {
+		log.Fatalf("Invalid fee configuration: %v", err)
+	}
+
+	return cfg
+}
+
+// Validate checks that the percentiles are within 0 to 100 and ascending from slow to fast, so that a
+// faster tier never pays a lower priority fee than a slower one
+func (f FeeConfig) Validate() error {
+	percentiles := []struct {
+		name  string
+		value float64
+	}{
+		{"FEE_PERCENTILE_SLOW", f.SlowPercentile},
+		{"FEE_PERCENTILE_NORMAL", f.NormalPercentile},
+		{"FEE_PERCENTILE_FAST", f.FastPercentile},
+	}
+
+	for i, percentile := range percentiles {
+		if percentile.value < 0 || percentile.value > 100 {
+			return fmt.Errorf("%s must be between 0 and 100, got %g", percentile.name, percentile.value)
+		}
+		if i > 0 && percentile.value < percentiles[i-1].value {
+			return fmt.Errorf("%s (%g) must not be below %s (%g)", percentile.name, percentile.value, percentiles[i-1].name, percentiles[i-1].value)
+		}
 	}
+
+	return nil
 }
 
 func getEnvOrFatal(key string) string {
@@ -62,3 +138,16 @@ func getEnvInt(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+func getEnvSeconds(key string, defaultSeconds uint64) time.Duration {
+	return time.Duration(getEnvUint64(key, defaultSeconds)) * time.Second
+}
@@ -0,0 +1,31 @@
+package config
+
+import "testing"
+
+func TestFeeConfigValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		fees  FeeConfig
+		valid bool
+	}{
+		{"Defaults", FeeConfig{SlowPercentile: 10, NormalPercentile: 50, FastPercentile: 90}, true},
+		{"EqualTiers", FeeConfig{SlowPercentile: 50, NormalPercentile: 50, FastPercentile: 50}, true},
+		{"Bounds", FeeConfig{SlowPercentile: 0, NormalPercentile: 50, FastPercentile: 100}, true},
+		{"Descending", FeeConfig{SlowPercentile: 90, NormalPercentile: 50, FastPercentile: 10}, false},
+		{"FastBelowNormal", FeeConfig{SlowPercentile: 10, NormalPercentile: 60, FastPercentile: 55}, false},
+		{"Negative", FeeConfig{SlowPercentile: -1, NormalPercentile: 50, FastPercentile: 90}, false},
+		{"AboveHundred", FeeConfig{SlowPercentile: 10, NormalPercentile: 50, FastPercentile: 101}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.fees.Validate()
+			if tt.valid && err != nil {
+				t.Errorf("Expected %+v to be valid, got %v", tt.fees, err)
+			}
+			if !tt.valid && err == nil {
+				t.Errorf("Expected %+v to be rejected", tt.fees)
+			}
+		})
+	}
+}
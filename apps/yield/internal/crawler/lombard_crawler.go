@@ -16,6 +16,7 @@ import (
 	"strings"
 	"sync"
 	"time"
+	"yield/apps/yield/internal/amount"
 	"yield/apps/yield/internal/assets"
 	"yield/apps/yield/internal/config"
 	"yield/apps/yield/internal/model"
@@ -68,11 +69,24 @@ const AtomicRequestABI = `[
 	}
 ]`
 
+const VaultABI = `[
+	{
+		"type": "event",
+		"name": "Transfer",
+		"inputs": [
+			{"internalType": "address", "name": "from", "type": "address", "indexed": true},
+			{"internalType": "address", "name": "to", "type": "address", "indexed": true},
+			{"internalType": "uint256", "name": "amount", "type": "uint256", "indexed": false}
+		]
+	}
+]`
+
 // Event signatures
 var (
 	DepositEventSig           = crypto.Keccak256Hash([]byte("Deposit(uint256,address,address,uint256,uint256,uint256,uint256)"))
 	AtomicRequestUpdatedSig   = crypto.Keccak256Hash([]byte("AtomicRequestUpdated(address,address,address,uint256,uint256,uint256,uint256)"))
 	AtomicRequestFulfilledSig = crypto.Keccak256Hash([]byte("AtomicRequestFulfilled(address,address,address,uint256,uint256,uint256)"))
+	ShareTransferEventSig     = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
 )
 
 type LombardCrawler struct {
@@ -87,6 +101,7 @@ type LombardCrawler struct {
 	registeredAddrs            sync.Map                  // map[common.Address]bool
 	tellerABI                  abi.ABI
 	atomicRequestABI           abi.ABI
+	vaultABI                   abi.ABI
 	repository                 *repository.CrawlerRepository
 	monitoredAddressRepository *repository.MonitoredAddressRepository
 }
@@ -96,31 +111,7 @@ type CrawlerState struct {
 	UpdatedAt          time.Time `db:"updated_at"`
 }
 
-// Helper functions for amount conversion and asset name mapping
-func (c *LombardCrawler) convertToDecimalAmount(amount *big.Int, decimals int) string {
-	// Convert wei to decimal representation
-	divisor := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
-	wholePart := new(big.Int).Div(amount, divisor)
-	remainder := new(big.Int).Mod(amount, divisor)
-
-	// Format as decimal string
-	if remainder.Cmp(big.NewInt(0)) == 0 {
-		return wholePart.String()
-	} else {
-		// Pad remainder with leading zeros to match decimal places
-		remainderStr := remainder.String()
-		for len(remainderStr) < decimals {
-			remainderStr = "0" + remainderStr
-		}
-		// Remove trailing zeros
-		remainderStr = strings.TrimRight(remainderStr, "0")
-		if remainderStr == "" {
-			return wholePart.String()
-		}
-		return wholePart.String() + "." + remainderStr
-	}
-}
-
+// Helper functions for asset name mapping
 func (c *LombardCrawler) getAssetName(assetAddress common.Address) string {
 	if name, exists := c.supportedTokens[assetAddress]; exists {
 		return name
@@ -128,6 +119,16 @@ func (c *LombardCrawler) getAssetName(assetAddress common.Address) string {
 	return assetAddress.Hex()
 }
 
+// formatAmount converts an amount of a token in its smallest units to a decimal string, using the token's
+// decimals from the asset registry
+func (c *LombardCrawler) formatAmount(token common.Address, units *big.Int) (string, error) {
+	asset, exists := assets.GlobalRegistry.GetByAddress(token)
+	if !exists {
+		return "", fmt.Errorf("token %s is not in the asset registry", token.Hex())
+	}
+	return amount.Format(units, asset.Decimals), nil
+}
+
 func NewLombardCrawler(
 	config *config.Config,
 	db *sql.DB,
@@ -152,6 +153,12 @@ func NewLombardCrawler(
 		return nil, fmt.Errorf("failed to parse atomic request ABI: %w", err)
 	}
 
+	// Parse vault ABI
+	parsedVaultABI, err := abi.JSON(strings.NewReader(VaultABI))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse vault ABI: %w", err)
+	}
+
 	// Initialize supported tokens map from asset registry
 	supportedTokens := make(map[common.Address]string)
 	for _, asset := range assets.GlobalRegistry.GetAllAsArray() {
@@ -175,6 +182,7 @@ func NewLombardCrawler(
 		supportedTokens:            supportedTokens,
 		tellerABI:                  parsedTellerABI,
 		atomicRequestABI:           parsedAtomicABI,
+		vaultABI:                   parsedVaultABI,
 		repository:                 repository,
 		monitoredAddressRepository: monitoredAddressRepository,
 	}
@@ -273,13 +281,13 @@ func (c *LombardCrawler) processBlockRange(fromBlock, toBlock uint64) error {
 }
 
 func (c *LombardCrawler) processVaultEvents(fromBlock, toBlock uint64) error {
-	// Filter for Deposit, Exit, and AtomicRequestUpdated events
+	// Filter for Deposit, AtomicRequestUpdated, AtomicRequestFulfilled and share Transfer events
 	query := ethereum.FilterQuery{
 		FromBlock: big.NewInt(int64(fromBlock)),
 		ToBlock:   big.NewInt(int64(toBlock)),
-		Addresses: []common.Address{c.tellerAddress, c.atomicRequestAddress},
+		Addresses: []common.Address{c.tellerAddress, c.atomicRequestAddress, c.vaultAddress},
 		Topics: [][]common.Hash{
-			{DepositEventSig, AtomicRequestUpdatedSig, AtomicRequestFulfilledSig}, // OR condition
+			{DepositEventSig, AtomicRequestUpdatedSig, AtomicRequestFulfilledSig, ShareTransferEventSig}, // OR condition
 		},
 	}
 
@@ -299,6 +307,11 @@ func (c *LombardCrawler) processVaultEvents(fromBlock, toBlock uint64) error {
 }
 
 func (c *LombardCrawler) processVaultEvent(eventLog types.Log) error {
+	// Share transfers are frequent, so the receipt is only fetched for those of monitored wallets
+	if eventLog.Topics[0] == ShareTransferEventSig {
+		return c.processShareTransferEvent(eventLog)
+	}
+
 	// Get transaction receipt to ensure success
 	receipt, err := c.client.TransactionReceipt(context.Background(), eventLog.TxHash)
 	if err != nil {
@@ -389,6 +402,11 @@ func (c *LombardCrawler) processDepositEvent(eventLog types.Log, blockTime time.
 		return fmt.Errorf("failed to marshal event: %w", err)
 	}
 
+	depositAmount, err := c.formatAmount(depositAsset, eventData.DepositAmount)
+	if err != nil {
+		return err
+	}
+
 	// Store in outbox
 	return c.repository.StoreOutboxEvent(model.OutboxEvent{
 		TxHash:        eventLog.TxHash.Hex(),
@@ -399,7 +417,7 @@ func (c *LombardCrawler) processDepositEvent(eventLog types.Log, blockTime time.
 		TxDate:        blockTime,
 		Address:       userAddr.Hex(),
 		EventBlob:     eventBlob,
-		Amount:        c.convertToDecimalAmount(eventData.DepositAmount, 8), // WBTC, LBTC, and cbBTC all use 8 decimals
+		Amount:        depositAmount,
 		FromAssetName: c.getAssetName(depositAsset),
 		ToAssetName:   c.getAssetName(c.vaultAddress),
 	})
@@ -462,6 +480,12 @@ func (c *LombardCrawler) processAtomicRequestFulfilledEvent(eventLog types.Log,
 		return fmt.Errorf("failed to marshal event: %w", err)
 	}
 
+	// The want amount received is the withdrawal amount
+	withdrawalAmount, err := c.formatAmount(wantToken, eventData.WantAmountReceived)
+	if err != nil {
+		return err
+	}
+
 	// Use the user address as the wallet address for this event
 	userAddr := user.Hex()
 
@@ -475,7 +499,7 @@ func (c *LombardCrawler) processAtomicRequestFulfilledEvent(eventLog types.Log,
 		TxDate:        blockTime,
 		Address:       userAddr,
 		EventBlob:     eventBlob,
-		Amount:        c.convertToDecimalAmount(eventData.WantAmountReceived, 8), // Use want amount received as the withdrawal amount
+		Amount:        withdrawalAmount,
 		FromAssetName: c.getAssetName(offerToken),
 		ToAssetName:   c.getAssetName(wantToken),
 	})
@@ -541,6 +565,11 @@ func (c *LombardCrawler) processAtomicRequestUpdatedEvent(eventLog types.Log, bl
 		return fmt.Errorf("failed to marshal event: %w", err)
 	}
 
+	requestAmount, err := c.formatAmount(offerToken, eventData.Amount)
+	if err != nil {
+		return err
+	}
+
 	// Use the user address as the wallet address for this event
 	userAddr := user.Hex()
 
@@ -554,12 +583,122 @@ func (c *LombardCrawler) processAtomicRequestUpdatedEvent(eventLog types.Log, bl
 		TxDate:        blockTime,
 		Address:       userAddr,
 		EventBlob:     eventBlob,
-		Amount:        c.convertToDecimalAmount(eventData.Amount, 8), // Assuming 8 decimals for consistency
+		Amount:        requestAmount,
 		FromAssetName: c.getAssetName(offerToken),
 		ToAssetName:   c.getAssetName(wantToken),
 	})
 }
 
+// processShareTransferEvent records LBTCv moving between a monitored wallet and another holder. Mints and
+// burns are skipped, as are transfers made while fulfilling an atomic request, since deposits and
+// withdrawals already account for those shares. A transfer between two monitored wallets is recorded on
+// both sides, as a transfer_out of the sender and a transfer_in of the receiver. A wallet sending shares
+// to itself holds the same shares afterwards, so self-transfers are skipped.
+func (c *LombardCrawler) processShareTransferEvent(eventLog types.Log) error {
+	var eventData struct {
+		Amount *big.Int
+	}
+
+	if err := c.vaultABI.UnpackIntoInterface(&eventData, "Transfer", eventLog.Data); err != nil {
+		c.logger.Error("Failed to unpack Transfer event data", zap.String("tx_hash", eventLog.TxHash.Hex()), zap.Error(err), zap.Int("data_length", len(eventLog.Data)), zap.String("raw_data", fmt.Sprintf("%x", eventLog.Data)))
+		return err
+	}
+
+	// Topics[1] is from (address)
+	// Topics[2] is to (address)
+	from := common.BytesToAddress(eventLog.Topics[1].Bytes())
+	to := common.BytesToAddress(eventLog.Topics[2].Bytes())
+
+	if from == (common.Address{}) || to == (common.Address{}) {
+		return nil
+	}
+	if from == to {
+		return nil
+	}
+
+	type transferSide struct {
+		eventType string
+		address   common.Address
+	}
+
+	var monitored []transferSide
+	for _, side := range []transferSide{{"transfer_out", from}, {"transfer_in", to}} {
+		isMonitored, err := c.monitoredAddressRepository.IsAddressMonitored(side.address.Hex(), 1) // chain_id = 1 for Ethereum mainnet
+		if err != nil {
+			c.logger.Error("Failed to check if address is monitored", zap.String("address", side.address.Hex()), zap.Error(err))
+			return err
+		}
+		if isMonitored {
+			monitored = append(monitored, side)
+		}
+	}
+
+	if len(monitored) == 0 {
+		return nil // Skip processing this event silently
+	}
+
+	receipt, err := c.client.TransactionReceipt(context.Background(), eventLog.TxHash)
+	if err != nil {
+		return fmt.Errorf("failed to get transaction receipt: %w", err)
+	}
+
+	if receipt.Status == 0 {
+		return nil // Skip failed transactions
+	}
+
+	for _, receiptLog := range receipt.Logs {
+		if receiptLog.Address == c.atomicRequestAddress && len(receiptLog.Topics) > 0 && receiptLog.Topics[0] == AtomicRequestFulfilledSig {
+			return nil
+		}
+	}
+
+	block, err := c.client.BlockByNumber(context.Background(), big.NewInt(int64(eventLog.BlockNumber)))
+	if err != nil {
+		return fmt.Errorf("failed to get block: %w", err)
+	}
+
+	// Create event blob
+	transferEvent := map[string]interface{}{
+		"from":   from.Hex(),
+		"to":     to.Hex(),
+		"amount": eventData.Amount.String(),
+	}
+
+	eventBlob, err := json.Marshal(transferEvent)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	// Transfer events are emitted by the vault for its own shares
+	shareAmount, err := c.formatAmount(c.vaultAddress, eventData.Amount)
+	if err != nil {
+		return err
+	}
+
+	for _, side := range monitored {
+		c.logger.Info("Found share Transfer event", zap.String("tx_hash", eventLog.TxHash.Hex()), zap.String("event_type", side.eventType), zap.String("user_address", side.address.Hex()))
+
+		// Store in outbox
+		if err := c.repository.StoreOutboxEvent(model.OutboxEvent{
+			TxHash:        eventLog.TxHash.Hex(),
+			EventType:     side.eventType,
+			Status:        "unsent",
+			BlockNumber:   eventLog.BlockNumber,
+			LogIndex:      eventLog.Index,
+			TxDate:        time.Unix(int64(block.Time()), 0),
+			Address:       side.address.Hex(),
+			EventBlob:     eventBlob,
+			Amount:        shareAmount,
+			FromAssetName: c.getAssetName(c.vaultAddress),
+			ToAssetName:   c.getAssetName(c.vaultAddress),
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (c *LombardCrawler) Close() error {
 	if c.db != nil {
 		c.db.Close()
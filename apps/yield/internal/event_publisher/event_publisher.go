@@ -25,9 +25,10 @@ func NewEventPublisher(kafkaBroker, kafkaTopic string, logger *zap.Logger, repos
 	// Setup Kafka producer
 	producer, err := kafka.NewProducer(&kafka.ConfigMap{
 		"bootstrap.servers": kafkaBroker,
-		"acks":              "all",
-		"retries":           3,
-		"retry.backoff.ms":  100,
+		"acks":               "all",
+		"retries":            3,
+		"retry.backoff.ms":   100,
+		"enable.idempotence": true, // Retries must not reorder messages within a wallet's partition
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Kafka producer: %w", err)
@@ -63,11 +64,22 @@ func (ep *EventPublisher) publishUnsentEvents() error {
 		return err
 	}
 
-	// Publish each event to Kafka
+	// Publish each event to Kafka. Events arrive in (block_number, log_index) order; once an event for a
+	// wallet fails, later events for that wallet in this batch are held back so they cannot overtake it.
 	successCount := 0
+	blockedWallets := make(map[string]bool)
 	for _, event := range outboxEvents {
+		if blockedWallets[event.Address] {
+			// Return to 'unsent' without publishing; it will be picked up after the failed event
+			if markErr := ep.repository.MarkEventAsFailed(event.TxHash, event.EventType, event.LogIndex); markErr != nil {
+				ep.logger.Error("Failed to release held back event", zap.String("tx_hash", event.TxHash), zap.String("event_type", event.EventType), zap.Uint("log_index", event.LogIndex), zap.Error(markErr))
+			}
+			continue
+		}
+
 		if err := ep.publishEventToKafka(event); err != nil {
 			ep.logger.Error("Failed to publish event to Kafka", zap.String("tx_hash", event.TxHash), zap.String("event_type", event.EventType), zap.Error(err))
+			blockedWallets[event.Address] = true
 			// Mark as failed (returns status to 'unsent' for retry)
 			if markErr := ep.repository.MarkEventAsFailed(event.TxHash, event.EventType, event.LogIndex); markErr != nil {
 				ep.logger.Error("Failed to mark event as failed", zap.String("tx_hash", event.TxHash), zap.String("event_type", event.EventType), zap.Uint("log_index", event.LogIndex), zap.Error(markErr))
@@ -78,7 +90,8 @@ func (ep *EventPublisher) publishUnsentEvents() error {
 		// Mark as sent
 		if err := ep.repository.MarkEventAsSent(event.TxHash, event.EventType, event.LogIndex); err != nil {
 			ep.logger.Error("Failed to mark event as sent", zap.String("tx_hash", event.TxHash), zap.String("event_type", event.EventType), zap.Uint("log_index", event.LogIndex), zap.Error(err))
-			// Note: Event was successfully published but marking failed - this could lead to duplicate sends
+			// The event stays claimed until its lease expires and is then published again, which the
+			// materializer skips as a redelivery
 		} else {
 			successCount++
 		}
@@ -93,19 +106,7 @@ func (ep *EventPublisher) publishUnsentEvents() error {
 
 func (ep *EventPublisher) publishEventToKafka(event model.OutboxEvent) error {
 	// Create Kafka message using the structured type
-	kafkaMsg := events.TransferEvent{
-		EventType:     event.EventType,
-		TxHash:        event.TxHash,
-		BlockNumber:   event.BlockNumber,
-		LogIndex:      uint64(event.LogIndex),
-		TxDate:        event.TxDate,
-		WalletAddress: event.Address,
-		EventData:     event.EventBlob,
-		Amount:        event.Amount,
-		FromAssetName: event.FromAssetName,
-		ToAssetName:   event.ToAssetName,
-		Timestamp:     time.Now(),
-	}
+	kafkaMsg := events.NewTransferEvent(event)
 
 	msgBytes, err := json.Marshal(kafkaMsg)
 	if err != nil {
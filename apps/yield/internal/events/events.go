@@ -3,6 +3,8 @@ package events
 import (
 	"encoding/json"
 	"time"
+
+	"yield/apps/yield/internal/model"
 )
 
 type TransferEvent struct {
@@ -18,3 +20,20 @@ type TransferEvent struct {
 	ToAssetName   string          `json:"to_asset_name"`
 	Timestamp     time.Time       `json:"timestamp"`
 }
+
+// NewTransferEvent builds the Kafka message for a stored outbox event
+func NewTransferEvent(event model.OutboxEvent) TransferEvent {
+	return TransferEvent{
+		EventType:     event.EventType,
+		TxHash:        event.TxHash,
+		BlockNumber:   event.BlockNumber,
+		LogIndex:      uint64(event.LogIndex),
+		TxDate:        event.TxDate,
+		WalletAddress: event.Address,
+		EventData:     event.EventBlob,
+		Amount:        event.Amount,
+		FromAssetName: event.FromAssetName,
+		ToAssetName:   event.ToAssetName,
+		Timestamp:     time.Now(),
+	}
+}
@@ -10,11 +10,12 @@ type Order struct {
 	LogIndex        uint64     `db:"log_index"`
 	BlockNumber     uint64     `db:"block_number"`
 	TxDate          time.Time  `db:"tx_date"`
-	TransferType    string     `db:"transfer_type"` // "deposit" or "withdrawal"
+	TransferType    string     `db:"transfer_type"` // "deposit", "withdrawal", "transfer_in" or "transfer_out"
 	Status          string     `db:"status"`        // "completed" or "in_progress"
 	WalletAddress   string     `db:"wallet_address"`
 	Amount          string     `db:"amount"`
 	FromAssetName   string     `db:"from_asset_name"`
 	ToAssetName     string     `db:"to_asset_name"`
 	EstimatedAmount *string    `db:"estimated_amount"` // nullable field
+	ShareAmount     *string    `db:"share_amount"`     // LBTCv shares the order added to or removed from the wallet
 }
\ No newline at end of file
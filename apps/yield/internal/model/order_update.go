@@ -0,0 +1,19 @@
+package model
+
+import (
+	"time"
+)
+
+// Order update types
+const (
+	OrderCreated = "order_created"
+	OrderUpdated = "order_updated"
+)
+
+type OrderUpdate struct {
+	ID            int64     `db:"id"`
+	WalletAddress string    `db:"wallet_address"`
+	UpdateType    string    `db:"update_type"` // "order_created" or "order_updated"
+	Order         Order     `db:"payload"`
+	CreatedAt     time.Time `db:"created_at"`
+}
@@ -19,3 +19,9 @@ type OutboxEvent struct {
 	ToAssetName   string          `db:"to_asset_name"`
 	CreatedAt     time.Time       `db:"created_at"`
 }
+
+// ActivityEvent is an outbox event with the vault's share price when it happened
+type ActivityEvent struct {
+	OutboxEvent
+	Rate *string `db:"rate"` // Accountant getRate from the last snapshot at or before the event's block, if any
+}
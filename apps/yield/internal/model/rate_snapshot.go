@@ -0,0 +1,15 @@
+package model
+
+import (
+	"time"
+)
+
+// RateSnapshot records the vault's exchange rate and size at a block. APYs are computed from the
+// change in rate between snapshots.
+type RateSnapshot struct {
+	BlockNumber    uint64    `db:"block_number"`
+	BlockTimestamp time.Time `db:"block_timestamp"`
+	Rate           string    `db:"rate"`         // Accountant getRate: one LBTCv in the base asset's smallest units
+	TotalSupply    string    `db:"total_supply"` // LBTCv supply in its smallest units
+	CreatedAt      time.Time `db:"created_at"`
+}
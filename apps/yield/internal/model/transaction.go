@@ -0,0 +1,41 @@
+package model
+
+import (
+	"time"
+)
+
+// Relayed transaction statuses
+const (
+	TransactionPendingOnchain = "pending_onchain"
+	TransactionConfirmed      = "confirmed"
+	TransactionReverted       = "reverted"
+	TransactionReplaced       = "replaced" // Another transaction with the same nonce was mined or relayed
+	TransactionDropped        = "dropped"  // Left the mempool without being mined
+)
+
+// BuiltTransaction is an unsigned transaction returned to a wallet. Relayed transactions must match one.
+type BuiltTransaction struct {
+	ID            string    `db:"id"`
+	WalletAddress string    `db:"wallet_address"`
+	StepType      string    `db:"step_type"` // "approve", "deposit" or "withdrawal"
+	ToAddress     string    `db:"to_address"`
+	Data          string    `db:"data"`
+	ChainID       int64     `db:"chain_id"`
+	CreatedAt     time.Time `db:"created_at"`
+}
+
+type RelayedTransaction struct {
+	TxHash             string    `db:"tx_hash"`
+	BuiltTransactionID string    `db:"built_transaction_id"`
+	WalletAddress      string    `db:"wallet_address"`
+	Nonce              uint64    `db:"nonce"`
+	ToAddress          string    `db:"to_address"`
+	StepType           string    `db:"step_type"`
+	Status             string    `db:"status"`
+	BlockNumber        *uint64   `db:"block_number"`
+	FailureReason      *string   `db:"failure_reason"`
+	ReplacedBy         *string   `db:"replaced_by"`
+	BroadcastAt        time.Time `db:"broadcast_at"`
+	LastSeenAt         time.Time `db:"last_seen_at"` // Last time the node knew of the transaction
+	UpdatedAt          time.Time `db:"updated_at"`
+}
@@ -0,0 +1,48 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Webhook event types, one per transfer event consumed from Kafka
+const (
+	WebhookEventDeposit             = "deposit"
+	WebhookEventWithdrawalRequested = "withdrawal_requested"
+	WebhookEventWithdrawalCompleted = "withdrawal_completed"
+	WebhookEventTransferIn          = "transfer_in"
+	WebhookEventTransferOut         = "transfer_out"
+)
+
+// Webhook delivery statuses
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed" // Gave up after the maximum number of attempts
+)
+
+type WebhookSubscription struct {
+	ID            string    `db:"id"`
+	URL           string    `db:"url"`
+	Secret        string    `db:"secret"`
+	WalletAddress *string   `db:"wallet_address"` // nil subscribes to every wallet
+	EventTypes    []string  `db:"event_types"`    // empty subscribes to every event type
+	CreatedAt     time.Time `db:"created_at"`
+}
+
+type WebhookDelivery struct {
+	ID             string          `db:"id"`
+	SubscriptionID string          `db:"subscription_id"`
+	EventType      string          `db:"event_type"`
+	TxHash         string          `db:"tx_hash"`
+	LogIndex       uint64          `db:"log_index"`
+	Payload        json.RawMessage `db:"payload"`
+	Status         string          `db:"status"`
+	Attempts       int             `db:"attempts"`
+	NextAttemptAt  time.Time       `db:"next_attempt_at"`
+	LastAttemptAt  *time.Time      `db:"last_attempt_at"`
+	ResponseStatus *int            `db:"response_status"`
+	LastError      *string         `db:"last_error"`
+	CreatedAt      time.Time       `db:"created_at"`
+	DeliveredAt    *time.Time      `db:"delivered_at"`
+}
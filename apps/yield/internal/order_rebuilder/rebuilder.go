@@ -0,0 +1,248 @@
+package order_rebuilder
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+	"go.uber.org/zap"
+	"yield/apps/yield/internal/events"
+	"yield/apps/yield/internal/repository"
+	"yield/apps/yield/internal/transfer_materializer"
+)
+
+const (
+	// SourceOutbox replays every published event stored in event_outbox
+	SourceOutbox = "outbox"
+	// SourceKafka replays events from an offset range of the Kafka topic
+	SourceKafka = "kafka"
+
+	// Number of outbox events read per page during replay
+	outboxPageSize = 500
+
+	// How long to wait for the next Kafka message before giving up on a replay
+	kafkaReadTimeout = 10 * time.Second
+)
+
+// Options controls a rebuild of the orders projection
+type Options struct {
+	Source     string // SourceOutbox or SourceKafka
+	FromOffset int64  // First Kafka offset to replay on every partition (inclusive)
+	ToOffset   int64  // Kafka offset to stop at on every partition (exclusive), -1 for the high watermark
+	DryRun     bool   // Build the shadow table but do not swap it in
+}
+
+// OrderRebuilder recomputes the orders projection from source events. Events are materialized into a
+// shadow table using the same TransferMaterializer code as the live pipeline, and the shadow table is
+// swapped in atomically once the replay has completed.
+type OrderRebuilder struct {
+	db                   *sql.DB
+	logger               *zap.Logger
+	crawlerRepository    *repository.CrawlerRepository
+	projectionRepository *repository.OrderProjectionRepository
+	kafkaBroker          string
+	kafkaTopic           string
+}
+
+// NewOrderRebuilder creates a new OrderRebuilder
+func NewOrderRebuilder(db *sql.DB, kafkaBroker, kafkaTopic string, logger *zap.Logger, crawlerRepository *repository.CrawlerRepository, projectionRepository *repository.OrderProjectionRepository) *OrderRebuilder {
+	return &OrderRebuilder{
+		db:                   db,
+		logger:               logger,
+		crawlerRepository:    crawlerRepository,
+		projectionRepository: projectionRepository,
+		kafkaBroker:          kafkaBroker,
+		kafkaTopic:           kafkaTopic,
+	}
+}
+
+// Rebuild replays events into a fresh shadow table and swaps it in place of the live orders table
+func (r *OrderRebuilder) Rebuild(opts Options) error {
+	if opts.Source != SourceOutbox && opts.Source != SourceKafka {
+		return fmt.Errorf("unsupported replay source: %s", opts.Source)
+	}
+
+	if err := r.projectionRepository.CreateShadowTable(); err != nil {
+		return r.abort(err)
+	}
+
+	shadowRepository := repository.NewOrderRepositoryForTable(r.db, r.logger, repository.OrdersShadowTable)
+	materializer := transfer_materializer.NewReplayMaterializer(r.logger, shadowRepository)
+
+	var replayed int
+	var err error
+	switch opts.Source {
+	case SourceOutbox:
+		replayed, err = r.replayFromOutbox(materializer)
+	case SourceKafka:
+		replayed, err = r.replayFromKafka(materializer, opts.FromOffset, opts.ToOffset)
+	}
+	if err != nil {
+		return r.abort(fmt.Errorf("failed to replay events from %s: %w", opts.Source, err))
+	}
+
+	liveCount, err := r.projectionRepository.CountOrders(repository.OrdersTable)
+	if err != nil {
+		return r.abort(err)
+	}
+	rebuiltCount, err := r.projectionRepository.CountOrders(repository.OrdersShadowTable)
+	if err != nil {
+		return r.abort(err)
+	}
+
+	r.logger.Info("Replayed events into shadow orders table",
+		zap.String("source", opts.Source),
+		zap.Int("events", replayed),
+		zap.Int64("live_orders", liveCount),
+		zap.Int64("rebuilt_orders", rebuiltCount))
+
+	if opts.DryRun {
+		r.logger.Info("Dry run: leaving rebuilt orders in shadow table", zap.String("table", repository.OrdersShadowTable))
+		return nil
+	}
+
+	if err := r.projectionRepository.SwapShadowTable(); err != nil {
+		return r.abort(err)
+	}
+
+	return nil
+}
+
+// abort cleans up the shadow table after a failed rebuild. The live table is never touched before the
+// swap, and the swap itself runs in a transaction, so it is left as it was.
+func (r *OrderRebuilder) abort(err error) error {
+	if dropErr := r.projectionRepository.DropShadowTable(); dropErr != nil {
+		r.logger.Error("Failed to drop shadow orders table", zap.Error(dropErr))
+	}
+	return err
+}
+
+// replayFromOutbox pages through published outbox events in chain order
+func (r *OrderRebuilder) replayFromOutbox(materializer *transfer_materializer.TransferMaterializer) (int, error) {
+	var lastBlock uint64
+	var lastLogIndex uint
+	var lastEventType string
+	replayed := 0
+
+	for {
+		outboxEvents, err := r.crawlerRepository.GetSentEventsAfter(lastBlock, lastLogIndex, lastEventType, outboxPageSize)
+		if err != nil {
+			return replayed, err
+		}
+		if len(outboxEvents) == 0 {
+			return replayed, nil
+		}
+
+		for _, event := range outboxEvents {
+			if err := materializer.ProcessEvent(events.NewTransferEvent(event)); err != nil {
+				return replayed, fmt.Errorf("failed to materialize event %s/%d: %w", event.TxHash, event.LogIndex, err)
+			}
+			replayed++
+			lastBlock = event.BlockNumber
+			lastLogIndex = event.LogIndex
+			lastEventType = event.EventType
+		}
+	}
+}
+
+// replayFromKafka reads the requested offset range from every partition of the topic, then applies the
+// events in chain order. Partitions are keyed by wallet, so events must be re-sorted across partitions
+// before they are applied.
+func (r *OrderRebuilder) replayFromKafka(materializer *transfer_materializer.TransferMaterializer, fromOffset, toOffset int64) (int, error) {
+	consumer, err := kafka.NewConsumer(&kafka.ConfigMap{
+		"bootstrap.servers":  r.kafkaBroker,
+		"group.id":           "order-rebuilder",
+		"enable.auto.commit": false,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to create Kafka consumer: %w", err)
+	}
+	defer consumer.Close()
+
+	metadata, err := consumer.GetMetadata(&r.kafkaTopic, false, int(kafkaReadTimeout.Milliseconds()))
+	if err != nil {
+		return 0, fmt.Errorf("failed to get metadata for topic %s: %w", r.kafkaTopic, err)
+	}
+
+	topicMetadata, exists := metadata.Topics[r.kafkaTopic]
+	if !exists {
+		return 0, fmt.Errorf("topic %s not found", r.kafkaTopic)
+	}
+
+	// Work out the offset range to read on each partition
+	var assignments []kafka.TopicPartition
+	endOffsets := make(map[int32]int64)
+	for _, partition := range topicMetadata.Partitions {
+		low, high, err := consumer.QueryWatermarkOffsets(r.kafkaTopic, partition.ID, int(kafkaReadTimeout.Milliseconds()))
+		if err != nil {
+			return 0, fmt.Errorf("failed to query offsets for partition %d: %w", partition.ID, err)
+		}
+
+		start := fromOffset
+		if start < low {
+			start = low
+		}
+		end := toOffset
+		if end < 0 || end > high {
+			end = high
+		}
+		if start >= end {
+			continue
+		}
+
+		assignments = append(assignments, kafka.TopicPartition{Topic: &r.kafkaTopic, Partition: partition.ID, Offset: kafka.Offset(start)})
+		endOffsets[partition.ID] = end
+	}
+
+	if err := consumer.Assign(assignments); err != nil {
+		return 0, fmt.Errorf("failed to assign partitions: %w", err)
+	}
+
+	var transferEvents []events.TransferEvent
+	seen := make(map[string]bool) // The publisher may deliver an event more than once
+	for len(endOffsets) > 0 {
+		msg, err := consumer.ReadMessage(kafkaReadTimeout)
+		if err != nil {
+			return 0, fmt.Errorf("failed to read message from Kafka: %w", err)
+		}
+
+		partition := msg.TopicPartition.Partition
+		end, pending := endOffsets[partition]
+		if !pending || int64(msg.TopicPartition.Offset) >= end {
+			continue
+		}
+
+		var transferEvent events.TransferEvent
+		if err := json.Unmarshal(msg.Value, &transferEvent); err != nil {
+			return 0, fmt.Errorf("failed to unmarshal transfer event at %d/%d: %w", partition, msg.TopicPartition.Offset, err)
+		}
+		// A transfer between monitored wallets is two events on one log, one per side
+		eventKey := fmt.Sprintf("%s/%d/%s", transferEvent.TxHash, transferEvent.LogIndex, transferEvent.EventType)
+		if !seen[eventKey] {
+			seen[eventKey] = true
+			transferEvents = append(transferEvents, transferEvent)
+		}
+
+		if int64(msg.TopicPartition.Offset)+1 >= end {
+			delete(endOffsets, partition)
+		}
+	}
+
+	sort.SliceStable(transferEvents, func(i, j int) bool {
+		if transferEvents[i].BlockNumber != transferEvents[j].BlockNumber {
+			return transferEvents[i].BlockNumber < transferEvents[j].BlockNumber
+		}
+		return transferEvents[i].LogIndex < transferEvents[j].LogIndex
+	})
+
+	for i, transferEvent := range transferEvents {
+		if err := materializer.ProcessEvent(transferEvent); err != nil {
+			return i, fmt.Errorf("failed to materialize event %s/%d: %w", transferEvent.TxHash, transferEvent.LogIndex, err)
+		}
+	}
+
+	return len(transferEvents), nil
+}
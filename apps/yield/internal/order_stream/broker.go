@@ -0,0 +1,108 @@
+package order_stream
+
+import (
+	"sync"
+
+	"go.uber.org/zap"
+	"yield/apps/yield/internal/model"
+	"yield/apps/yield/internal/repository"
+)
+
+// Number of updates buffered per subscriber before it is considered too slow and disconnected
+const subscriberBufferSize = 64
+
+// Subscription receives the live order updates of a single wallet. The Updates channel is closed when
+// the subscriber falls behind; the client is expected to reconnect and resume from the update log.
+type Subscription struct {
+	WalletAddress string
+	Updates       chan model.OrderUpdate
+}
+
+// Broker fans out order updates produced by the transfer materializer to streaming clients. Every
+// update is first appended to the order update log so that clients can resume after a disconnect.
+type Broker struct {
+	repository  *repository.OrderUpdateRepository
+	logger      *zap.Logger
+	mu          sync.Mutex
+	subscribers map[string]map[*Subscription]struct{} // keyed by wallet address
+}
+
+// NewBroker creates a new Broker
+func NewBroker(repository *repository.OrderUpdateRepository, logger *zap.Logger) *Broker {
+	return &Broker{
+		repository:  repository,
+		logger:      logger,
+		subscribers: make(map[string]map[*Subscription]struct{}),
+	}
+}
+
+// Publish records an order change and delivers it to the wallet's subscribers
+func (b *Broker) Publish(order model.Order, updateType string) error {
+	update, err := b.repository.AppendOrderUpdate(order, updateType)
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for subscription := range b.subscribers[order.WalletAddress] {
+		select {
+		case subscription.Updates <- *update:
+		default:
+			// Never block the materializer on a slow client
+			b.logger.Warn("Dropping slow order stream subscriber", zap.String("wallet_address", order.WalletAddress))
+			b.removeLocked(subscription)
+		}
+	}
+
+	return nil
+}
+
+// Subscribe registers a subscriber for the live updates of a wallet
+func (b *Broker) Subscribe(walletAddress string) *Subscription {
+	subscription := &Subscription{
+		WalletAddress: walletAddress,
+		Updates:       make(chan model.OrderUpdate, subscriberBufferSize),
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.subscribers[walletAddress] == nil {
+		b.subscribers[walletAddress] = make(map[*Subscription]struct{})
+	}
+	b.subscribers[walletAddress][subscription] = struct{}{}
+
+	return subscription
+}
+
+// Unsubscribe removes a subscriber. It is safe to call more than once.
+func (b *Broker) Unsubscribe(subscription *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.removeLocked(subscription)
+}
+
+// Replay returns the wallet's updates after the given update ID from the update log
+func (b *Broker) Replay(walletAddress string, afterID int64, limit int) ([]model.OrderUpdate, error) {
+	return b.repository.GetOrderUpdatesAfter(walletAddress, afterID, limit)
+}
+
+func (b *Broker) removeLocked(subscription *Subscription) {
+	walletSubscribers, exists := b.subscribers[subscription.WalletAddress]
+	if !exists {
+		return
+	}
+	if _, subscribed := walletSubscribers[subscription]; !subscribed {
+		return
+	}
+
+	delete(walletSubscribers, subscription)
+	close(subscription.Updates)
+
+	if len(walletSubscribers) == 0 {
+		delete(b.subscribers, subscription.WalletAddress)
+	}
+}
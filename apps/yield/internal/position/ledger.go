@@ -0,0 +1,89 @@
+package position
+
+import (
+	"math/big"
+)
+
+// Lot is the part of a wallet's LBTCv bought with one asset. Amounts are exact decimals in whole
+// tokens; cost basis and realized yield are in the lot's asset.
+type Lot struct {
+	Asset         string
+	Shares        *big.Rat
+	CostBasis     *big.Rat
+	RealizedYield *big.Rat
+}
+
+// Ledger tracks a wallet's shares and their cost basis with average-cost accounting: shares leaving
+// the wallet take a pro rata part of every lot's shares and cost basis with them.
+type Ledger struct {
+	lots   map[string]*Lot
+	assets []string // In the order the lots were opened
+}
+
+// NewLedger creates an empty ledger
+func NewLedger() *Ledger {
+	return &Ledger{lots: make(map[string]*Lot)}
+}
+
+// Add records shares acquired for cost, in units of asset
+func (l *Ledger) Add(asset string, shares, cost *big.Rat) {
+	lot, exists := l.lots[asset]
+	if !exists {
+		lot = &Lot{Asset: asset, Shares: new(big.Rat), CostBasis: new(big.Rat), RealizedYield: new(big.Rat)}
+		l.lots[asset] = lot
+		l.assets = append(l.assets, asset)
+	}
+
+	lot.Shares.Add(lot.Shares, shares)
+	lot.CostBasis.Add(lot.CostBasis, cost)
+}
+
+// Remove records shares leaving the wallet. For a withdrawal, proceeds is what it paid out and is
+// split across lots in proportion to the shares taken from each, realizing the difference with the
+// cost basis removed. Transfers out pass nil and realize nothing. Removing more shares than the ledger
+// holds empties it; the proceeds of the excess are not attributed.
+func (l *Ledger) Remove(shares, proceeds *big.Rat) {
+	held := l.Shares()
+	if held.Sign() == 0 || shares.Sign() <= 0 {
+		return
+	}
+
+	removed := shares
+	if removed.Cmp(held) > 0 {
+		removed = held
+	}
+
+	// Every lot gives up the same fraction of its shares and cost basis
+	fraction := new(big.Rat).Quo(removed, held)
+	for _, asset := range l.assets {
+		lot := l.lots[asset]
+		lotShares := new(big.Rat).Mul(lot.Shares, fraction)
+		lotCost := new(big.Rat).Mul(lot.CostBasis, fraction)
+
+		if proceeds != nil {
+			lotProceeds := new(big.Rat).Quo(new(big.Rat).Mul(proceeds, lotShares), shares)
+			lot.RealizedYield.Add(lot.RealizedYield, lotProceeds.Sub(lotProceeds, lotCost))
+		}
+
+		lot.Shares.Sub(lot.Shares, lotShares)
+		lot.CostBasis.Sub(lot.CostBasis, lotCost)
+	}
+}
+
+// Shares returns the shares held across all lots
+func (l *Ledger) Shares() *big.Rat {
+	total := new(big.Rat)
+	for _, lot := range l.lots {
+		total.Add(total, lot.Shares)
+	}
+	return total
+}
+
+// Lots returns every lot ever opened, including emptied ones that realized yield, in opening order
+func (l *Ledger) Lots() []*Lot {
+	lots := make([]*Lot, len(l.assets))
+	for i, asset := range l.assets {
+		lots[i] = l.lots[asset]
+	}
+	return lots
+}
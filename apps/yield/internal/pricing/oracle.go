@@ -0,0 +1,268 @@
+package pricing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"yield/apps/yield/internal/assets"
+)
+
+// AggregatorV3ABI covers the Chainlink aggregator reads used for pricing
+const AggregatorV3ABI = `[
+	{"inputs": [], "name": "decimals", "outputs": [{"internalType": "uint8", "name": "", "type": "uint8"}], "stateMutability": "view", "type": "function"},
+	{"inputs": [], "name": "latestRoundData", "outputs": [
+		{"internalType": "uint80", "name": "roundId", "type": "uint80"},
+		{"internalType": "int256", "name": "answer", "type": "int256"},
+		{"internalType": "uint256", "name": "startedAt", "type": "uint256"},
+		{"internalType": "uint256", "name": "updatedAt", "type": "uint256"},
+		{"internalType": "uint80", "name": "answeredInRound", "type": "uint80"}
+	], "stateMutability": "view", "type": "function"}
+]`
+
+var (
+	// ErrNoPriceFeeds is returned on chains the asset registry has no price feeds for
+	ErrNoPriceFeeds = errors.New("no price feeds configured for this chain")
+
+	// ErrStalePrice is returned when a feed's latest answer is older than the feed's MaxAge, or was
+	// carried over from an earlier round
+	ErrStalePrice = errors.New("price feed answer is stale")
+
+	// ErrInvalidPrice is returned when a feed answers with a non-positive price or an unfinished round
+	ErrInvalidPrice = errors.New("price feed answer is invalid")
+)
+
+// ChainReader is the part of an Ethereum client the oracle reads through. Both ethclient.Client and the
+// simulated backend's client implement it.
+type ChainReader interface {
+	ChainID(ctx context.Context) (*big.Int, error)
+	CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+}
+
+// Price is a validated aggregator answer
+type Price struct {
+	Feed      common.Address
+	Value     *big.Rat
+	RoundID   *big.Int
+	UpdatedAt time.Time
+}
+
+// Quote holds the prices read at one block, so that several amounts are valued consistently
+type Quote struct {
+	BTCPriceUSD *Price
+	pegs        map[string]*Price // Asset price in BTC, by symbol
+	pegErrors   map[string]error  // Peg feeds that could not be read or failed validation
+}
+
+// Value values an amount of an asset, in whole tokens, in BTC and USD. Assets without a peg feed are
+// valued at par with BTC; an asset whose peg feed failed cannot be valued.
+func (q *Quote) Value(symbol string, amount *big.Rat) (btc, usd *big.Rat, err error) {
+	if err := q.pegErrors[symbol]; err != nil {
+		return nil, nil, err
+	}
+
+	btc = new(big.Rat).Set(amount)
+	if peg, exists := q.pegs[symbol]; exists {
+		btc.Mul(btc, peg.Value)
+	}
+
+	return btc, new(big.Rat).Mul(btc, q.BTCPriceUSD.Value), nil
+}
+
+// Peg returns the price of an asset in BTC, or nil when it is valued at par
+func (q *Quote) Peg(symbol string) *Price {
+	return q.pegs[symbol]
+}
+
+// OldestUpdate returns when the oldest of the quote's prices was updated
+func (q *Quote) OldestUpdate() time.Time {
+	oldest := q.BTCPriceUSD.UpdatedAt
+	for _, peg := range q.pegs {
+		if peg.UpdatedAt.Before(oldest) {
+			oldest = peg.UpdatedAt
+		}
+	}
+	return oldest
+}
+
+// Oracle reads the Chainlink price feeds the asset registry configures for the client's chain
+type Oracle struct {
+	client        ChainReader
+	registry      *assets.AssetRegistry
+	aggregatorABI abi.ABI
+	mu            sync.Mutex
+	feeds         *assets.PriceFeeds          // Resolved on first use
+	decimals      map[common.Address]*big.Int // 10^decimals of each feed, which never change
+}
+
+// NewOracle creates a new Oracle. The chain ID is looked up on first use.
+func NewOracle(client ChainReader, registry *assets.AssetRegistry) (*Oracle, error) {
+	aggregatorABI, err := abi.JSON(strings.NewReader(AggregatorV3ABI))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse aggregator ABI: %w", err)
+	}
+
+	return &Oracle{
+		client:        client,
+		registry:      registry,
+		aggregatorABI: aggregatorABI,
+		decimals:      make(map[common.Address]*big.Int),
+	}, nil
+}
+
+// Quote reads the BTC/USD price and every peg feed at the block, or the latest block when block is nil.
+// A failing BTC/USD feed fails the quote; a failing peg feed only fails valuations of its asset.
+func (o *Oracle) Quote(ctx context.Context, block *big.Int) (*Quote, error) {
+	feeds, err := o.priceFeeds(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Staleness is measured against the block, so historical reads are judged by the prices of their time
+	header, err := o.client.HeaderByNumber(ctx, block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get block header: %w", err)
+	}
+	now := time.Unix(int64(header.Time), 0)
+	block = header.Number
+
+	btcPrice, err := o.ReadPrice(ctx, feeds.BTCUSD, block, now)
+	if err != nil {
+		return nil, fmt.Errorf("BTC/USD: %w", err)
+	}
+
+	quote := &Quote{
+		BTCPriceUSD: btcPrice,
+		pegs:        make(map[string]*Price),
+		pegErrors:   make(map[string]error),
+	}
+	for symbol, feed := range feeds.BTCPegs {
+		peg, err := o.ReadPrice(ctx, feed, block, now)
+		if err != nil {
+			quote.pegErrors[symbol] = fmt.Errorf("%s/BTC: %w", symbol, err)
+			continue
+		}
+		quote.pegs[symbol] = peg
+	}
+
+	return quote, nil
+}
+
+// ReadPrice reads a feed's latest answer at the block and validates it as of now, the block's time
+func (o *Oracle) ReadPrice(ctx context.Context, feed assets.PriceFeed, block *big.Int, now time.Time) (*Price, error) {
+	scale, err := o.scale(ctx, feed.Address, block)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := o.call(ctx, feed.Address, "latestRoundData", block)
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := o.aggregatorABI.Unpack("latestRoundData", result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpack latestRoundData result: %w", err)
+	}
+	roundID, answer := values[0].(*big.Int), values[1].(*big.Int)
+	updatedAt, answeredInRound := values[3].(*big.Int), values[4].(*big.Int)
+
+	if answer.Sign() <= 0 {
+		return nil, fmt.Errorf("%w: answer %s", ErrInvalidPrice, answer)
+	}
+	if updatedAt.Sign() == 0 || !updatedAt.IsInt64() {
+		return nil, fmt.Errorf("%w: round %s is not complete", ErrInvalidPrice, roundID)
+	}
+	if answeredInRound.Cmp(roundID) < 0 {
+		return nil, fmt.Errorf("%w: round %s was answered in round %s", ErrStalePrice, roundID, answeredInRound)
+	}
+
+	updated := time.Unix(updatedAt.Int64(), 0)
+	if age := now.Sub(updated); age > feed.MaxAge {
+		return nil, fmt.Errorf("%w: updated %s ago, at most %s allowed", ErrStalePrice, age, feed.MaxAge)
+	}
+
+	return &Price{
+		Feed:      feed.Address,
+		Value:     new(big.Rat).SetFrac(answer, scale),
+		RoundID:   roundID,
+		UpdatedAt: updated.UTC(),
+	}, nil
+}
+
+// priceFeeds returns the feeds configured for the client's chain
+func (o *Oracle) priceFeeds(ctx context.Context) (*assets.PriceFeeds, error) {
+	o.mu.Lock()
+	feeds := o.feeds
+	o.mu.Unlock()
+	if feeds != nil {
+		return feeds, nil
+	}
+
+	chainID, err := o.client.ChainID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chain ID: %w", err)
+	}
+
+	feeds, exists := o.registry.GetPriceFeeds(chainID.Uint64())
+	if !exists {
+		return nil, fmt.Errorf("%w: chain %s", ErrNoPriceFeeds, chainID)
+	}
+
+	o.mu.Lock()
+	o.feeds = feeds
+	o.mu.Unlock()
+	return feeds, nil
+}
+
+// scale returns 10^decimals of a feed's answers
+func (o *Oracle) scale(ctx context.Context, feed common.Address, block *big.Int) (*big.Int, error) {
+	o.mu.Lock()
+	scale, cached := o.decimals[feed]
+	o.mu.Unlock()
+	if cached {
+		return scale, nil
+	}
+
+	result, err := o.call(ctx, feed, "decimals", block)
+	if err != nil {
+		return nil, err
+	}
+
+	var decimals uint8
+	if err := o.aggregatorABI.UnpackIntoInterface(&decimals, "decimals", result); err != nil {
+		return nil, fmt.Errorf("failed to unpack decimals result: %w", err)
+	}
+
+	scale = new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	o.mu.Lock()
+	o.decimals[feed] = scale
+	o.mu.Unlock()
+	return scale, nil
+}
+
+func (o *Oracle) call(ctx context.Context, feed common.Address, method string, block *big.Int) ([]byte, error) {
+	data, err := o.aggregatorABI.Pack(method)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack %s call: %w", method, err)
+	}
+
+	result, err := o.client.CallContract(ctx, ethereum.CallMsg{
+		To:   &feed,
+		Data: data,
+	}, block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call %s on feed %s: %w", method, feed.Hex(), err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,177 @@
+package rate_snapshotter
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"go.uber.org/zap"
+	"yield/apps/yield/internal/assets"
+	"yield/apps/yield/internal/model"
+	"yield/apps/yield/internal/repository"
+)
+
+const (
+	// The accountant rate changes when the vault is updated, usually daily, so hourly snapshots are
+	// enough to follow it
+	pollInterval = time.Hour
+
+	// An empty series is backfilled with one snapshot per day over the longest APY window, so that APYs
+	// are available right away. This needs an archive node.
+	BackfillDays = 90
+	blocksPerDay = 24 * 60 * 60 / 12
+)
+
+// snapshotABI covers the accountant rate and the vault supply
+const snapshotABI = `[
+	{"type": "function", "name": "getRate", "stateMutability": "view",
+		"inputs": [],
+		"outputs": [{"name": "", "type": "uint256"}]},
+	{"type": "function", "name": "totalSupply", "stateMutability": "view",
+		"inputs": [],
+		"outputs": [{"name": "", "type": "uint256"}]}
+]`
+
+// Snapshotter periodically records the accountant rate and vault supply
+type Snapshotter struct {
+	logger                 *zap.Logger
+	ethClient              *ethclient.Client
+	contractABI            abi.ABI
+	rateSnapshotRepository *repository.RateSnapshotRepository
+}
+
+func NewSnapshotter(rpcURL string, logger *zap.Logger, rateSnapshotRepository *repository.RateSnapshotRepository) (*Snapshotter, error) {
+	ethClient, err := ethclient.Dial(rpcURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Ethereum client: %w", err)
+	}
+
+	contractABI, err := abi.JSON(strings.NewReader(snapshotABI))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot ABI: %w", err)
+	}
+
+	return &Snapshotter{
+		logger:                 logger,
+		ethClient:              ethClient,
+		contractABI:            contractABI,
+		rateSnapshotRepository: rateSnapshotRepository,
+	}, nil
+}
+
+func (s *Snapshotter) Start() {
+	s.logger.Info("Starting Rate Snapshotter...")
+
+	if err := s.backfill(); err != nil {
+		s.logger.Error("Error backfilling rate snapshots", zap.Error(err))
+	}
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	if err := s.snapshotLatest(); err != nil {
+		s.logger.Error("Error recording rate snapshot", zap.Error(err))
+	}
+
+	for range ticker.C {
+		if err := s.snapshotLatest(); err != nil {
+			s.logger.Error("Error recording rate snapshot", zap.Error(err))
+		}
+	}
+}
+
+// backfill records daily snapshots over the last BackfillDays when no snapshot has been recorded yet.
+// Days before the vault existed, or that the node cannot serve, are skipped.
+func (s *Snapshotter) backfill() error {
+	latest, err := s.rateSnapshotRepository.GetLatestRateSnapshot()
+	if err != nil {
+		return err
+	}
+	if latest != nil {
+		return nil
+	}
+
+	head, err := s.ethClient.BlockNumber(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to get block number from blockchain: %w", err)
+	}
+
+	recorded := 0
+	for day := BackfillDays; day > 0; day-- {
+		offset := uint64(day * blocksPerDay)
+		if offset >= head {
+			continue
+		}
+
+		if err := s.snapshot(head - offset); err != nil {
+			s.logger.Debug("Skipping rate snapshot backfill", zap.Int("days_ago", day), zap.Error(err))
+			continue
+		}
+		recorded++
+	}
+
+	s.logger.Info("Backfilled rate snapshots", zap.Int("snapshots", recorded))
+	return nil
+}
+
+func (s *Snapshotter) snapshotLatest() error {
+	head, err := s.ethClient.BlockNumber(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to get block number from blockchain: %w", err)
+	}
+
+	return s.snapshot(head)
+}
+
+// snapshot reads the rate and supply at the block and records them with the block's timestamp
+func (s *Snapshotter) snapshot(blockNumber uint64) error {
+	ctx := context.Background()
+	block := new(big.Int).SetUint64(blockNumber)
+
+	header, err := s.ethClient.HeaderByNumber(ctx, block)
+	if err != nil {
+		return fmt.Errorf("failed to get block header: %w", err)
+	}
+
+	rate, err := s.callUint256(ctx, common.HexToAddress(assets.AccountantContractAddress), "getRate", block)
+	if err != nil {
+		return err
+	}
+
+	totalSupply, err := s.callUint256(ctx, assets.LBTCVAddress, "totalSupply", block)
+	if err != nil {
+		return err
+	}
+
+	return s.rateSnapshotRepository.RecordRateSnapshot(model.RateSnapshot{
+		BlockNumber:    blockNumber,
+		BlockTimestamp: time.Unix(int64(header.Time), 0),
+		Rate:           rate.String(),
+		TotalSupply:    totalSupply.String(),
+	})
+}
+
+func (s *Snapshotter) callUint256(ctx context.Context, contract common.Address, method string, block *big.Int) (*big.Int, error) {
+	data, err := s.contractABI.Pack(method)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack %s call: %w", method, err)
+	}
+
+	result, err := s.ethClient.CallContract(ctx, ethereum.CallMsg{To: &contract, Data: data}, block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call %s: %w", method, err)
+	}
+
+	var value *big.Int
+	if err := s.contractABI.UnpackIntoInterface(&value, method, result); err != nil {
+		return nil, fmt.Errorf("failed to unpack %s result: %w", method, err)
+	}
+
+	return value, nil
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"yield/apps/yield/internal/model"
+)
+
+// ActivityRepository reads a wallet's on-chain activity from the event outbox. Unlike the orders
+// projection, which merges a withdrawal request with its fulfilment, it keeps one row per event.
+type ActivityRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewActivityRepository(db *sql.DB, logger *zap.Logger) *ActivityRepository {
+	return &ActivityRepository{db: db, logger: logger}
+}
+
+// ActivityFilter selects and pages through the events of a wallet in chain order
+type ActivityFilter struct {
+	WalletAddress  string
+	From           *time.Time // Optional: inclusive lower bound on tx_date
+	To             *time.Time // Optional: exclusive upper bound on tx_date
+	AfterBlock     uint64     // Position of the last event of the previous page
+	AfterLogIndex  uint
+	AfterEventType string // Events on the same log are told apart by type
+	Limit          int
+}
+
+// ListWalletActivity returns a page of the wallet's events strictly after the filter's position, each
+// with the rate of the last snapshot at or before its block
+func (r *ActivityRepository) ListWalletActivity(filter ActivityFilter) ([]model.ActivityEvent, error) {
+	conditions := []string{"e.wallet_address = $1", "(e.block_number, e.log_index, e.event_type) > ($2, $3, $4)"}
+	args := []interface{}{filter.WalletAddress, filter.AfterBlock, filter.AfterLogIndex, filter.AfterEventType}
+
+	if filter.From != nil {
+		args = append(args, *filter.From)
+		conditions = append(conditions, fmt.Sprintf("e.tx_date >= $%d", len(args)))
+	}
+	if filter.To != nil {
+		args = append(args, *filter.To)
+		conditions = append(conditions, fmt.Sprintf("e.tx_date < $%d", len(args)))
+	}
+
+	args = append(args, filter.Limit)
+	rows, err := r.db.Query(fmt.Sprintf(`
+		SELECT e.tx_hash, e.event_type, e.status, e.block_number, e.log_index, e.tx_date, e.wallet_address, e.event_blob, e.amount, e.from_asset_name, e.to_asset_name, e.created_at, s.rate
+		FROM event_outbox e
+		LEFT JOIN LATERAL (
+			SELECT rate FROM rate_snapshots
+			WHERE block_number <= e.block_number
+			ORDER BY block_number DESC
+			LIMIT 1
+		) s ON true
+		WHERE %s
+		ORDER BY e.block_number, e.log_index, e.event_type
+		LIMIT $%d
+	`, strings.Join(conditions, " AND "), len(args)), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list wallet activity: %w", err)
+	}
+	defer rows.Close()
+
+	var events []model.ActivityEvent
+	for rows.Next() {
+		var event model.ActivityEvent
+		if err := rows.Scan(&event.TxHash, &event.EventType, &event.Status,
+			&event.BlockNumber, &event.LogIndex, &event.TxDate, &event.Address, &event.EventBlob, &event.Amount, &event.FromAssetName, &event.ToAssetName, &event.CreatedAt, &event.Rate); err != nil {
+			return nil, fmt.Errorf("failed to scan wallet activity: %w", err)
+		}
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating wallet activity: %w", err)
+	}
+
+	return events, nil
+}
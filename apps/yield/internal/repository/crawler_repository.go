@@ -3,6 +3,8 @@ package repository
 import (
 	"database/sql"
 	"fmt"
+	"time"
+
 	"go.uber.org/zap"
 	"yield/apps/yield/internal/model"
 )
@@ -37,7 +39,7 @@ func (c *CrawlerRepository) StoreOutboxEvent(event model.OutboxEvent) error {
 	_, err := c.db.Exec(`
 		INSERT INTO event_outbox (tx_hash, event_type, status, block_number, log_index, tx_date, wallet_address, event_blob, amount, from_asset_name, to_asset_name)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
-		ON CONFLICT (tx_hash, log_index) DO UPDATE SET
+		ON CONFLICT (tx_hash, log_index, event_type) DO UPDATE SET
 			status = EXCLUDED.status,
 			block_number = EXCLUDED.block_number,
 			tx_date = EXCLUDED.tx_date,
@@ -57,6 +59,19 @@ func (c *CrawlerRepository) StoreOutboxEvent(event model.OutboxEvent) error {
 	return nil
 }
 
+// publisherLockKey is the advisory lock key that serializes event selection across publisher instances
+const publisherLockKey = 727001
+
+// ProcessingLease is how long a publisher may hold claimed events. Events still 'processing' after it,
+// because the publisher crashed or failed to mark them, are released for another attempt. A released
+// event that had reached Kafka is published twice, which the materializer skips as a redelivery.
+const ProcessingLease = 10 * time.Minute
+
+// GetUnsentEventsForProcessing claims the next batch of unsent events in chain order. An event is only
+// claimed when no earlier event for the same wallet is still being published, so that events for a
+// wallet reach Kafka in (block_number, log_index) order even when an earlier one is being retried.
+// Claims older than ProcessingLease are released first, so that an abandoned claim cannot hold back
+// the wallet's later events for good.
 func (c *CrawlerRepository) GetUnsentEventsForProcessing(limit int) ([]model.OutboxEvent, error) {
 	// Use a transaction to ensure atomicity
 	tx, err := c.db.Begin()
@@ -65,14 +80,39 @@ func (c *CrawlerRepository) GetUnsentEventsForProcessing(limit int) ([]model.Out
 	}
 	defer tx.Rollback() // Will be ignored if tx.Commit() succeeds
 
-	// Select and lock unsent events for processing
+	// Serialize selection so that two publishers cannot claim different events of the same wallet
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock($1)`, publisherLockKey); err != nil {
+		return nil, err
+	}
+
+	// Claims made before claimed_at was recorded have no timestamp and are released too
+	released, err := tx.Exec(`
+		UPDATE event_outbox
+		SET status = 'unsent', claimed_at = NULL
+		WHERE status = 'processing' AND (claimed_at IS NULL OR claimed_at < NOW() - $1 * INTERVAL '1 second')
+	`, int64(ProcessingLease/time.Second))
+	if err != nil {
+		return nil, err
+	}
+	if count, err := released.RowsAffected(); err == nil && count > 0 {
+		c.logger.Warn("Released expired outbox claims", zap.Int64("count", count))
+	}
+
+	// Select and lock unsent events for processing, holding back events that have an earlier
+	// event for the same wallet in flight
 	rows, err := tx.Query(`
 		SELECT tx_hash, event_type, status, block_number, log_index, tx_date, wallet_address, event_blob, amount, from_asset_name, to_asset_name, created_at
-		FROM event_outbox 
-		WHERE status = 'unsent' 
-		ORDER BY created_at, log_index
+		FROM event_outbox e
+		WHERE e.status = 'unsent'
+		AND NOT EXISTS (
+			SELECT 1 FROM event_outbox earlier
+			WHERE earlier.wallet_address = e.wallet_address
+			AND earlier.status = 'processing'
+			AND (earlier.block_number, earlier.log_index) < (e.block_number, e.log_index)
+		)
+		ORDER BY e.block_number, e.log_index, e.event_type
 		LIMIT $1
-		FOR UPDATE SKIP LOCKED
+		FOR UPDATE
 	`, limit)
 	if err != nil {
 		return nil, err
@@ -97,7 +137,7 @@ func (c *CrawlerRepository) GetUnsentEventsForProcessing(limit int) ([]model.Out
 	for _, key := range eventKeys {
 		_, err = tx.Exec(`
 			UPDATE event_outbox 
-			SET status = 'processing' 
+			SET status = 'processing', claimed_at = NOW()
 			WHERE tx_hash = $1 AND event_type = $2 AND log_index = $3 AND status = 'unsent'
 		`, key.txHash, key.eventType, key.logIndex)
 		if err != nil {
@@ -116,7 +156,7 @@ func (c *CrawlerRepository) GetUnsentEventsForProcessing(limit int) ([]model.Out
 func (c *CrawlerRepository) MarkEventAsSent(txHash, eventType string, logIndex uint) error {
 	_, err := c.db.Exec(`
 		UPDATE event_outbox 
-		SET status = 'sent'
+		SET status = 'sent', claimed_at = NULL
 		WHERE tx_hash = $1 AND event_type = $2 AND log_index = $3
 	`, txHash, eventType, logIndex)
 	return err
@@ -125,8 +165,41 @@ func (c *CrawlerRepository) MarkEventAsSent(txHash, eventType string, logIndex u
 func (c *CrawlerRepository) MarkEventAsFailed(txHash, eventType string, logIndex uint) error {
 	_, err := c.db.Exec(`
 		UPDATE event_outbox 
-		SET status = 'unsent'
+		SET status = 'unsent', claimed_at = NULL
 		WHERE tx_hash = $1 AND event_type = $2 AND log_index = $3 AND status = 'processing'
 	`, txHash, eventType, logIndex)
 	return err
 }
+
+// GetSentEventsAfter returns published outbox events strictly after the given (block_number, log_index,
+// event_type) position, in chain order. It is used to page through the outbox when replaying the orders
+// projection. The event type breaks ties between the two sides of a transfer, which share a log.
+func (c *CrawlerRepository) GetSentEventsAfter(blockNumber uint64, logIndex uint, eventType string, limit int) ([]model.OutboxEvent, error) {
+	rows, err := c.db.Query(`
+		SELECT tx_hash, event_type, status, block_number, log_index, tx_date, wallet_address, event_blob, amount, from_asset_name, to_asset_name, created_at
+		FROM event_outbox
+		WHERE status = 'sent' AND (block_number, log_index, event_type) > ($1, $2, $3)
+		ORDER BY block_number, log_index, event_type
+		LIMIT $4
+	`, blockNumber, logIndex, eventType, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sent events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []model.OutboxEvent
+	for rows.Next() {
+		var event model.OutboxEvent
+		if err := rows.Scan(&event.TxHash, &event.EventType, &event.Status,
+			&event.BlockNumber, &event.LogIndex, &event.TxDate, &event.Address, &event.EventBlob, &event.Amount, &event.FromAssetName, &event.ToAssetName, &event.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan outbox event: %w", err)
+		}
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating outbox events: %w", err)
+	}
+
+	return events, nil
+}
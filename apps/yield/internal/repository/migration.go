@@ -22,24 +22,17 @@ func InitMigration(db *sql.DB) error {
 			from_asset_name VARCHAR(50) NOT NULL,
 			to_asset_name VARCHAR(50) NOT NULL,
 			created_at TIMESTAMP NOT NULL DEFAULT NOW(),
-			PRIMARY KEY (tx_hash, log_index)
+			claimed_at TIMESTAMP,
+			CONSTRAINT event_outbox_event_key PRIMARY KEY (tx_hash, log_index, event_type)
 		)`,
-		`CREATE TABLE IF NOT EXISTS orders (
-			order_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
-			tx_hash VARCHAR(66) NOT NULL,
-			log_index INTEGER NOT NULL,
-			block_number BIGINT NOT NULL,
-			tx_date TIMESTAMP NOT NULL,
-			transfer_type VARCHAR(20) NOT NULL,
-			status VARCHAR(20) NOT NULL,
-			wallet_address VARCHAR(42) NOT NULL,
-			amount DECIMAL(78,18) NOT NULL,
-			from_asset_name VARCHAR(50) NOT NULL,
-			to_asset_name VARCHAR(50) NOT NULL,
-			estimated_amount DECIMAL(78,18),
-			UNIQUE(tx_hash, log_index)
-		)`,
-		`CREATE INDEX IF NOT EXISTS idx_orders_wallet_type_status_date ON orders (wallet_address, transfer_type, status, tx_date DESC)`,
+		// A transfer between two monitored wallets stores one event per side, so events are keyed by type
+		// as well. Tables created before then swap their (tx_hash, log_index) primary key for a unique index.
+		`CREATE UNIQUE INDEX IF NOT EXISTS event_outbox_event_key ON event_outbox (tx_hash, log_index, event_type)`,
+		`ALTER TABLE event_outbox DROP CONSTRAINT IF EXISTS event_outbox_pkey`,
+		// When a publisher claimed the event, so that claims it never finished can be released
+		`ALTER TABLE event_outbox ADD COLUMN IF NOT EXISTS claimed_at TIMESTAMP`,
+		`CREATE INDEX IF NOT EXISTS idx_event_outbox_status_position ON event_outbox (status, block_number, log_index)`,
+		`CREATE INDEX IF NOT EXISTS idx_event_outbox_wallet_position ON event_outbox (wallet_address, block_number, log_index)`,
 		`CREATE TABLE IF NOT EXISTS monitored_addresses (
 			id SERIAL PRIMARY KEY,
 			wallet_address VARCHAR(42) NOT NULL,
@@ -48,6 +41,92 @@ func InitMigration(db *sql.DB) error {
 			UNIQUE(wallet_address, chain_id)
 		)`,
 		`CREATE INDEX IF NOT EXISTS idx_monitored_addresses_wallet ON monitored_addresses (wallet_address)`,
+		`CREATE TABLE IF NOT EXISTS order_updates (
+			id BIGSERIAL PRIMARY KEY,
+			wallet_address VARCHAR(42) NOT NULL,
+			order_id UUID NOT NULL,
+			update_type VARCHAR(20) NOT NULL,
+			payload JSONB NOT NULL,
+			created_at TIMESTAMP NOT NULL DEFAULT NOW()
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_order_updates_wallet_id ON order_updates (wallet_address, id)`,
+		`CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			url TEXT NOT NULL,
+			secret TEXT NOT NULL,
+			wallet_address VARCHAR(42),
+			event_types TEXT[] NOT NULL DEFAULT '{}',
+			created_at TIMESTAMP NOT NULL DEFAULT NOW()
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_webhook_subscriptions_wallet ON webhook_subscriptions (wallet_address)`,
+		`CREATE TABLE IF NOT EXISTS webhook_deliveries (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			subscription_id UUID NOT NULL REFERENCES webhook_subscriptions (id) ON DELETE CASCADE,
+			event_type VARCHAR(20) NOT NULL,
+			tx_hash VARCHAR(66) NOT NULL,
+			log_index INTEGER NOT NULL,
+			payload JSONB NOT NULL,
+			status VARCHAR(20) NOT NULL DEFAULT 'pending',
+			attempts INTEGER NOT NULL DEFAULT 0,
+			next_attempt_at TIMESTAMP NOT NULL DEFAULT NOW(),
+			last_attempt_at TIMESTAMP,
+			response_status INTEGER,
+			last_error TEXT,
+			created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+			delivered_at TIMESTAMP,
+			CONSTRAINT webhook_deliveries_event_key UNIQUE (subscription_id, tx_hash, log_index, event_type)
+		)`,
+		// Both sides of a transfer between monitored wallets share a log, so deliveries are keyed by type too
+		`CREATE UNIQUE INDEX IF NOT EXISTS webhook_deliveries_event_key ON webhook_deliveries (subscription_id, tx_hash, log_index, event_type)`,
+		`ALTER TABLE webhook_deliveries DROP CONSTRAINT IF EXISTS webhook_deliveries_subscription_id_tx_hash_log_index_key`,
+		`CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries (status, next_attempt_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_subscription_created ON webhook_deliveries (subscription_id, created_at DESC)`,
+		`CREATE TABLE IF NOT EXISTS built_transactions (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			wallet_address VARCHAR(42) NOT NULL,
+			step_type VARCHAR(20) NOT NULL,
+			to_address VARCHAR(42) NOT NULL,
+			data TEXT NOT NULL,
+			chain_id INTEGER NOT NULL,
+			created_at TIMESTAMP NOT NULL DEFAULT NOW()
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_built_transactions_wallet_to_created ON built_transactions (wallet_address, to_address, created_at DESC)`,
+		`CREATE TABLE IF NOT EXISTS relayed_transactions (
+			tx_hash VARCHAR(66) PRIMARY KEY,
+			built_transaction_id UUID NOT NULL REFERENCES built_transactions (id),
+			wallet_address VARCHAR(42) NOT NULL,
+			nonce BIGINT NOT NULL,
+			to_address VARCHAR(42) NOT NULL,
+			step_type VARCHAR(20) NOT NULL,
+			status VARCHAR(20) NOT NULL DEFAULT 'pending_onchain',
+			block_number BIGINT,
+			failure_reason TEXT,
+			replaced_by VARCHAR(66),
+			broadcast_at TIMESTAMP NOT NULL DEFAULT NOW(),
+			last_seen_at TIMESTAMP NOT NULL DEFAULT NOW(),
+			updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_relayed_transactions_status ON relayed_transactions (status)`,
+		`CREATE INDEX IF NOT EXISTS idx_relayed_transactions_wallet_nonce ON relayed_transactions (wallet_address, nonce)`,
+		`CREATE TABLE IF NOT EXISTS rate_snapshots (
+			block_number BIGINT PRIMARY KEY,
+			block_timestamp TIMESTAMP NOT NULL,
+			rate NUMERIC(78,0) NOT NULL,
+			total_supply NUMERIC(78,0) NOT NULL,
+			created_at TIMESTAMP NOT NULL DEFAULT NOW()
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_rate_snapshots_block_timestamp ON rate_snapshots (block_timestamp)`,
+		// Events the materializer could not apply and will not retry, kept for inspection
+		`CREATE TABLE IF NOT EXISTS materializer_dead_letters (
+			id BIGSERIAL PRIMARY KEY,
+			wallet_address VARCHAR(42) NOT NULL,
+			tx_hash VARCHAR(66) NOT NULL,
+			log_index INTEGER NOT NULL,
+			event_type VARCHAR(20) NOT NULL,
+			event JSONB NOT NULL,
+			error TEXT NOT NULL,
+			created_at TIMESTAMP NOT NULL DEFAULT NOW()
+		)`,
 		`CREATE TABLE IF NOT EXISTS crawler_state (
 			id INTEGER PRIMARY KEY DEFAULT 1,
 			last_processed_block BIGINT NOT NULL DEFAULT 22800181,
@@ -56,6 +135,8 @@ func InitMigration(db *sql.DB) error {
 		)`,
 	}
 
+	queries = append(queries, ordersProjectionQueries(OrdersTable)...)
+
 	for _, query := range queries {
 		if _, err := db.Exec(query); err != nil {
 			return fmt.Errorf("failed to execute query %s: %w", query, err)
@@ -71,3 +152,54 @@ func InitMigration(db *sql.DB) error {
 
 	return err
 }
+
+// ordersProjectionQueries returns the DDL for the orders projection under the given table name.
+// The same statements are used by InitMigration and by the projection rebuild so that a rebuilt
+// table is structurally identical to the live one.
+func ordersProjectionQueries(table string) []string {
+	return []string{
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+			order_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			tx_hash VARCHAR(66) NOT NULL,
+			log_index INTEGER NOT NULL,
+			block_number BIGINT NOT NULL,
+			tx_date TIMESTAMP NOT NULL,
+			transfer_type VARCHAR(20) NOT NULL,
+			status VARCHAR(20) NOT NULL,
+			wallet_address VARCHAR(42) NOT NULL,
+			amount DECIMAL(78,18) NOT NULL,
+			from_asset_name VARCHAR(50) NOT NULL,
+			to_asset_name VARCHAR(50) NOT NULL,
+			estimated_amount DECIMAL(78,18),
+			share_amount DECIMAL(78,18),
+			CONSTRAINT %s_event_key UNIQUE (tx_hash, log_index, wallet_address)
+		)`, table, table),
+		// Added after the table was first released; rebuild the projection to fill it for older orders
+		fmt.Sprintf(`ALTER TABLE %s ADD COLUMN IF NOT EXISTS share_amount DECIMAL(78,18)`, table),
+		// A transfer between two monitored wallets is an order of each wallet, so orders are keyed by wallet
+		// as well. Tables created before then swap their (tx_hash, log_index) constraint for a unique index.
+		fmt.Sprintf(`CREATE UNIQUE INDEX IF NOT EXISTS %s_event_key ON %s (tx_hash, log_index, wallet_address)`, table, table),
+		fmt.Sprintf(`ALTER TABLE %s DROP CONSTRAINT IF EXISTS %s_tx_hash_log_index_key`, table, table),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS idx_%s_wallet_type_status_date ON %s (wallet_address, transfer_type, status, tx_date DESC)`, table, table),
+		// Serves the (tx_date, order_id) keyset pagination of a wallet's history when neither type nor
+		// status is filtered
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS idx_%s_wallet_date_order ON %s (wallet_address, tx_date DESC, order_id DESC)`, table, table),
+		// Position of the last event applied to the projection for each wallet, used to reject
+		// events that arrive out of order
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s_watermarks (
+			wallet_address VARCHAR(42) PRIMARY KEY,
+			block_number BIGINT NOT NULL,
+			log_index INTEGER NOT NULL,
+			updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+		)`, table),
+		// Events that arrived ahead of an earlier event for the same wallet, held until it is applied
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s_parked_events (
+			wallet_address VARCHAR(42) NOT NULL,
+			block_number BIGINT NOT NULL,
+			log_index INTEGER NOT NULL,
+			event JSONB NOT NULL,
+			parked_at TIMESTAMP NOT NULL DEFAULT NOW(),
+			PRIMARY KEY (wallet_address, block_number, log_index)
+		)`, table),
+	}
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"go.uber.org/zap"
+)
+
+// OrdersShadowTable is the table the orders projection is rebuilt into before being swapped in
+const OrdersShadowTable = "orders_rebuild"
+
+// OrderProjectionRepository manages the lifecycle of the orders projection tables during a rebuild
+type OrderProjectionRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewOrderProjectionRepository(db *sql.DB, logger *zap.Logger) *OrderProjectionRepository {
+	return &OrderProjectionRepository{db: db, logger: logger}
+}
+
+// CreateShadowTable drops any leftover shadow table from a previous run and creates an empty one
+// with the same structure as the live orders table
+func (r *OrderProjectionRepository) CreateShadowTable() error {
+	if err := r.DropShadowTable(); err != nil {
+		return err
+	}
+
+	for _, query := range ordersProjectionQueries(OrdersShadowTable) {
+		if _, err := r.db.Exec(query); err != nil {
+			return fmt.Errorf("failed to create shadow orders table: %w", err)
+		}
+	}
+
+	r.logger.Info("Created shadow orders table", zap.String("table", OrdersShadowTable))
+	return nil
+}
+
+// DropShadowTable removes the shadow table if it exists
+func (r *OrderProjectionRepository) DropShadowTable() error {
+	if _, err := r.db.Exec(fmt.Sprintf(`DROP TABLE IF EXISTS %s, %s_watermarks, %s_parked_events`, OrdersShadowTable, OrdersShadowTable, OrdersShadowTable)); err != nil {
+		return fmt.Errorf("failed to drop shadow orders table: %w", err)
+	}
+	return nil
+}
+
+// CountOrders returns the number of rows in the given orders table
+func (r *OrderProjectionRepository) CountOrders(table string) (int64, error) {
+	var count int64
+	if err := r.db.QueryRow(fmt.Sprintf(`SELECT COUNT(*) FROM %s`, table)).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count orders in %s: %w", table, err)
+	}
+	return count, nil
+}
+
+// SwapShadowTable atomically replaces the live orders table, its watermarks and its parked events with the
+// shadow tables. The old tables are dropped and the shadow indexes and constraints are renamed to the live
+// names so that the next migration and the next rebuild see the same schema as a fresh install. Events
+// parked in the live table have been published, so the replay has already applied them.
+func (r *OrderProjectionRepository) SwapShadowTable() error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin swap transaction: %w", err)
+	}
+	defer tx.Rollback() // Will be ignored if tx.Commit() succeeds
+
+	replacedTable := OrdersTable + "_replaced"
+	queries := []string{
+		fmt.Sprintf(`LOCK TABLE %s IN ACCESS EXCLUSIVE MODE`, OrdersTable),
+		fmt.Sprintf(`ALTER TABLE %s RENAME TO %s`, OrdersTable, replacedTable),
+		fmt.Sprintf(`ALTER TABLE %s RENAME TO %s`, OrdersShadowTable, OrdersTable),
+		fmt.Sprintf(`DROP TABLE %s`, replacedTable),
+		fmt.Sprintf(`ALTER INDEX idx_%s_wallet_type_status_date RENAME TO idx_%s_wallet_type_status_date`, OrdersShadowTable, OrdersTable),
+		fmt.Sprintf(`ALTER INDEX idx_%s_wallet_date_order RENAME TO idx_%s_wallet_date_order`, OrdersShadowTable, OrdersTable),
+		fmt.Sprintf(`ALTER TABLE %s RENAME CONSTRAINT %s_pkey TO %s_pkey`, OrdersTable, OrdersShadowTable, OrdersTable),
+		fmt.Sprintf(`ALTER TABLE %s RENAME CONSTRAINT %s_event_key TO %s_event_key`, OrdersTable, OrdersShadowTable, OrdersTable),
+		fmt.Sprintf(`LOCK TABLE %s_watermarks IN ACCESS EXCLUSIVE MODE`, OrdersTable),
+		fmt.Sprintf(`DROP TABLE %s_watermarks`, OrdersTable),
+		fmt.Sprintf(`ALTER TABLE %s_watermarks RENAME TO %s_watermarks`, OrdersShadowTable, OrdersTable),
+		fmt.Sprintf(`ALTER TABLE %s_watermarks RENAME CONSTRAINT %s_watermarks_pkey TO %s_watermarks_pkey`, OrdersTable, OrdersShadowTable, OrdersTable),
+		fmt.Sprintf(`LOCK TABLE %s_parked_events IN ACCESS EXCLUSIVE MODE`, OrdersTable),
+		fmt.Sprintf(`DROP TABLE %s_parked_events`, OrdersTable),
+		fmt.Sprintf(`ALTER TABLE %s_parked_events RENAME TO %s_parked_events`, OrdersShadowTable, OrdersTable),
+		fmt.Sprintf(`ALTER TABLE %s_parked_events RENAME CONSTRAINT %s_parked_events_pkey TO %s_parked_events_pkey`, OrdersTable, OrdersShadowTable, OrdersTable),
+	}
+
+	for _, query := range queries {
+		if _, err := tx.Exec(query); err != nil {
+			return fmt.Errorf("failed to execute swap query %s: %w", query, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit swap transaction: %w", err)
+	}
+
+	r.logger.Info("Swapped rebuilt orders table into place", zap.String("shadow_table", OrdersShadowTable))
+	return nil
+}
@@ -2,36 +2,81 @@ package repository
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"strings"
+	"time"
+
 	"go.uber.org/zap"
 	"yield/apps/yield/internal/model"
 )
 
+// OrdersTable is the name of the live orders projection
+const OrdersTable = "orders"
+
+// queryer is implemented by both *sql.DB and *sql.Tx, so that an OrderRepository can run inside a transaction
+type queryer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
 type OrderRepository struct {
-	db     *sql.DB
+	db     queryer
+	conn   *sql.DB // nil inside a transaction
 	logger *zap.Logger
+	table  string
 }
 
 func NewOrderRepository(db *sql.DB, logger *zap.Logger) *OrderRepository {
-	return &OrderRepository{db: db, logger: logger}
+	return NewOrderRepositoryForTable(db, logger, OrdersTable)
+}
+
+// NewOrderRepositoryForTable creates an OrderRepository that reads and writes the given orders
+// table. It is used to materialize into a shadow table while the projection is being rebuilt.
+func NewOrderRepositoryForTable(db *sql.DB, logger *zap.Logger, table string) *OrderRepository {
+	return &OrderRepository{db: db, conn: db, logger: logger, table: table}
+}
+
+// WithTransaction runs fn with a repository whose reads and writes share one transaction, which is
+// committed if fn returns nil and rolled back otherwise. Called inside a transaction, fn joins it.
+func (r *OrderRepository) WithTransaction(fn func(*OrderRepository) error) error {
+	if r.conn == nil {
+		return fn(r)
+	}
+
+	tx, err := r.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // Will be ignored if tx.Commit() succeeds
+
+	if err := fn(&OrderRepository{db: tx, logger: r.logger, table: r.table}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
 }
 
 func (r *OrderRepository) UpsertOrder(order model.Order) error {
-	_, err := r.db.Exec(`
-		INSERT INTO orders (order_id, tx_hash, log_index, block_number, tx_date, transfer_type, status, wallet_address, amount, from_asset_name, to_asset_name, estimated_amount)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
-		ON CONFLICT (tx_hash, log_index) DO UPDATE SET
+	_, err := r.db.Exec(fmt.Sprintf(`
+		INSERT INTO %s (order_id, tx_hash, log_index, block_number, tx_date, transfer_type, status, wallet_address, amount, from_asset_name, to_asset_name, estimated_amount, share_amount)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		ON CONFLICT (tx_hash, log_index, wallet_address) DO UPDATE SET
 			order_id = EXCLUDED.order_id,
 			block_number = EXCLUDED.block_number,
 			tx_date = EXCLUDED.tx_date,
 			transfer_type = EXCLUDED.transfer_type,
 			status = EXCLUDED.status,
-			wallet_address = EXCLUDED.wallet_address,
 			amount = EXCLUDED.amount,
 			from_asset_name = EXCLUDED.from_asset_name,
 			to_asset_name = EXCLUDED.to_asset_name,
-			estimated_amount = EXCLUDED.estimated_amount
-	`, order.OrderID, order.TxHash, order.LogIndex, order.BlockNumber, order.TxDate, order.TransferType, order.Status, order.WalletAddress, order.Amount, order.FromAssetName, order.ToAssetName, order.EstimatedAmount)
+			estimated_amount = EXCLUDED.estimated_amount,
+			share_amount = EXCLUDED.share_amount
+	`, r.table), order.OrderID, order.TxHash, order.LogIndex, order.BlockNumber, order.TxDate, order.TransferType, order.Status, order.WalletAddress, order.Amount, order.FromAssetName, order.ToAssetName, order.EstimatedAmount, order.ShareAmount)
 
 	if err != nil {
 		return fmt.Errorf("failed to upsert order: %w", err)
@@ -46,14 +91,54 @@ func (r *OrderRepository) UpsertOrder(order model.Order) error {
 	return nil
 }
 
+// UpdateOrder overwrites an existing order, found by its order ID
+func (r *OrderRepository) UpdateOrder(order model.Order) error {
+	result, err := r.db.Exec(fmt.Sprintf(`
+		UPDATE %s SET
+			tx_hash = $2,
+			log_index = $3,
+			block_number = $4,
+			tx_date = $5,
+			transfer_type = $6,
+			status = $7,
+			wallet_address = $8,
+			amount = $9,
+			from_asset_name = $10,
+			to_asset_name = $11,
+			estimated_amount = $12,
+			share_amount = $13
+		WHERE order_id = $1
+	`, r.table), order.OrderID, order.TxHash, order.LogIndex, order.BlockNumber, order.TxDate, order.TransferType, order.Status, order.WalletAddress, order.Amount, order.FromAssetName, order.ToAssetName, order.EstimatedAmount, order.ShareAmount)
+
+	if err != nil {
+		return fmt.Errorf("failed to update order: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to update order: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("failed to update order: order %s not found", order.OrderID)
+	}
+
+	r.logger.Info("Updated order",
+		zap.String("order_id", order.OrderID),
+		zap.String("tx_hash", order.TxHash),
+		zap.Uint64("log_index", order.LogIndex),
+		zap.String("status", order.Status),
+		zap.String("wallet_address", order.WalletAddress))
+	return nil
+}
+
 func (r *OrderRepository) GetOrderByTxHash(txHash string) (*model.Order, error) {
 	var order model.Order
-	err := r.db.QueryRow(`
-		SELECT order_id, tx_hash, log_index, block_number, tx_date, transfer_type, status, wallet_address, amount, from_asset_name, to_asset_name, estimated_amount
-		FROM orders 
+	err := r.db.QueryRow(fmt.Sprintf(`
+		SELECT order_id, tx_hash, log_index, block_number, tx_date, transfer_type, status, wallet_address, amount, from_asset_name, to_asset_name, estimated_amount, share_amount
+		FROM %s 
 		WHERE tx_hash = $1
-	`, txHash).Scan(&order.OrderID, &order.TxHash, &order.LogIndex, &order.BlockNumber, &order.TxDate, &order.TransferType,
-		&order.Status, &order.WalletAddress, &order.Amount, &order.FromAssetName, &order.ToAssetName, &order.EstimatedAmount)
+	`, r.table), txHash).Scan(&order.OrderID, &order.TxHash, &order.LogIndex, &order.BlockNumber, &order.TxDate, &order.TransferType,
+		&order.Status, &order.WalletAddress, &order.Amount, &order.FromAssetName, &order.ToAssetName, &order.EstimatedAmount, &order.ShareAmount)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -67,14 +152,14 @@ func (r *OrderRepository) GetOrderByTxHash(txHash string) (*model.Order, error)
 
 func (r *OrderRepository) GetLastInProgressWithdrawalByWallet(walletAddress string) (*model.Order, error) {
 	var order model.Order
-	err := r.db.QueryRow(`
-		SELECT order_id, tx_hash, log_index, block_number, tx_date, transfer_type, status, wallet_address, amount, from_asset_name, to_asset_name, estimated_amount
-		FROM orders 
+	err := r.db.QueryRow(fmt.Sprintf(`
+		SELECT order_id, tx_hash, log_index, block_number, tx_date, transfer_type, status, wallet_address, amount, from_asset_name, to_asset_name, estimated_amount, share_amount
+		FROM %s 
 		WHERE wallet_address = $1 AND transfer_type = 'withdrawal' AND status = 'in_progress'
 		ORDER BY tx_date DESC
 		LIMIT 1
-	`, walletAddress).Scan(&order.OrderID, &order.TxHash, &order.LogIndex, &order.BlockNumber, &order.TxDate, &order.TransferType,
-		&order.Status, &order.WalletAddress, &order.Amount, &order.FromAssetName, &order.ToAssetName, &order.EstimatedAmount)
+	`, r.table), walletAddress).Scan(&order.OrderID, &order.TxHash, &order.LogIndex, &order.BlockNumber, &order.TxDate, &order.TransferType,
+		&order.Status, &order.WalletAddress, &order.Amount, &order.FromAssetName, &order.ToAssetName, &order.EstimatedAmount, &order.ShareAmount)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -88,14 +173,14 @@ func (r *OrderRepository) GetLastInProgressWithdrawalByWallet(walletAddress stri
 
 func (r *OrderRepository) GetInProgressWithdrawalByWalletAndAmount(walletAddress string, amount string) (*model.Order, error) {
 	var order model.Order
-	err := r.db.QueryRow(`
-		SELECT order_id, tx_hash, log_index, block_number, tx_date, transfer_type, status, wallet_address, amount, from_asset_name, to_asset_name, estimated_amount
-		FROM orders 
+	err := r.db.QueryRow(fmt.Sprintf(`
+		SELECT order_id, tx_hash, log_index, block_number, tx_date, transfer_type, status, wallet_address, amount, from_asset_name, to_asset_name, estimated_amount, share_amount
+		FROM %s 
 		WHERE wallet_address = $1 AND transfer_type = 'withdrawal' AND status = 'in_progress' AND amount = $2
 		ORDER BY tx_date DESC
 		LIMIT 1
-	`, walletAddress, amount).Scan(&order.OrderID, &order.TxHash, &order.LogIndex, &order.BlockNumber, &order.TxDate, &order.TransferType,
-		&order.Status, &order.WalletAddress, &order.Amount, &order.FromAssetName, &order.ToAssetName, &order.EstimatedAmount)
+	`, r.table), walletAddress, amount).Scan(&order.OrderID, &order.TxHash, &order.LogIndex, &order.BlockNumber, &order.TxDate, &order.TransferType,
+		&order.Status, &order.WalletAddress, &order.Amount, &order.FromAssetName, &order.ToAssetName, &order.EstimatedAmount, &order.ShareAmount)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -109,12 +194,12 @@ func (r *OrderRepository) GetInProgressWithdrawalByWalletAndAmount(walletAddress
 
 func (r *OrderRepository) GetOrderByID(orderID string) (*model.Order, error) {
 	var order model.Order
-	err := r.db.QueryRow(`
-		SELECT order_id, tx_hash, log_index, block_number, tx_date, transfer_type, status, wallet_address, amount, from_asset_name, to_asset_name, estimated_amount
-		FROM orders 
+	err := r.db.QueryRow(fmt.Sprintf(`
+		SELECT order_id, tx_hash, log_index, block_number, tx_date, transfer_type, status, wallet_address, amount, from_asset_name, to_asset_name, estimated_amount, share_amount
+		FROM %s 
 		WHERE order_id = $1
-	`, orderID).Scan(&order.OrderID, &order.TxHash, &order.LogIndex, &order.BlockNumber, &order.TxDate, &order.TransferType,
-		&order.Status, &order.WalletAddress, &order.Amount, &order.FromAssetName, &order.ToAssetName, &order.EstimatedAmount)
+	`, r.table), orderID).Scan(&order.OrderID, &order.TxHash, &order.LogIndex, &order.BlockNumber, &order.TxDate, &order.TransferType,
+		&order.Status, &order.WalletAddress, &order.Amount, &order.FromAssetName, &order.ToAssetName, &order.EstimatedAmount, &order.ShareAmount)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -127,10 +212,10 @@ func (r *OrderRepository) GetOrderByID(orderID string) (*model.Order, error) {
 }
 
 func (r *OrderRepository) CreateOrder(order model.Order) error {
-	_, err := r.db.Exec(`
-		INSERT INTO orders (order_id, tx_hash, log_index, block_number, tx_date, transfer_type, status, wallet_address, amount, from_asset_name, to_asset_name, estimated_amount)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
-	`, order.OrderID, order.TxHash, order.LogIndex, order.BlockNumber, order.TxDate, order.TransferType, order.Status, order.WalletAddress, order.Amount, order.FromAssetName, order.ToAssetName, order.EstimatedAmount)
+	_, err := r.db.Exec(fmt.Sprintf(`
+		INSERT INTO %s (order_id, tx_hash, log_index, block_number, tx_date, transfer_type, status, wallet_address, amount, from_asset_name, to_asset_name, estimated_amount, share_amount)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+	`, r.table), order.OrderID, order.TxHash, order.LogIndex, order.BlockNumber, order.TxDate, order.TransferType, order.Status, order.WalletAddress, order.Amount, order.FromAssetName, order.ToAssetName, order.EstimatedAmount, order.ShareAmount)
 
 	if err != nil {
 		return fmt.Errorf("failed to create order: %w", err)
@@ -145,9 +230,9 @@ func (r *OrderRepository) CreateOrder(order model.Order) error {
 }
 
 func (r *OrderRepository) UpdateOrderStatus(txHash, status string) error {
-	_, err := r.db.Exec(`
-		UPDATE orders SET status = $1 WHERE tx_hash = $2
-	`, status, txHash)
+	_, err := r.db.Exec(fmt.Sprintf(`
+		UPDATE %s SET status = $1 WHERE tx_hash = $2
+	`, r.table), status, txHash)
 
 	if err != nil {
 		return fmt.Errorf("failed to update order status: %w", err)
@@ -158,3 +243,265 @@ func (r *OrderRepository) UpdateOrderStatus(txHash, status string) error {
 		zap.String("status", status))
 	return nil
 }
+
+// GetWalletWatermark returns the position of the last event applied for the wallet. found is false if no
+// event has been applied for the wallet yet.
+func (r *OrderRepository) GetWalletWatermark(walletAddress string) (blockNumber uint64, logIndex uint64, found bool, err error) {
+	err = r.db.QueryRow(fmt.Sprintf(`
+		SELECT block_number, log_index FROM %s_watermarks WHERE wallet_address = $1
+	`, r.table), walletAddress).Scan(&blockNumber, &logIndex)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, 0, false, nil
+		}
+		return 0, 0, false, fmt.Errorf("failed to get wallet watermark: %w", err)
+	}
+
+	return blockNumber, logIndex, true, nil
+}
+
+// AdvanceWalletWatermark records the position of the last event applied for the wallet. The watermark
+// never moves backwards.
+func (r *OrderRepository) AdvanceWalletWatermark(walletAddress string, blockNumber, logIndex uint64) error {
+	_, err := r.db.Exec(fmt.Sprintf(`
+		INSERT INTO %s_watermarks AS w (wallet_address, block_number, log_index)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (wallet_address) DO UPDATE SET
+			block_number = EXCLUDED.block_number,
+			log_index = EXCLUDED.log_index,
+			updated_at = NOW()
+		WHERE (w.block_number, w.log_index) < (EXCLUDED.block_number, EXCLUDED.log_index)
+	`, r.table), walletAddress, blockNumber, logIndex)
+
+	if err != nil {
+		return fmt.Errorf("failed to advance wallet watermark: %w", err)
+	}
+
+	return nil
+}
+
+// HasOutboxEventsBetween reports whether the outbox holds an event for the wallet strictly between the two
+// (block_number, log_index) positions. The outbox records every event that will reach the projection, so
+// such an event is one that has not been applied yet.
+func (r *OrderRepository) HasOutboxEventsBetween(walletAddress string, fromBlock, fromLogIndex, toBlock, toLogIndex uint64) (bool, error) {
+	var exists bool
+	err := r.db.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM event_outbox
+			WHERE wallet_address = $1
+			AND (block_number, log_index) > ($2, $3)
+			AND (block_number, log_index) < ($4, $5)
+		)
+	`, walletAddress, fromBlock, fromLogIndex, toBlock, toLogIndex).Scan(&exists)
+
+	if err != nil {
+		return false, fmt.Errorf("failed to check outbox for earlier events: %w", err)
+	}
+
+	return exists, nil
+}
+
+// HasOrders reports whether any order has been materialized for the wallet
+func (r *OrderRepository) HasOrders(walletAddress string) (bool, error) {
+	var exists bool
+	err := r.db.QueryRow(fmt.Sprintf(`
+		SELECT EXISTS (SELECT 1 FROM %s WHERE wallet_address = $1)
+	`, r.table), walletAddress).Scan(&exists)
+
+	if err != nil {
+		return false, fmt.Errorf("failed to check for orders: %w", err)
+	}
+
+	return exists, nil
+}
+
+// ParkEvent stores an event that cannot be applied until earlier events for its wallet have been
+func (r *OrderRepository) ParkEvent(walletAddress string, blockNumber, logIndex uint64, event json.RawMessage) error {
+	_, err := r.db.Exec(fmt.Sprintf(`
+		INSERT INTO %s_parked_events (wallet_address, block_number, log_index, event)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (wallet_address, block_number, log_index) DO UPDATE SET
+			event = EXCLUDED.event
+	`, r.table), walletAddress, blockNumber, logIndex, event)
+
+	if err != nil {
+		return fmt.Errorf("failed to park event: %w", err)
+	}
+
+	return nil
+}
+
+// GetParkedEvents returns the wallet's parked events in chain order
+func (r *OrderRepository) GetParkedEvents(walletAddress string) ([]json.RawMessage, error) {
+	rows, err := r.db.Query(fmt.Sprintf(`
+		SELECT event FROM %s_parked_events
+		WHERE wallet_address = $1
+		ORDER BY block_number, log_index
+	`, r.table), walletAddress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get parked events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []json.RawMessage
+	for rows.Next() {
+		var event json.RawMessage
+		if err := rows.Scan(&event); err != nil {
+			return nil, fmt.Errorf("failed to scan parked event: %w", err)
+		}
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating parked events: %w", err)
+	}
+
+	return events, nil
+}
+
+// DeleteParkedEvent removes a parked event once it has been applied or found to be a redelivery
+func (r *OrderRepository) DeleteParkedEvent(walletAddress string, blockNumber, logIndex uint64) error {
+	_, err := r.db.Exec(fmt.Sprintf(`
+		DELETE FROM %s_parked_events WHERE wallet_address = $1 AND block_number = $2 AND log_index = $3
+	`, r.table), walletAddress, blockNumber, logIndex)
+
+	if err != nil {
+		return fmt.Errorf("failed to delete parked event: %w", err)
+	}
+
+	return nil
+}
+
+// DeadLetterEvent records an event the materializer gave up on, with the reason it could not be applied
+func (r *OrderRepository) DeadLetterEvent(walletAddress, txHash string, logIndex uint64, eventType string, event json.RawMessage, reason string) error {
+	_, err := r.db.Exec(`
+		INSERT INTO materializer_dead_letters (wallet_address, tx_hash, log_index, event_type, event, error)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, walletAddress, txHash, logIndex, eventType, event, reason)
+
+	if err != nil {
+		return fmt.Errorf("failed to dead-letter event: %w", err)
+	}
+
+	return nil
+}
+
+// OrderCursor identifies the position of an order in a wallet's order history
+type OrderCursor struct {
+	TxDate  time.Time
+	OrderID string
+}
+
+// OrderFilter selects and pages through the orders of a wallet
+type OrderFilter struct {
+	WalletAddress string
+	TransferType  string     // Optional: "deposit", "withdrawal", "transfer_in" or "transfer_out"
+	Status        string     // Optional: "completed" or "in_progress"
+	AssetName     string     // Optional: matches either the from or the to asset
+	From          *time.Time // Optional: inclusive lower bound on tx_date
+	To            *time.Time // Optional: exclusive upper bound on tx_date
+	After         *OrderCursor
+	Ascending     bool
+	Limit         int
+}
+
+// ListOrdersByWallet returns a page of the wallet's orders sorted by tx_date. Paging is keyset based on
+// (tx_date, order_id) so that it can use idx_orders_wallet_date_order, or idx_orders_wallet_type_status_date
+// when type and status are filtered, and stays stable while new orders are written.
+func (r *OrderRepository) ListOrdersByWallet(filter OrderFilter) ([]model.Order, error) {
+	conditions := []string{"wallet_address = $1"}
+	args := []interface{}{filter.WalletAddress}
+
+	addCondition := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, strings.ReplaceAll(condition, "?", fmt.Sprintf("$%d", len(args))))
+	}
+
+	if filter.TransferType != "" {
+		addCondition("transfer_type = ?", filter.TransferType)
+	}
+	if filter.Status != "" {
+		addCondition("status = ?", filter.Status)
+	}
+	if filter.AssetName != "" {
+		addCondition("(UPPER(from_asset_name) = UPPER(?) OR UPPER(to_asset_name) = UPPER(?))", filter.AssetName)
+	}
+	if filter.From != nil {
+		addCondition("tx_date >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		addCondition("tx_date < ?", *filter.To)
+	}
+
+	direction := "DESC"
+	comparison := "<"
+	if filter.Ascending {
+		direction = "ASC"
+		comparison = ">"
+	}
+
+	if filter.After != nil {
+		args = append(args, filter.After.TxDate, filter.After.OrderID)
+		conditions = append(conditions, fmt.Sprintf("(tx_date, order_id) %s ($%d, $%d)", comparison, len(args)-1, len(args)))
+	}
+
+	args = append(args, filter.Limit)
+	rows, err := r.db.Query(fmt.Sprintf(`
+		SELECT order_id, tx_hash, log_index, block_number, tx_date, transfer_type, status, wallet_address, amount, from_asset_name, to_asset_name, estimated_amount, share_amount
+		FROM %s
+		WHERE %s
+		ORDER BY tx_date %s, order_id %s
+		LIMIT $%d
+	`, r.table, strings.Join(conditions, " AND "), direction, direction, len(args)), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list orders: %w", err)
+	}
+	defer rows.Close()
+
+	var orders []model.Order
+	for rows.Next() {
+		var order model.Order
+		if err := rows.Scan(&order.OrderID, &order.TxHash, &order.LogIndex, &order.BlockNumber, &order.TxDate, &order.TransferType,
+			&order.Status, &order.WalletAddress, &order.Amount, &order.FromAssetName, &order.ToAssetName, &order.EstimatedAmount, &order.ShareAmount); err != nil {
+			return nil, fmt.Errorf("failed to scan order: %w", err)
+		}
+		orders = append(orders, order)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating orders: %w", err)
+	}
+
+	return orders, nil
+}
+
+// ListOrdersByWalletInChainOrder returns all of the wallet's orders in the order their events were applied
+func (r *OrderRepository) ListOrdersByWalletInChainOrder(walletAddress string) ([]model.Order, error) {
+	rows, err := r.db.Query(fmt.Sprintf(`
+		SELECT order_id, tx_hash, log_index, block_number, tx_date, transfer_type, status, wallet_address, amount, from_asset_name, to_asset_name, estimated_amount, share_amount
+		FROM %s
+		WHERE wallet_address = $1
+		ORDER BY block_number, log_index
+	`, r.table), walletAddress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list orders: %w", err)
+	}
+	defer rows.Close()
+
+	var orders []model.Order
+	for rows.Next() {
+		var order model.Order
+		if err := rows.Scan(&order.OrderID, &order.TxHash, &order.LogIndex, &order.BlockNumber, &order.TxDate, &order.TransferType,
+			&order.Status, &order.WalletAddress, &order.Amount, &order.FromAssetName, &order.ToAssetName, &order.EstimatedAmount, &order.ShareAmount); err != nil {
+			return nil, fmt.Errorf("failed to scan order: %w", err)
+		}
+		orders = append(orders, order)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating orders: %w", err)
+	}
+
+	return orders, nil
+}
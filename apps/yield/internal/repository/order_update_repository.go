@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"go.uber.org/zap"
+	"yield/apps/yield/internal/model"
+)
+
+// OrderUpdateRepository stores the log of changes made to orders by the materializer. The log gives
+// every change a sequential ID so that streaming clients can resume from the last change they saw.
+type OrderUpdateRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewOrderUpdateRepository(db *sql.DB, logger *zap.Logger) *OrderUpdateRepository {
+	return &OrderUpdateRepository{db: db, logger: logger}
+}
+
+// AppendOrderUpdate records a change to an order and returns it with its assigned ID
+func (r *OrderUpdateRepository) AppendOrderUpdate(order model.Order, updateType string) (*model.OrderUpdate, error) {
+	payload, err := json.Marshal(order)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal order update: %w", err)
+	}
+
+	update := model.OrderUpdate{
+		WalletAddress: order.WalletAddress,
+		UpdateType:    updateType,
+		Order:         order,
+	}
+
+	err = r.db.QueryRow(`
+		INSERT INTO order_updates (wallet_address, order_id, update_type, payload)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`, order.WalletAddress, order.OrderID, updateType, payload).Scan(&update.ID, &update.CreatedAt)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to append order update: %w", err)
+	}
+
+	return &update, nil
+}
+
+// GetOrderUpdatesAfter returns the wallet's order updates with an ID greater than afterID, oldest first
+func (r *OrderUpdateRepository) GetOrderUpdatesAfter(walletAddress string, afterID int64, limit int) ([]model.OrderUpdate, error) {
+	rows, err := r.db.Query(`
+		SELECT id, wallet_address, update_type, payload, created_at
+		FROM order_updates
+		WHERE wallet_address = $1 AND id > $2
+		ORDER BY id
+		LIMIT $3
+	`, walletAddress, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order updates: %w", err)
+	}
+	defer rows.Close()
+
+	var updates []model.OrderUpdate
+	for rows.Next() {
+		var update model.OrderUpdate
+		var payload []byte
+		if err := rows.Scan(&update.ID, &update.WalletAddress, &update.UpdateType, &payload, &update.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan order update: %w", err)
+		}
+		if err := json.Unmarshal(payload, &update.Order); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal order update %d: %w", update.ID, err)
+		}
+		updates = append(updates, update)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating order updates: %w", err)
+	}
+
+	return updates, nil
+}
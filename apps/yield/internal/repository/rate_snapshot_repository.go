@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"yield/apps/yield/internal/model"
+)
+
+// RateSnapshotRepository stores the vault exchange rate series used to compute APYs
+type RateSnapshotRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewRateSnapshotRepository(db *sql.DB, logger *zap.Logger) *RateSnapshotRepository {
+	return &RateSnapshotRepository{db: db, logger: logger}
+}
+
+const rateSnapshotColumns = `block_number, block_timestamp, rate, total_supply, created_at`
+
+// RecordRateSnapshot stores a snapshot. A snapshot of a block that was already recorded is ignored.
+func (r *RateSnapshotRepository) RecordRateSnapshot(snapshot model.RateSnapshot) error {
+	_, err := r.db.Exec(`
+		INSERT INTO rate_snapshots (block_number, block_timestamp, rate, total_supply)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (block_number) DO NOTHING
+	`, snapshot.BlockNumber, snapshot.BlockTimestamp.UTC(), snapshot.Rate, snapshot.TotalSupply)
+
+	if err != nil {
+		return fmt.Errorf("failed to record rate snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// GetLatestRateSnapshot returns the most recent snapshot, or nil if none has been recorded
+func (r *RateSnapshotRepository) GetLatestRateSnapshot() (*model.RateSnapshot, error) {
+	return r.queryRateSnapshot(`
+		SELECT ` + rateSnapshotColumns + `
+		FROM rate_snapshots
+		ORDER BY block_number DESC
+		LIMIT 1
+	`)
+}
+
+// GetRateSnapshotAtOrBefore returns the most recent snapshot taken at or before the given time, or nil
+// if there is none
+func (r *RateSnapshotRepository) GetRateSnapshotAtOrBefore(t time.Time) (*model.RateSnapshot, error) {
+	return r.queryRateSnapshot(`
+		SELECT `+rateSnapshotColumns+`
+		FROM rate_snapshots
+		WHERE block_timestamp <= $1
+		ORDER BY block_timestamp DESC
+		LIMIT 1
+	`, t.UTC())
+}
+
+// ListRateSnapshotBuckets returns the last snapshot of each bucket between from and to, oldest first.
+// Buckets are the given date_trunc unit, "hour" or "day", in UTC.
+func (r *RateSnapshotRepository) ListRateSnapshotBuckets(from, to time.Time, interval string) ([]model.RateSnapshot, error) {
+	rows, err := r.db.Query(`
+		SELECT DISTINCT ON (date_trunc($3, block_timestamp)) `+rateSnapshotColumns+`
+		FROM rate_snapshots
+		WHERE block_timestamp >= $1 AND block_timestamp <= $2
+		ORDER BY date_trunc($3, block_timestamp), block_timestamp DESC
+	`, from.UTC(), to.UTC(), interval)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list rate snapshots: %w", err)
+	}
+	defer rows.Close()
+
+	var snapshots []model.RateSnapshot
+	for rows.Next() {
+		var snapshot model.RateSnapshot
+		if err := rows.Scan(&snapshot.BlockNumber, &snapshot.BlockTimestamp, &snapshot.Rate,
+			&snapshot.TotalSupply, &snapshot.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan rate snapshot: %w", err)
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list rate snapshots: %w", err)
+	}
+
+	return snapshots, nil
+}
+
+func (r *RateSnapshotRepository) queryRateSnapshot(query string, args ...interface{}) (*model.RateSnapshot, error) {
+	var snapshot model.RateSnapshot
+	err := r.db.QueryRow(query, args...).Scan(&snapshot.BlockNumber, &snapshot.BlockTimestamp,
+		&snapshot.Rate, &snapshot.TotalSupply, &snapshot.CreatedAt)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to query rate snapshot: %w", err)
+	}
+
+	return &snapshot, nil
+}
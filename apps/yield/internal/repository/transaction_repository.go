@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"yield/apps/yield/internal/model"
+)
+
+// TransactionRepository stores the unsigned transactions built for wallets and the signed transactions
+// relayed on their behalf
+type TransactionRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewTransactionRepository(db *sql.DB, logger *zap.Logger) *TransactionRepository {
+	return &TransactionRepository{db: db, logger: logger}
+}
+
+const relayedTransactionColumns = `tx_hash, built_transaction_id, wallet_address, nonce, to_address, step_type, status,
+		block_number, failure_reason, replaced_by, broadcast_at, last_seen_at, updated_at`
+
+func (r *TransactionRepository) RecordBuiltTransaction(transaction model.BuiltTransaction) error {
+	_, err := r.db.Exec(`
+		INSERT INTO built_transactions (wallet_address, step_type, to_address, data, chain_id)
+		VALUES ($1, $2, $3, $4, $5)
+	`, transaction.WalletAddress, transaction.StepType, transaction.ToAddress, transaction.Data, transaction.ChainID)
+
+	if err != nil {
+		return fmt.Errorf("failed to record built transaction: %w", err)
+	}
+
+	return nil
+}
+
+// FindBuiltTransaction returns the most recent transaction built for the wallet since the given time
+// with the same recipient, calldata and chain, or nil if there is none
+func (r *TransactionRepository) FindBuiltTransaction(walletAddress, toAddress, data string, chainID int64, since time.Time) (*model.BuiltTransaction, error) {
+	var transaction model.BuiltTransaction
+	err := r.db.QueryRow(`
+		SELECT id, wallet_address, step_type, to_address, data, chain_id, created_at
+		FROM built_transactions
+		WHERE wallet_address = $1 AND to_address = $2 AND data = $3 AND chain_id = $4 AND created_at >= $5
+		ORDER BY created_at DESC
+		LIMIT 1
+	`, walletAddress, toAddress, data, chainID, since).Scan(&transaction.ID, &transaction.WalletAddress,
+		&transaction.StepType, &transaction.ToAddress, &transaction.Data, &transaction.ChainID, &transaction.CreatedAt)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find built transaction: %w", err)
+	}
+
+	return &transaction, nil
+}
+
+// CreateRelayedTransaction records a broadcast transaction as pending. Pending transactions from the
+// same wallet with the same nonce stay pending: any one of them may be mined, and the tracker settles
+// which from their receipts.
+func (r *TransactionRepository) CreateRelayedTransaction(transaction model.RelayedTransaction) error {
+	_, err := r.db.Exec(`
+		INSERT INTO relayed_transactions (tx_hash, built_transaction_id, wallet_address, nonce, to_address, step_type, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (tx_hash) DO NOTHING
+	`, transaction.TxHash, transaction.BuiltTransactionID, transaction.WalletAddress, transaction.Nonce,
+		transaction.ToAddress, transaction.StepType, model.TransactionPendingOnchain)
+	if err != nil {
+		return fmt.Errorf("failed to create relayed transaction: %w", err)
+	}
+
+	r.logger.Info("Relayed transaction",
+		zap.String("tx_hash", transaction.TxHash),
+		zap.String("wallet_address", transaction.WalletAddress),
+		zap.Uint64("nonce", transaction.Nonce))
+	return nil
+}
+
+// GetRelayedTransaction returns the relayed transaction with the given hash, or nil if it does not exist
+func (r *TransactionRepository) GetRelayedTransaction(txHash string) (*model.RelayedTransaction, error) {
+	transactions, err := r.queryRelayedTransactions(fmt.Sprintf(`
+		SELECT %s
+		FROM relayed_transactions
+		WHERE tx_hash = $1
+	`, relayedTransactionColumns), txHash)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(transactions) == 0 {
+		return nil, nil
+	}
+
+	return &transactions[0], nil
+}
+
+// ListPendingRelayedTransactions returns transactions that have been broadcast but not yet resolved,
+// oldest first
+func (r *TransactionRepository) ListPendingRelayedTransactions(limit int) ([]model.RelayedTransaction, error) {
+	return r.queryRelayedTransactions(fmt.Sprintf(`
+		SELECT %s
+		FROM relayed_transactions
+		WHERE status = $1
+		ORDER BY broadcast_at
+		LIMIT $2
+	`, relayedTransactionColumns), model.TransactionPendingOnchain, limit)
+}
+
+// MarkRelayedTransactionSeen records that the node still knows of a pending transaction
+func (r *TransactionRepository) MarkRelayedTransactionSeen(txHash string) error {
+	_, err := r.db.Exec(`
+		UPDATE relayed_transactions SET last_seen_at = NOW() WHERE tx_hash = $1
+	`, txHash)
+
+	if err != nil {
+		return fmt.Errorf("failed to mark relayed transaction seen: %w", err)
+	}
+
+	return nil
+}
+
+// ResolveRelayedTransaction moves a pending transaction to its final status. Transactions that were
+// resolved in the meantime are left unchanged. Replaced transactions record the relayed transaction
+// that was mined with their nonce, whichever of the two is resolved first.
+func (r *TransactionRepository) ResolveRelayedTransaction(txHash, status string, blockNumber *uint64, failureReason *string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		UPDATE relayed_transactions
+		SET status = $2, block_number = $3, failure_reason = $4, updated_at = NOW()
+		WHERE tx_hash = $1 AND status = $5
+	`, txHash, status, blockNumber, failureReason, model.TransactionPendingOnchain)
+	if err != nil {
+		return fmt.Errorf("failed to resolve relayed transaction: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		UPDATE relayed_transactions replaced
+		SET replaced_by = mined.tx_hash, updated_at = NOW()
+		FROM relayed_transactions resolved, relayed_transactions mined
+		WHERE resolved.tx_hash = $1
+			AND mined.wallet_address = resolved.wallet_address AND mined.nonce = resolved.nonce AND mined.status IN ($2, $3)
+			AND replaced.wallet_address = resolved.wallet_address AND replaced.nonce = resolved.nonce AND replaced.status = $4
+			AND replaced.replaced_by IS NULL
+	`, txHash, model.TransactionConfirmed, model.TransactionReverted, model.TransactionReplaced)
+	if err != nil {
+		return fmt.Errorf("failed to link replaced transactions: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit resolved transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *TransactionRepository) queryRelayedTransactions(query string, args ...interface{}) ([]model.RelayedTransaction, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get relayed transactions: %w", err)
+	}
+	defer rows.Close()
+
+	var transactions []model.RelayedTransaction
+	for rows.Next() {
+		var transaction model.RelayedTransaction
+		if err := rows.Scan(&transaction.TxHash, &transaction.BuiltTransactionID, &transaction.WalletAddress,
+			&transaction.Nonce, &transaction.ToAddress, &transaction.StepType, &transaction.Status,
+			&transaction.BlockNumber, &transaction.FailureReason, &transaction.ReplacedBy,
+			&transaction.BroadcastAt, &transaction.LastSeenAt, &transaction.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan relayed transaction: %w", err)
+		}
+		transactions = append(transactions, transaction)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating relayed transactions: %w", err)
+	}
+
+	return transactions, nil
+}
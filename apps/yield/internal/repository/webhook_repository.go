@@ -0,0 +1,236 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+	"yield/apps/yield/internal/model"
+)
+
+// WebhookRepository stores webhook subscriptions and the log of deliveries made to them
+type WebhookRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewWebhookRepository(db *sql.DB, logger *zap.Logger) *WebhookRepository {
+	return &WebhookRepository{db: db, logger: logger}
+}
+
+const webhookDeliveryColumns = `id, subscription_id, event_type, tx_hash, log_index, payload, status, attempts,
+		next_attempt_at, last_attempt_at, response_status, last_error, created_at, delivered_at`
+
+func (r *WebhookRepository) CreateSubscription(subscription model.WebhookSubscription) (*model.WebhookSubscription, error) {
+	if subscription.EventTypes == nil {
+		subscription.EventTypes = []string{}
+	}
+
+	err := r.db.QueryRow(`
+		INSERT INTO webhook_subscriptions (url, secret, wallet_address, event_types)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`, subscription.URL, subscription.Secret, subscription.WalletAddress, pq.Array(subscription.EventTypes)).
+		Scan(&subscription.ID, &subscription.CreatedAt)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to create webhook subscription: %w", err)
+	}
+
+	r.logger.Info("Created webhook subscription",
+		zap.String("subscription_id", subscription.ID),
+		zap.String("url", subscription.URL))
+	return &subscription, nil
+}
+
+// GetSubscription returns the subscription with the given ID, or nil if it does not exist
+func (r *WebhookRepository) GetSubscription(id string) (*model.WebhookSubscription, error) {
+	var subscription model.WebhookSubscription
+	err := r.db.QueryRow(`
+		SELECT id, url, secret, wallet_address, event_types, created_at
+		FROM webhook_subscriptions
+		WHERE id = $1
+	`, id).Scan(&subscription.ID, &subscription.URL, &subscription.Secret, &subscription.WalletAddress,
+		pq.Array(&subscription.EventTypes), &subscription.CreatedAt)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get webhook subscription: %w", err)
+	}
+
+	return &subscription, nil
+}
+
+func (r *WebhookRepository) ListSubscriptions() ([]model.WebhookSubscription, error) {
+	return r.querySubscriptions(`
+		SELECT id, url, secret, wallet_address, event_types, created_at
+		FROM webhook_subscriptions
+		ORDER BY created_at DESC
+	`)
+}
+
+// GetMatchingSubscriptions returns the subscriptions that should be notified of an event for a wallet.
+// A subscription matches when it is for that wallet or for every wallet, and when it either lists the
+// event type or has no event type filter.
+func (r *WebhookRepository) GetMatchingSubscriptions(walletAddress, eventType string) ([]model.WebhookSubscription, error) {
+	return r.querySubscriptions(`
+		SELECT id, url, secret, wallet_address, event_types, created_at
+		FROM webhook_subscriptions
+		WHERE (wallet_address IS NULL OR wallet_address = $1)
+		  AND (cardinality(event_types) = 0 OR $2 = ANY(event_types))
+	`, walletAddress, eventType)
+}
+
+// DeleteSubscription deletes a subscription along with its delivery log. It reports whether the
+// subscription existed.
+func (r *WebhookRepository) DeleteSubscription(id string) (bool, error) {
+	result, err := r.db.Exec(`DELETE FROM webhook_subscriptions WHERE id = $1`, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete webhook subscription: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// EnqueueDelivery schedules an event for delivery to a subscription. Enqueueing the same event for the
+// same subscription again is a no-op, so redelivered Kafka messages do not produce duplicate webhooks.
+func (r *WebhookRepository) EnqueueDelivery(subscriptionID, eventType, txHash string, logIndex uint64, payload []byte) error {
+	_, err := r.db.Exec(`
+		INSERT INTO webhook_deliveries (subscription_id, event_type, tx_hash, log_index, payload)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (subscription_id, tx_hash, log_index, event_type) DO NOTHING
+	`, subscriptionID, eventType, txHash, logIndex, payload)
+
+	if err != nil {
+		return fmt.Errorf("failed to enqueue webhook delivery: %w", err)
+	}
+
+	return nil
+}
+
+// ClaimDueDeliveries returns pending deliveries whose next attempt is due. Claimed deliveries have their
+// next attempt pushed back by the lease so that concurrent workers do not send them twice; a worker
+// that dies mid-delivery leaves the delivery to be retried once the lease expires.
+func (r *WebhookRepository) ClaimDueDeliveries(limit int, lease time.Duration) ([]model.WebhookDelivery, error) {
+	return r.queryDeliveries(fmt.Sprintf(`
+		UPDATE webhook_deliveries
+		SET next_attempt_at = NOW() + $2 * INTERVAL '1 second'
+		WHERE id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING %s
+	`, webhookDeliveryColumns), limit, lease.Seconds())
+}
+
+func (r *WebhookRepository) MarkDeliverySucceeded(id string, responseStatus int) error {
+	_, err := r.db.Exec(`
+		UPDATE webhook_deliveries
+		SET status = 'succeeded', attempts = attempts + 1, last_attempt_at = NOW(), delivered_at = NOW(),
+		    response_status = $2, last_error = NULL
+		WHERE id = $1
+	`, id, responseStatus)
+
+	if err != nil {
+		return fmt.Errorf("failed to mark webhook delivery succeeded: %w", err)
+	}
+
+	return nil
+}
+
+// MarkDeliveryAttemptFailed records a failed attempt. The delivery is retried at nextAttemptAt, or
+// marked failed for good when giveUp is set.
+func (r *WebhookRepository) MarkDeliveryAttemptFailed(id string, responseStatus *int, lastError string, nextAttemptAt time.Time, giveUp bool) error {
+	status := model.WebhookDeliveryPending
+	if giveUp {
+		status = model.WebhookDeliveryFailed
+	}
+
+	_, err := r.db.Exec(`
+		UPDATE webhook_deliveries
+		SET status = $2, attempts = attempts + 1, last_attempt_at = NOW(), next_attempt_at = $3,
+		    response_status = $4, last_error = $5
+		WHERE id = $1
+	`, id, status, nextAttemptAt, responseStatus, lastError)
+
+	if err != nil {
+		return fmt.Errorf("failed to record webhook delivery attempt: %w", err)
+	}
+
+	return nil
+}
+
+// ListDeliveries returns the most recent deliveries made to a subscription, newest first
+func (r *WebhookRepository) ListDeliveries(subscriptionID string, limit int) ([]model.WebhookDelivery, error) {
+	return r.queryDeliveries(fmt.Sprintf(`
+		SELECT %s
+		FROM webhook_deliveries
+		WHERE subscription_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2
+	`, webhookDeliveryColumns), subscriptionID, limit)
+}
+
+func (r *WebhookRepository) querySubscriptions(query string, args ...interface{}) ([]model.WebhookSubscription, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	var subscriptions []model.WebhookSubscription
+	for rows.Next() {
+		var subscription model.WebhookSubscription
+		if err := rows.Scan(&subscription.ID, &subscription.URL, &subscription.Secret, &subscription.WalletAddress,
+			pq.Array(&subscription.EventTypes), &subscription.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook subscription: %w", err)
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating webhook subscriptions: %w", err)
+	}
+
+	return subscriptions, nil
+}
+
+func (r *WebhookRepository) queryDeliveries(query string, args ...interface{}) ([]model.WebhookDelivery, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []model.WebhookDelivery
+	for rows.Next() {
+		var delivery model.WebhookDelivery
+		var payload []byte
+		if err := rows.Scan(&delivery.ID, &delivery.SubscriptionID, &delivery.EventType, &delivery.TxHash, &delivery.LogIndex,
+			&payload, &delivery.Status, &delivery.Attempts, &delivery.NextAttemptAt, &delivery.LastAttemptAt,
+			&delivery.ResponseStatus, &delivery.LastError, &delivery.CreatedAt, &delivery.DeliveredAt); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		delivery.Payload = json.RawMessage(payload)
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating webhook deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
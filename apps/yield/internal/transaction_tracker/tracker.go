@@ -0,0 +1,155 @@
+package transaction_tracker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"go.uber.org/zap"
+	"yield/apps/yield/internal/model"
+	"yield/apps/yield/internal/repository"
+)
+
+const (
+	// How often pending transactions are checked, roughly once per block
+	pollInterval = 12 * time.Second
+	batchSize    = 100
+
+	// A pending transaction the node has not known of for this long is considered dropped
+	DropTimeout = 30 * time.Minute
+)
+
+// Tracker follows relayed transactions until they are mined, replaced or dropped
+type Tracker struct {
+	logger                *zap.Logger
+	ethClient             *ethclient.Client
+	transactionRepository *repository.TransactionRepository
+}
+
+func NewTracker(rpcURL string, logger *zap.Logger, transactionRepository *repository.TransactionRepository) (*Tracker, error) {
+	ethClient, err := ethclient.Dial(rpcURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Ethereum client: %w", err)
+	}
+
+	return &Tracker{
+		logger:                logger,
+		ethClient:             ethClient,
+		transactionRepository: transactionRepository,
+	}, nil
+}
+
+func (t *Tracker) Start() {
+	t.logger.Info("Starting Transaction Tracker...")
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := t.checkPending(); err != nil {
+			t.logger.Error("Error tracking relayed transactions", zap.Error(err))
+		}
+	}
+}
+
+func (t *Tracker) checkPending() error {
+	transactions, err := t.transactionRepository.ListPendingRelayedTransactions(batchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, transaction := range transactions {
+		if err := t.check(transaction); err != nil {
+			t.logger.Warn("Failed to check relayed transaction", zap.String("tx_hash", transaction.TxHash), zap.Error(err))
+		}
+	}
+
+	return nil
+}
+
+// check resolves a pending transaction if it was mined, or if it can no longer be: its nonce was used by
+// another transaction, or the node has forgotten it for longer than DropTimeout
+func (t *Tracker) check(transaction model.RelayedTransaction) error {
+	ctx := context.Background()
+	txHash := common.HexToHash(transaction.TxHash)
+
+	receipt, err := t.receipt(ctx, txHash)
+	if err != nil {
+		return err
+	}
+	if receipt != nil {
+		return t.resolveMined(transaction, receipt)
+	}
+
+	if _, _, err := t.ethClient.TransactionByHash(ctx, txHash); err == nil {
+		return t.transactionRepository.MarkRelayedTransactionSeen(transaction.TxHash)
+	} else if !errors.Is(err, ethereum.NotFound) {
+		return fmt.Errorf("failed to get transaction: %w", err)
+	}
+
+	// The node no longer knows of the transaction; if the wallet's nonce moved past it, another
+	// transaction took its place
+	nonce, err := t.ethClient.NonceAt(ctx, common.HexToAddress(transaction.WalletAddress), nil)
+	if err != nil {
+		return fmt.Errorf("failed to get nonce from blockchain: %w", err)
+	}
+	if nonce > transaction.Nonce {
+		// The transaction itself may have been mined since its receipt was looked up
+		receipt, err := t.receipt(ctx, txHash)
+		if err != nil {
+			return err
+		}
+		if receipt != nil {
+			return t.resolveMined(transaction, receipt)
+		}
+		return t.resolve(transaction, model.TransactionReplaced, nil, "nonce was used by another transaction")
+	}
+
+	if time.Since(transaction.LastSeenAt) > DropTimeout {
+		return t.resolve(transaction, model.TransactionDropped, nil, "transaction left the mempool without being mined")
+	}
+
+	return nil
+}
+
+// receipt returns the transaction's receipt, or nil if it has not been mined
+func (t *Tracker) receipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	receipt, err := t.ethClient.TransactionReceipt(ctx, txHash)
+	if err != nil {
+		if errors.Is(err, ethereum.NotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get transaction receipt: %w", err)
+	}
+	return receipt, nil
+}
+
+func (t *Tracker) resolveMined(transaction model.RelayedTransaction, receipt *types.Receipt) error {
+	blockNumber := receipt.BlockNumber.Uint64()
+	if receipt.Status == types.ReceiptStatusSuccessful {
+		return t.resolve(transaction, model.TransactionConfirmed, &blockNumber, "")
+	}
+	return t.resolve(transaction, model.TransactionReverted, &blockNumber, "transaction reverted on chain")
+}
+
+func (t *Tracker) resolve(transaction model.RelayedTransaction, status string, blockNumber *uint64, reason string) error {
+	var failureReason *string
+	if reason != "" {
+		failureReason = &reason
+	}
+
+	if err := t.transactionRepository.ResolveRelayedTransaction(transaction.TxHash, status, blockNumber, failureReason); err != nil {
+		return err
+	}
+
+	t.logger.Info("Relayed transaction resolved",
+		zap.String("tx_hash", transaction.TxHash),
+		zap.String("wallet_address", transaction.WalletAddress),
+		zap.String("status", status))
+	return nil
+}
@@ -2,26 +2,76 @@ package transfer_materializer
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math/big"
 	"strings"
+	"time"
 
 	"github.com/confluentinc/confluent-kafka-go/kafka"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"go.uber.org/zap"
+	"yield/apps/yield/internal/amount"
+	"yield/apps/yield/internal/assets"
 	"yield/apps/yield/internal/events"
 	"yield/apps/yield/internal/model"
+	"yield/apps/yield/internal/order_stream"
 	"yield/apps/yield/internal/repository"
 )
 
+const (
+	// Backoff between attempts to process a message that failed, doubling up to the maximum
+	retryBackoff    = time.Second
+	maxRetryBackoff = time.Minute
+)
+
+// errInvalidEvent marks an event missing or carrying malformed data that its order needs
+var errInvalidEvent = errors.New("invalid transfer event")
+
+// permanentError is returned for an event that failed in a way no retry can fix: invalid event data, or a
+// write the projection's constraints reject
+type permanentError struct {
+	event events.TransferEvent
+	err   error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+
+func (e *permanentError) Unwrap() error { return e.err }
+
+// isPermanent reports whether applying an event failed for a reason retrying cannot fix. Postgres data
+// exceptions (class 22) and integrity constraint violations (class 23) fail the same way every time.
+func isPermanent(err error) bool {
+	if errors.Is(err, errInvalidEvent) {
+		return true
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		class := pqErr.Code.Class()
+		return class == "22" || class == "23"
+	}
+	return false
+}
+
 type TransferMaterializer struct {
 	logger          *zap.Logger
 	kafkaConsumer   *kafka.Consumer
 	orderRepository *repository.OrderRepository
+	orderBroker     *order_stream.Broker // Optional: receives every order change for streaming
 	kafkaTopic      string
+	parkEarlyEvents bool // Hold events that arrive ahead of an earlier event for the same wallet
 }
 
-func NewTransferMaterializer(kafkaBroker, kafkaTopic string, logger *zap.Logger, orderRepository *repository.OrderRepository) (*TransferMaterializer, error) {
+// orderChange is an order saved while applying an event, published to streaming clients once the
+// event's transaction has committed
+type orderChange struct {
+	order      model.Order
+	updateType string
+}
+
+func NewTransferMaterializer(kafkaBroker, kafkaTopic string, logger *zap.Logger, orderRepository *repository.OrderRepository, orderBroker *order_stream.Broker) (*TransferMaterializer, error) {
 	// Setup Kafka consumer
 	consumer, err := kafka.NewConsumer(&kafka.ConfigMap{
 		"bootstrap.servers": kafkaBroker,
@@ -36,10 +86,23 @@ func NewTransferMaterializer(kafkaBroker, kafkaTopic string, logger *zap.Logger,
 		logger:          logger,
 		kafkaConsumer:   consumer,
 		orderRepository: orderRepository,
+		orderBroker:     orderBroker,
 		kafkaTopic:      kafkaTopic,
+		parkEarlyEvents: true,
 	}, nil
 }
 
+// NewReplayMaterializer creates a materializer without a Kafka consumer. Events are fed to it through
+// ProcessEvent, which lets the projection rebuild reuse the exact same materialization logic. A replay
+// feeds events in chain order, so none are parked, and order changes are not published to streaming
+// clients.
+func NewReplayMaterializer(logger *zap.Logger, orderRepository *repository.OrderRepository) *TransferMaterializer {
+	return &TransferMaterializer{
+		logger:          logger,
+		orderRepository: orderRepository,
+	}
+}
+
 func (tm *TransferMaterializer) Start() error {
 	tm.logger.Info("Starting Transfer Materializer...")
 
@@ -57,23 +120,195 @@ func (tm *TransferMaterializer) Start() error {
 			continue
 		}
 
-		if err := tm.processMessage(msg); err != nil {
-			tm.logger.Error("Error processing message",
+		// A message that cannot be parsed never will be, and an event that fails permanently is dead-lettered.
+		// Anything else is retried until it succeeds, so that the consumer never moves past an event that was
+		// not applied.
+		var transferEvent events.TransferEvent
+		if err := json.Unmarshal(msg.Value, &transferEvent); err != nil {
+			tm.logger.Error("Skipping malformed transfer event",
+				zap.String("topic", *msg.TopicPartition.Topic),
+				zap.Int32("partition", msg.TopicPartition.Partition),
+				zap.String("key", string(msg.Key)),
+				zap.Error(err))
+			continue
+		}
+
+		backoff := retryBackoff
+		for {
+			err := tm.ProcessEvent(transferEvent)
+			if err == nil {
+				break
+			}
+
+			// The failed event may be this one or a parked event applied after it. Once it is set aside,
+			// processing again applies whatever it was holding back.
+			var permanentErr *permanentError
+			if errors.As(err, &permanentErr) {
+				if err = tm.deadLetter(permanentErr.event, permanentErr.err); err == nil {
+					continue
+				}
+			}
+
+			tm.logger.Error("Error processing message, retrying",
 				zap.String("topic", *msg.TopicPartition.Topic),
 				zap.Int32("partition", msg.TopicPartition.Partition),
 				zap.String("key", string(msg.Key)),
+				zap.Duration("backoff", backoff),
 				zap.Error(err))
+
+			time.Sleep(backoff)
+			backoff = min(backoff*2, maxRetryBackoff)
+		}
+	}
+}
+
+// ProcessEvent applies a single transfer event to the orders projection. Events for a wallet must be
+// applied in (block_number, log_index) order, since applying e.g. a withdrawal_completed ahead of its
+// withdrawal_requested would create an orphan completed withdrawal. An event at or behind the wallet's
+// watermark has already been applied and is skipped. An event that arrives while an earlier event for
+// the wallet is still on its way is parked, and applied as soon as the earlier event has been.
+func (tm *TransferMaterializer) ProcessEvent(transferEvent events.TransferEvent) error {
+	parked, err := tm.processEvent(transferEvent)
+	if err != nil || parked {
+		return err
+	}
+
+	return tm.applyParkedEvents(transferEvent.WalletAddress)
+}
+
+// processEvent applies, skips or parks an event, and reports whether it was left parked
+func (tm *TransferMaterializer) processEvent(transferEvent events.TransferEvent) (bool, error) {
+	blockNumber, logIndex, found, err := tm.orderRepository.GetWalletWatermark(transferEvent.WalletAddress)
+	if err != nil {
+		return false, err
+	}
+
+	if found && (transferEvent.BlockNumber < blockNumber || (transferEvent.BlockNumber == blockNumber && transferEvent.LogIndex <= logIndex)) {
+		tm.logger.Info("Skipping already applied transfer event",
+			zap.String("event_type", transferEvent.EventType),
+			zap.String("tx_hash", transferEvent.TxHash),
+			zap.Uint64("log_index", transferEvent.LogIndex),
+			zap.String("wallet_address", transferEvent.WalletAddress))
+		return false, tm.orderRepository.DeleteParkedEvent(transferEvent.WalletAddress, transferEvent.BlockNumber, transferEvent.LogIndex)
+	}
+
+	if tm.parkEarlyEvents {
+		early, err := tm.isEarly(transferEvent, blockNumber, logIndex, found)
+		if err != nil {
+			return false, err
+		}
+		if early {
+			return true, tm.parkEvent(transferEvent)
+		}
+	}
+
+	var change orderChange
+	err = tm.orderRepository.WithTransaction(func(orderRepository *repository.OrderRepository) error {
+		var err error
+		if change, err = tm.applyEvent(orderRepository, transferEvent); err != nil {
+			return err
+		}
+		if err := orderRepository.AdvanceWalletWatermark(transferEvent.WalletAddress, transferEvent.BlockNumber, transferEvent.LogIndex); err != nil {
+			return err
+		}
+		return orderRepository.DeleteParkedEvent(transferEvent.WalletAddress, transferEvent.BlockNumber, transferEvent.LogIndex)
+	})
+	if err != nil && isPermanent(err) {
+		return false, &permanentError{event: transferEvent, err: err}
+	}
+	if err != nil {
+		return false, err
+	}
+
+	tm.publish(change)
+	return false, nil
+}
+
+// isEarly reports whether the outbox holds an event for the wallet between its watermark and this event
+func (tm *TransferMaterializer) isEarly(transferEvent events.TransferEvent, blockNumber, logIndex uint64, found bool) (bool, error) {
+	if !found {
+		// Wallets materialized before watermarks were introduced have orders but no watermark, and their
+		// earlier events have been applied
+		hasOrders, err := tm.orderRepository.HasOrders(transferEvent.WalletAddress)
+		if err != nil || hasOrders {
+			return false, err
+		}
+	}
+
+	return tm.orderRepository.HasOutboxEventsBetween(transferEvent.WalletAddress, blockNumber, logIndex, transferEvent.BlockNumber, transferEvent.LogIndex)
+}
+
+func (tm *TransferMaterializer) parkEvent(transferEvent events.TransferEvent) error {
+	eventJSON, err := json.Marshal(transferEvent)
+	if err != nil {
+		return fmt.Errorf("failed to marshal transfer event: %w", err)
+	}
+
+	if err := tm.orderRepository.ParkEvent(transferEvent.WalletAddress, transferEvent.BlockNumber, transferEvent.LogIndex, eventJSON); err != nil {
+		return err
+	}
+
+	tm.logger.Warn("Parked transfer event until earlier events for the wallet are applied",
+		zap.String("event_type", transferEvent.EventType),
+		zap.String("tx_hash", transferEvent.TxHash),
+		zap.Uint64("log_index", transferEvent.LogIndex),
+		zap.String("wallet_address", transferEvent.WalletAddress))
+	return nil
+}
+
+// deadLetter sets aside an event that can never be applied. It is recorded for inspection, and the
+// wallet's watermark moves past it so that the wallet's later events are no longer held back by it.
+func (tm *TransferMaterializer) deadLetter(transferEvent events.TransferEvent, cause error) error {
+	eventJSON, err := json.Marshal(transferEvent)
+	if err != nil {
+		return fmt.Errorf("failed to marshal transfer event: %w", err)
+	}
+
+	err = tm.orderRepository.WithTransaction(func(orderRepository *repository.OrderRepository) error {
+		if err := orderRepository.DeadLetterEvent(transferEvent.WalletAddress, transferEvent.TxHash, transferEvent.LogIndex, transferEvent.EventType, eventJSON, cause.Error()); err != nil {
+			return err
 		}
+		if err := orderRepository.AdvanceWalletWatermark(transferEvent.WalletAddress, transferEvent.BlockNumber, transferEvent.LogIndex); err != nil {
+			return err
+		}
+		return orderRepository.DeleteParkedEvent(transferEvent.WalletAddress, transferEvent.BlockNumber, transferEvent.LogIndex)
+	})
+	if err != nil {
+		return err
 	}
+
+	tm.logger.Error("Dead-lettered transfer event that cannot be applied",
+		zap.String("event_type", transferEvent.EventType),
+		zap.String("tx_hash", transferEvent.TxHash),
+		zap.Uint64("log_index", transferEvent.LogIndex),
+		zap.String("wallet_address", transferEvent.WalletAddress),
+		zap.Error(cause))
+	return nil
 }
 
-func (tm *TransferMaterializer) processMessage(msg *kafka.Message) error {
-	// Parse the Kafka message
-	var transferEvent events.TransferEvent
-	if err := json.Unmarshal(msg.Value, &transferEvent); err != nil {
-		return fmt.Errorf("failed to unmarshal transfer event: %w", err)
+// applyParkedEvents applies the wallet's parked events in chain order, until one is still waiting for an
+// earlier event
+func (tm *TransferMaterializer) applyParkedEvents(walletAddress string) error {
+	for {
+		parkedEvents, err := tm.orderRepository.GetParkedEvents(walletAddress)
+		if err != nil || len(parkedEvents) == 0 {
+			return err
+		}
+
+		var transferEvent events.TransferEvent
+		if err := json.Unmarshal(parkedEvents[0], &transferEvent); err != nil {
+			return fmt.Errorf("failed to unmarshal parked transfer event: %w", err)
+		}
+
+		parked, err := tm.processEvent(transferEvent)
+		if err != nil || parked {
+			return err
+		}
 	}
+}
 
+// applyEvent materializes an event through the given repository and returns the order it saved
+func (tm *TransferMaterializer) applyEvent(orderRepository *repository.OrderRepository, transferEvent events.TransferEvent) (orderChange, error) {
 	tm.logger.Info("Processing transfer event",
 		zap.String("event_type", transferEvent.EventType),
 		zap.String("tx_hash", transferEvent.TxHash),
@@ -81,17 +316,22 @@ func (tm *TransferMaterializer) processMessage(msg *kafka.Message) error {
 
 	// Handle withdrawal_completed events specially
 	if strings.ToLower(transferEvent.EventType) == "withdrawal_completed" {
-		return tm.processWithdrawalCompleted(transferEvent)
+		return tm.processWithdrawalCompleted(orderRepository, transferEvent)
 	}
 
 	// Handle withdrawal_requested events specially
 	if strings.ToLower(transferEvent.EventType) == "withdrawal_requested" {
-		return tm.processWithdrawalRequested(transferEvent)
+		return tm.processWithdrawalRequested(orderRepository, transferEvent)
 	}
 
 	// Map event type to transfer type and status
 	transferType, status := tm.mapEventToTransferAndStatus(transferEvent.EventType)
 
+	shareAmount, err := tm.calculateShareAmount(transferEvent)
+	if err != nil {
+		return orderChange{}, fmt.Errorf("failed to calculate share amount for %s: %w", transferEvent.EventType, err)
+	}
+
 	// Create or update order
 	order := model.Order{
 		OrderID:         uuid.New().String(),
@@ -106,22 +346,23 @@ func (tm *TransferMaterializer) processMessage(msg *kafka.Message) error {
 		FromAssetName:   transferEvent.FromAssetName,
 		ToAssetName:     transferEvent.ToAssetName,
 		EstimatedAmount: nil, // For deposit events, estimated_amount remains nil
+		ShareAmount:     shareAmount,
 	}
 
-	return tm.orderRepository.UpsertOrder(order)
+	return tm.saveOrder(orderRepository, order, model.OrderCreated)
 }
 
-func (tm *TransferMaterializer) processWithdrawalRequested(transferEvent events.TransferEvent) error {
+func (tm *TransferMaterializer) processWithdrawalRequested(orderRepository *repository.OrderRepository, transferEvent events.TransferEvent) (orderChange, error) {
 	// Calculate estimated amount from event data
-	estimatedAmount, err := tm.calculateEstimatedAmount(transferEvent.EventData, transferEvent.Amount)
+	estimatedAmount, err := tm.calculateEstimatedAmount(transferEvent.EventData, transferEvent.Amount, transferEvent.ToAssetName)
 	if err != nil {
-		return fmt.Errorf("failed to calculate estimated amount for withdrawal request: %w", err)
+		return orderChange{}, fmt.Errorf("failed to calculate estimated amount for withdrawal request: %w", err)
 	}
 
 	// Check if there's already an in_progress withdrawal for this wallet with the same amount
-	existingWithdrawal, err := tm.orderRepository.GetInProgressWithdrawalByWalletAndAmount(transferEvent.WalletAddress, transferEvent.Amount)
+	existingWithdrawal, err := orderRepository.GetInProgressWithdrawalByWalletAndAmount(transferEvent.WalletAddress, transferEvent.Amount)
 	if err != nil {
-		return fmt.Errorf("failed to find existing in_progress withdrawal for wallet %s and amount %s: %w", transferEvent.WalletAddress, transferEvent.Amount, err)
+		return orderChange{}, fmt.Errorf("failed to find existing in_progress withdrawal for wallet %s and amount %s: %w", transferEvent.WalletAddress, transferEvent.Amount, err)
 	}
 
 	if existingWithdrawal != nil {
@@ -134,6 +375,7 @@ func (tm *TransferMaterializer) processWithdrawalRequested(transferEvent events.
 		existingWithdrawal.ToAssetName = transferEvent.ToAssetName
 		// Update estimated amount with new calculation
 		existingWithdrawal.EstimatedAmount = estimatedAmount
+		existingWithdrawal.ShareAmount = &transferEvent.Amount
 
 		tm.logger.Info("Updating existing in_progress withdrawal",
 			zap.String("wallet_address", transferEvent.WalletAddress),
@@ -141,7 +383,7 @@ func (tm *TransferMaterializer) processWithdrawalRequested(transferEvent events.
 			zap.String("new_tx_hash", transferEvent.TxHash),
 			zap.String("amount", transferEvent.Amount))
 
-		return tm.orderRepository.UpsertOrder(*existingWithdrawal)
+		return tm.saveOrder(orderRepository, *existingWithdrawal, model.OrderUpdated)
 	}
 
 	// No existing withdrawal found, create a new one
@@ -158,6 +400,7 @@ func (tm *TransferMaterializer) processWithdrawalRequested(transferEvent events.
 		FromAssetName:   transferEvent.FromAssetName,
 		ToAssetName:     transferEvent.ToAssetName,
 		EstimatedAmount: estimatedAmount,
+		ShareAmount:     &transferEvent.Amount, // The request amount is in shares
 	}
 
 	tm.logger.Info("Creating new withdrawal request",
@@ -165,14 +408,19 @@ func (tm *TransferMaterializer) processWithdrawalRequested(transferEvent events.
 		zap.String("tx_hash", transferEvent.TxHash),
 		zap.String("amount", transferEvent.Amount))
 
-	return tm.orderRepository.UpsertOrder(order)
+	return tm.saveOrder(orderRepository, order, model.OrderCreated)
 }
 
-func (tm *TransferMaterializer) processWithdrawalCompleted(transferEvent events.TransferEvent) error {
+func (tm *TransferMaterializer) processWithdrawalCompleted(orderRepository *repository.OrderRepository, transferEvent events.TransferEvent) (orderChange, error) {
+	shareAmount, err := tm.calculateShareAmount(transferEvent)
+	if err != nil {
+		return orderChange{}, fmt.Errorf("failed to calculate share amount for withdrawal completion: %w", err)
+	}
+
 	// Find the last in_progress withdrawal for this wallet
-	lastWithdrawal, err := tm.orderRepository.GetLastInProgressWithdrawalByWallet(transferEvent.WalletAddress)
+	lastWithdrawal, err := orderRepository.GetLastInProgressWithdrawalByWallet(transferEvent.WalletAddress)
 	if err != nil {
-		return fmt.Errorf("failed to find last in_progress withdrawal for wallet %s: %w", transferEvent.WalletAddress, err)
+		return orderChange{}, fmt.Errorf("failed to find last in_progress withdrawal for wallet %s: %w", transferEvent.WalletAddress, err)
 	}
 
 	if lastWithdrawal == nil {
@@ -193,25 +441,25 @@ func (tm *TransferMaterializer) processWithdrawalCompleted(transferEvent events.
 			FromAssetName:   transferEvent.FromAssetName,
 			ToAssetName:     transferEvent.ToAssetName,
 			EstimatedAmount: estimatedAmount,
+			ShareAmount:     shareAmount,
 		}
 
 		tm.logger.Info("Creating new completed withdrawal order",
 			zap.String("wallet_address", transferEvent.WalletAddress),
 			zap.String("completion_tx_hash", transferEvent.TxHash))
 
-		return tm.orderRepository.UpsertOrder(order)
-	}
-
-	// Update the status of the in_progress withdrawal to completed
-	// Preserve existing estimated_amount, or use the amount if not set
-	if lastWithdrawal.EstimatedAmount == nil {
-		lastWithdrawal.EstimatedAmount = &transferEvent.Amount
+		return tm.saveOrder(orderRepository, order, model.OrderCreated)
 	}
 
+	// Update the status of the in_progress withdrawal to completed. The estimate is replaced by the
+	// want_amount_received, which is less than the estimate when the request was only partly filled.
+	lastWithdrawal.EstimatedAmount = &transferEvent.Amount
 	lastWithdrawal.Status = "completed"
+	lastWithdrawal.ShareAmount = shareAmount
 
-	if err := tm.orderRepository.UpsertOrder(*lastWithdrawal); err != nil {
-		return fmt.Errorf("failed to update withdrawal status to completed: %w", err)
+	change, err := tm.saveOrder(orderRepository, *lastWithdrawal, model.OrderUpdated)
+	if err != nil {
+		return orderChange{}, fmt.Errorf("failed to update withdrawal status to completed: %w", err)
 	}
 
 	tm.logger.Info("Marked withdrawal as completed",
@@ -219,7 +467,37 @@ func (tm *TransferMaterializer) processWithdrawalCompleted(transferEvent events.
 		zap.String("withdrawal_tx_hash", lastWithdrawal.TxHash),
 		zap.String("completion_tx_hash", transferEvent.TxHash))
 
-	return nil
+	return change, nil
+}
+
+// saveOrder upserts a new order, or updates an existing one by its order ID, and returns the change to
+// publish once it has been committed. Updates may move an order to another transaction, so they cannot go
+// through the upsert, which matches orders by transaction.
+func (tm *TransferMaterializer) saveOrder(orderRepository *repository.OrderRepository, order model.Order, updateType string) (orderChange, error) {
+	save := orderRepository.UpsertOrder
+	if updateType == model.OrderUpdated {
+		save = orderRepository.UpdateOrder
+	}
+	if err := save(order); err != nil {
+		return orderChange{}, err
+	}
+
+	return orderChange{order: order, updateType: updateType}, nil
+}
+
+// publish sends a committed order change to streaming clients
+func (tm *TransferMaterializer) publish(change orderChange) {
+	if tm.orderBroker == nil {
+		return
+	}
+
+	if err := tm.orderBroker.Publish(change.order, change.updateType); err != nil {
+		// The order itself is saved; only the notification is lost
+		tm.logger.Error("Failed to publish order update",
+			zap.String("order_id", change.order.OrderID),
+			zap.String("update_type", change.updateType),
+			zap.Error(err))
+	}
 }
 
 func (tm *TransferMaterializer) mapEventToTransferAndStatus(eventType string) (transferType, status string) {
@@ -230,57 +508,98 @@ func (tm *TransferMaterializer) mapEventToTransferAndStatus(eventType string) (t
 		return "withdrawal", "in_progress"
 	case "withdrawal_completed":
 		return "withdrawal", "completed"
+	case "transfer_in":
+		return "transfer_in", "completed"
+	case "transfer_out":
+		return "transfer_out", "completed"
 	default:
 		tm.logger.Warn("Unknown event type", zap.String("event_type", eventType))
 		return eventType, "unknown"
 	}
 }
 
-func (tm *TransferMaterializer) calculateEstimatedAmount(eventData json.RawMessage, amount string) (*string, error) {
+func (tm *TransferMaterializer) calculateEstimatedAmount(eventData json.RawMessage, amount string, wantAssetName string) (*string, error) {
+	// min_price is in the smallest units of the withdrawn asset, so it cannot be priced without its decimals
+	wantAsset, exists := assets.GlobalRegistry.GetBySymbol(wantAssetName)
+	if !exists {
+		tm.logger.Warn("Withdrawn asset is not in the asset registry, setting estimated_amount to NULL",
+			zap.String("asset", wantAssetName))
+		return nil, nil
+	}
+
 	// Parse the event blob to extract min_price
 	var eventMap map[string]interface{}
 	if err := json.Unmarshal(eventData, &eventMap); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal event data: %w", err)
+		return nil, fmt.Errorf("%w: failed to unmarshal event data: %v", errInvalidEvent, err)
 	}
 
 	minPriceStr, ok := eventMap["min_price"].(string)
 	if !ok {
-		return nil, fmt.Errorf("min_price not found in event data")
+		return nil, fmt.Errorf("%w: min_price not found in event data", errInvalidEvent)
 	}
 
-	// Convert strings to big.Float for decimal calculation
-	amountFloat, ok := new(big.Float).SetString(amount)
+	// Parse both as exact rationals; big.Float rounds to 64 bits of precision
+	amountRat, ok := new(big.Rat).SetString(amount)
 	if !ok {
-		return nil, fmt.Errorf("failed to parse amount: %s", amount)
+		return nil, fmt.Errorf("%w: failed to parse amount: %s", errInvalidEvent, amount)
 	}
 
-	minPriceFloat, ok := new(big.Float).SetString(minPriceStr)
+	minPrice, ok := new(big.Int).SetString(minPriceStr, 10)
 	if !ok {
-		return nil, fmt.Errorf("failed to parse min_price: %s", minPriceStr)
+		return nil, fmt.Errorf("%w: failed to parse min_price: %s", errInvalidEvent, minPriceStr)
 	}
 
 	// Check for division by zero - return nil (NULL) for zero min_price
-	if minPriceFloat.Cmp(big.NewFloat(0)) == 0 {
-		tm.logger.Warn("min_price is zero, setting estimated_amount to NULL", 
+	if minPrice.Sign() == 0 {
+		tm.logger.Warn("min_price is zero, setting estimated_amount to NULL",
 			zap.String("amount", amount),
 			zap.String("min_price", minPriceStr))
 		return nil, nil
 	}
 
-	// Calculate estimated_amount = (min_price × amount) ÷ (10^8)
-	// First multiply min_price by amount
-	numeratorFloat := new(big.Float).Mul(minPriceFloat, amountFloat)
-	
-	// Create 10^8 as divisor
-	divisorFloat := new(big.Float).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(8), nil))
-	
-	// Divide by 10^8
-	estimatedAmountFloat := new(big.Float).Quo(numeratorFloat, divisorFloat)
-	estimatedAmountStr := estimatedAmountFloat.Text('f', 18) // Use fixed-point notation with 18 decimal places
+	// Calculate estimated_amount = (min_price × amount) ÷ (10^decimals)
+	divisor := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(wantAsset.Decimals)), nil)
+	estimatedAmount := new(big.Rat).Mul(amountRat, new(big.Rat).SetFrac(minPrice, divisor))
+	estimatedAmountStr := estimatedAmount.FloatString(18) // Fixed-point notation with 18 decimal places
 
 	return &estimatedAmountStr, nil
 }
 
+// calculateShareAmount returns the LBTCv shares an event added to or removed from the wallet. Deposits and
+// fulfilled withdrawals carry them in the event data; withdrawal requests and share transfers are
+// denominated in shares already.
+func (tm *TransferMaterializer) calculateShareAmount(transferEvent events.TransferEvent) (*string, error) {
+	var field string
+	switch strings.ToLower(transferEvent.EventType) {
+	case "deposit":
+		field = "share_amount"
+	case "withdrawal_completed":
+		field = "offer_amount_spent"
+	default:
+		return &transferEvent.Amount, nil
+	}
+
+	var eventMap map[string]interface{}
+	if err := json.Unmarshal(transferEvent.EventData, &eventMap); err != nil {
+		return nil, fmt.Errorf("%w: failed to unmarshal event data: %v", errInvalidEvent, err)
+	}
+
+	raw, ok := eventMap[field].(string)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s not found in event data", errInvalidEvent, field)
+	}
+
+	units, ok := new(big.Int).SetString(raw, 10)
+	if !ok {
+		return nil, fmt.Errorf("%w: failed to parse %s: %s", errInvalidEvent, field, raw)
+	}
+
+	// Both fields are in LBTCv shares
+	lbtcvAsset, _ := assets.GlobalRegistry.GetBySymbol("LBTCv")
+	shareAmount := amount.Format(units, lbtcvAsset.Decimals)
+	return &shareAmount, nil
+}
+
 func (tm *TransferMaterializer) Close() error {
 	if tm.kafkaConsumer != nil {
 		return tm.kafkaConsumer.Close()
@@ -0,0 +1,217 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+	"go.uber.org/zap"
+	"yield/apps/yield/internal/events"
+	"yield/apps/yield/internal/model"
+	"yield/apps/yield/internal/repository"
+)
+
+// ErrUnknownEventType is returned for transfer events that have no webhook event type
+var ErrUnknownEventType = errors.New("unknown webhook event type")
+
+const (
+	// Backoff between attempts to dispatch a message that failed, doubling up to the maximum
+	dispatchRetryBackoff    = time.Second
+	maxDispatchRetryBackoff = time.Minute
+)
+
+// Event is the JSON body POSTed to webhook subscribers
+type Event struct {
+	ID        string    `json:"id"` // <tx_hash>:<log_index>:<type>, stable across retries
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      EventData `json:"data"`
+}
+
+// EventData describes the order change behind a webhook event
+type EventData struct {
+	WalletAddress string    `json:"wallet_address"`
+	TxHash        string    `json:"tx_hash"`
+	LogIndex      uint64    `json:"log_index"`
+	BlockNumber   uint64    `json:"block_number"`
+	TxDate        time.Time `json:"tx_date"`
+	TransferType  string    `json:"transfer_type"`
+	Status        string    `json:"status"`
+	Amount        string    `json:"amount"`
+	FromAssetName string    `json:"from_asset_name"`
+	ToAssetName   string    `json:"to_asset_name"`
+}
+
+// Dispatcher consumes the transfer events topic with its own consumer group, independently of the
+// TransferMaterializer, and enqueues a delivery for every subscription matching each event. Deliveries
+// are sent by the DeliveryWorker.
+type Dispatcher struct {
+	logger            *zap.Logger
+	kafkaConsumer     *kafka.Consumer
+	webhookRepository *repository.WebhookRepository
+	kafkaTopic        string
+}
+
+func NewDispatcher(kafkaBroker, kafkaTopic string, logger *zap.Logger, webhookRepository *repository.WebhookRepository) (*Dispatcher, error) {
+	consumer, err := kafka.NewConsumer(&kafka.ConfigMap{
+		"bootstrap.servers": kafkaBroker,
+		"group.id":          "webhook-dispatcher",
+		// A new consumer group must not replay the whole topic history to subscribers
+		"auto.offset.reset":  "latest",
+		"enable.auto.commit": false,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kafka consumer: %w", err)
+	}
+
+	return &Dispatcher{
+		logger:            logger,
+		kafkaConsumer:     consumer,
+		webhookRepository: webhookRepository,
+		kafkaTopic:        kafkaTopic,
+	}, nil
+}
+
+func (d *Dispatcher) Start() error {
+	d.logger.Info("Starting Webhook Dispatcher...")
+
+	if err := d.kafkaConsumer.Subscribe(d.kafkaTopic, nil); err != nil {
+		return fmt.Errorf("failed to subscribe to topic %s: %w", d.kafkaTopic, err)
+	}
+
+	for {
+		msg, err := d.kafkaConsumer.ReadMessage(-1)
+		if err != nil {
+			d.logger.Error("Error reading message from Kafka", zap.Error(err))
+			continue
+		}
+
+		d.processMessage(msg)
+
+		// The message has been dispatched or can never be, so the offset can move past it
+		if _, err := d.kafkaConsumer.CommitMessage(msg); err != nil {
+			d.logger.Error("Failed to commit webhook dispatcher offset", zap.Error(err))
+		}
+	}
+}
+
+// processMessage dispatches a message, retrying until its deliveries are enqueued. Committing a later
+// offset on the partition would skip a message that failed, so the consumer does not move on until it
+// has succeeded. Only messages that can never be dispatched are skipped.
+func (d *Dispatcher) processMessage(msg *kafka.Message) {
+	var transferEvent events.TransferEvent
+	if err := json.Unmarshal(msg.Value, &transferEvent); err != nil {
+		d.logger.Error("Skipping malformed transfer event",
+			zap.Int32("partition", msg.TopicPartition.Partition),
+			zap.String("key", string(msg.Key)),
+			zap.Error(err))
+		return
+	}
+
+	backoff := dispatchRetryBackoff
+	for {
+		err := d.Dispatch(transferEvent)
+		if err == nil {
+			return
+		}
+
+		if errors.Is(err, ErrUnknownEventType) {
+			d.logger.Error("Skipping transfer event without a webhook event type",
+				zap.String("tx_hash", transferEvent.TxHash),
+				zap.Uint64("log_index", transferEvent.LogIndex),
+				zap.Error(err))
+			return
+		}
+
+		d.logger.Error("Error dispatching webhooks, retrying",
+			zap.Int32("partition", msg.TopicPartition.Partition),
+			zap.String("key", string(msg.Key)),
+			zap.Duration("backoff", backoff),
+			zap.Error(err))
+
+		time.Sleep(backoff)
+		backoff = min(backoff*2, maxDispatchRetryBackoff)
+	}
+}
+
+// Dispatch enqueues a delivery of the transfer event to every matching subscription
+func (d *Dispatcher) Dispatch(transferEvent events.TransferEvent) error {
+	event, err := NewEvent(transferEvent)
+	if err != nil {
+		return err
+	}
+	eventType := event.Type
+
+	subscriptions, err := d.webhookRepository.GetMatchingSubscriptions(transferEvent.WalletAddress, eventType)
+	if err != nil {
+		return err
+	}
+	if len(subscriptions) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook event: %w", err)
+	}
+
+	for _, subscription := range subscriptions {
+		if err := d.webhookRepository.EnqueueDelivery(subscription.ID, eventType, transferEvent.TxHash, transferEvent.LogIndex, payload); err != nil {
+			return err
+		}
+	}
+
+	d.logger.Info("Enqueued webhook deliveries",
+		zap.String("event_type", eventType),
+		zap.String("tx_hash", transferEvent.TxHash),
+		zap.Int("subscriptions", len(subscriptions)))
+	return nil
+}
+
+// NewEvent builds the webhook body for a transfer event. Event types without a webhook event type return
+// ErrUnknownEventType.
+func NewEvent(transferEvent events.TransferEvent) (Event, error) {
+	eventType := strings.ToLower(transferEvent.EventType)
+
+	var transferType, status string
+	switch eventType {
+	case model.WebhookEventDeposit:
+		transferType, status = "deposit", "completed"
+	case model.WebhookEventWithdrawalRequested:
+		transferType, status = "withdrawal", "in_progress"
+	case model.WebhookEventWithdrawalCompleted:
+		transferType, status = "withdrawal", "completed"
+	case model.WebhookEventTransferIn, model.WebhookEventTransferOut:
+		transferType, status = eventType, "completed"
+	default:
+		return Event{}, fmt.Errorf("%w: %s", ErrUnknownEventType, transferEvent.EventType)
+	}
+
+	return Event{
+		ID:        fmt.Sprintf("%s:%d:%s", transferEvent.TxHash, transferEvent.LogIndex, eventType),
+		Type:      eventType,
+		CreatedAt: time.Now().UTC(),
+		Data: EventData{
+			WalletAddress: transferEvent.WalletAddress,
+			TxHash:        transferEvent.TxHash,
+			LogIndex:      transferEvent.LogIndex,
+			BlockNumber:   transferEvent.BlockNumber,
+			TxDate:        transferEvent.TxDate,
+			TransferType:  transferType,
+			Status:        status,
+			Amount:        transferEvent.Amount,
+			FromAssetName: transferEvent.FromAssetName,
+			ToAssetName:   transferEvent.ToAssetName,
+		},
+	}, nil
+}
+
+func (d *Dispatcher) Close() error {
+	if d.kafkaConsumer != nil {
+		return d.kafkaConsumer.Close()
+	}
+	return nil
+}
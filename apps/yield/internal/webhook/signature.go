@@ -0,0 +1,40 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// Headers sent with every webhook request
+const (
+	HeaderSignature = "X-Yield-Signature"
+	HeaderTimestamp = "X-Yield-Timestamp"
+	HeaderEvent     = "X-Yield-Event"
+	HeaderDelivery  = "X-Yield-Delivery"
+)
+
+// SignatureTolerance is how far a request's timestamp may be from the receiver's clock for Verify to
+// accept it
+const SignatureTolerance = 5 * time.Minute
+
+// Sign computes the X-Yield-Signature header value for a request body. The signed message is
+// "<timestamp>.<body>" so that a captured request cannot be replayed with a fresh timestamp.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether a signature header value matches the body, using a constant time comparison,
+// and the timestamp is within SignatureTolerance of now
+func Verify(secret string, timestamp int64, body []byte, signature string) bool {
+	age := time.Since(time.Unix(timestamp, 0))
+	if age > SignatureTolerance || age < -SignatureTolerance {
+		return false
+	}
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
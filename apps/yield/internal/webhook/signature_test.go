@@ -0,0 +1,61 @@
+package webhook
+
+import (
+	"testing"
+	"time"
+)
+
+const testSecret = "whsec-test-secret-0123"
+
+var testBody = []byte(`{"id":"0xabc:1","type":"deposit"}`)
+
+func TestSignKnownVector(t *testing.T) {
+	// HMAC-SHA256 of "1700000000.<body>", computed independently
+	expected := "sha256=ccb51915b1801400519ffeb66dcafdbdb69879ad8c746e5ce97ba38341d29861"
+
+	if signature := Sign(testSecret, 1700000000, testBody); signature != expected {
+		t.Errorf("Expected signature %s, got %s", expected, signature)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Now().Unix()
+	signature := Sign(testSecret, now, testBody)
+
+	if !Verify(testSecret, now, testBody, signature) {
+		t.Error("Expected a fresh signature to verify")
+	}
+
+	t.Run("TamperedBody", func(t *testing.T) {
+		tampered := []byte(`{"id":"0xabc:1","type":"withdrawal_completed"}`)
+		if Verify(testSecret, now, tampered, signature) {
+			t.Error("Expected a tampered body to fail verification")
+		}
+	})
+
+	t.Run("WrongSecret", func(t *testing.T) {
+		if Verify("another-secret-0123", now, testBody, signature) {
+			t.Error("Expected a different secret to fail verification")
+		}
+	})
+
+	t.Run("ReplayedWithNewTimestamp", func(t *testing.T) {
+		if Verify(testSecret, now+1, testBody, signature) {
+			t.Error("Expected the signature to be bound to its timestamp")
+		}
+	})
+
+	t.Run("StaleTimestamp", func(t *testing.T) {
+		stale := time.Now().Add(-SignatureTolerance - time.Minute).Unix()
+		if Verify(testSecret, stale, testBody, Sign(testSecret, stale, testBody)) {
+			t.Error("Expected a stale timestamp to fail verification")
+		}
+	})
+
+	t.Run("FutureTimestamp", func(t *testing.T) {
+		future := time.Now().Add(SignatureTolerance + time.Minute).Unix()
+		if Verify(testSecret, future, testBody, Sign(testSecret, future, testBody)) {
+			t.Error("Expected a timestamp too far ahead to fail verification")
+		}
+	})
+}
@@ -0,0 +1,108 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+var (
+	// ErrInsecureURL is returned for webhook URLs that are not absolute https URLs
+	ErrInsecureURL = errors.New("webhook URL must be an absolute https URL")
+
+	// ErrDisallowedTarget is returned for webhook URLs that resolve to a loopback, private, link-local or
+	// otherwise non-public address, which would let a subscriber reach internal services
+	ErrDisallowedTarget = errors.New("webhook URL must resolve to a public address")
+)
+
+// Address ranges that are not covered by the net.IP classifiers but are not publicly routable either
+var reservedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),     // "This" network
+	netip.MustParsePrefix("100.64.0.0/10"), // Carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),  // IETF protocol assignments
+	netip.MustParsePrefix("198.18.0.0/15"), // Benchmarking
+	netip.MustParsePrefix("240.0.0.0/4"),   // Reserved, including broadcast
+}
+
+// How long resolving a webhook host may take when a subscription is registered
+const resolveTimeout = 5 * time.Second
+
+// ValidateURL checks that a webhook URL is an absolute https URL whose host only resolves to public
+// addresses. Deliveries check the address again when they connect, since DNS can change afterwards.
+func ValidateURL(rawURL string) error {
+	parsedURL, err := url.ParseRequestURI(rawURL)
+	if err != nil || parsedURL.Scheme != "https" || parsedURL.Hostname() == "" {
+		return ErrInsecureURL
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), resolveTimeout)
+	defer cancel()
+
+	addresses, err := net.DefaultResolver.LookupNetIP(ctx, "ip", parsedURL.Hostname())
+	if err != nil {
+		return fmt.Errorf("%w: failed to resolve %s: %v", ErrDisallowedTarget, parsedURL.Hostname(), err)
+	}
+
+	for _, address := range addresses {
+		if !isPublicAddress(address) {
+			return fmt.Errorf("%w: %s resolves to %s", ErrDisallowedTarget, parsedURL.Hostname(), address)
+		}
+	}
+
+	return nil
+}
+
+func isPublicAddress(address netip.Addr) bool {
+	address = address.Unmap()
+	if !address.IsValid() || address.IsLoopback() || address.IsPrivate() || address.IsUnspecified() ||
+		address.IsLinkLocalUnicast() || address.IsLinkLocalMulticast() || address.IsInterfaceLocalMulticast() ||
+		address.IsMulticast() {
+		return false
+	}
+
+	for _, prefix := range reservedPrefixes {
+		if prefix.Contains(address) {
+			return false
+		}
+	}
+	return true
+}
+
+// newDeliveryClient returns an HTTP client that refuses to connect to non-public addresses, whatever the
+// subscription's host resolves to at delivery time. Redirects are not followed, and a redirect response
+// counts as a failed delivery.
+func newDeliveryClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: deliveryTimeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return fmt.Errorf("%w: %s", ErrDisallowedTarget, address)
+			}
+			if !isPublicAddress(addrPort.Addr()) {
+				return fmt.Errorf("%w: %s", ErrDisallowedTarget, addrPort.Addr())
+			}
+			return nil
+		},
+	}
+
+	return &http.Client{
+		Timeout: deliveryTimeout,
+		Transport: &http.Transport{
+			// No proxy, so that the address checked at dial time is the subscriber's
+			Proxy:               nil,
+			DialContext:         dialer.DialContext,
+			ForceAttemptHTTP2:   true,
+			TLSHandshakeTimeout: deliveryTimeout,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
@@ -0,0 +1,65 @@
+package webhook
+
+import (
+	"errors"
+	"net/netip"
+	"testing"
+)
+
+func TestValidateURL(t *testing.T) {
+	tests := []struct {
+		url      string
+		expected error
+	}{
+		{"http://93.184.215.14/hooks", ErrInsecureURL},
+		{"ftp://93.184.215.14/hooks", ErrInsecureURL},
+		{"/hooks", ErrInsecureURL},
+		{"https://127.0.0.1/hooks", ErrDisallowedTarget},
+		{"https://localhost/hooks", ErrDisallowedTarget},
+		{"https://169.254.169.254/latest/meta-data", ErrDisallowedTarget},
+		{"https://10.0.0.5/hooks", ErrDisallowedTarget},
+		{"https://192.168.1.10:8443/hooks", ErrDisallowedTarget},
+		{"https://[::1]/hooks", ErrDisallowedTarget},
+		{"https://[::ffff:127.0.0.1]/hooks", ErrDisallowedTarget},
+		{"https://93.184.215.14/hooks", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			err := ValidateURL(tt.url)
+			if tt.expected == nil && err != nil {
+				t.Errorf("Expected %s to be accepted, got %v", tt.url, err)
+			}
+			if tt.expected != nil && !errors.Is(err, tt.expected) {
+				t.Errorf("Expected %v for %s, got %v", tt.expected, tt.url, err)
+			}
+		})
+	}
+}
+
+func TestIsPublicAddress(t *testing.T) {
+	tests := map[string]bool{
+		"8.8.8.8":         true,
+		"2606:4700::1111": true,
+		"172.16.0.1":      false,
+		"100.64.0.1":      false,
+		"0.0.0.0":         false,
+		"fd00::1":         false,
+		"fe80::1":         false,
+		"224.0.0.1":       false,
+	}
+
+	for address, expected := range tests {
+		if public := isPublicAddress(netip.MustParseAddr(address)); public != expected {
+			t.Errorf("Expected isPublicAddress(%s) to be %t", address, expected)
+		}
+	}
+}
+
+func TestDeliveryClientRefusesPrivateAddresses(t *testing.T) {
+	// The dial-time check catches hosts that resolved to a public address when they were registered
+	_, err := newDeliveryClient().Get("https://127.0.0.1:1/hooks")
+	if !errors.Is(err, ErrDisallowedTarget) {
+		t.Errorf("Expected the connection to be refused, got %v", err)
+	}
+}
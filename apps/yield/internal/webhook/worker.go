@@ -0,0 +1,166 @@
+package webhook
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+	"yield/apps/yield/internal/model"
+	"yield/apps/yield/internal/repository"
+)
+
+const (
+	// MaxDeliveryAttempts is the number of attempts after which a delivery is marked failed
+	MaxDeliveryAttempts = 8
+
+	// Delay before the first retry, doubled after every failed attempt up to maxRetryDelay
+	initialRetryDelay = 10 * time.Second
+	maxRetryDelay     = time.Hour
+
+	// How often the worker polls for due deliveries, and how many it sends per poll
+	pollInterval      = 2 * time.Second
+	deliveryBatchSize = 20
+
+	// How long a claimed delivery is reserved for the worker that claimed it
+	deliveryLease = time.Minute
+
+	deliveryTimeout = 10 * time.Second
+
+	// Longest response body excerpt stored in the delivery log
+	maxErrorBodyLength = 512
+)
+
+// DeliveryWorker sends pending webhook deliveries, retrying failures with exponential backoff
+type DeliveryWorker struct {
+	logger            *zap.Logger
+	webhookRepository *repository.WebhookRepository
+	httpClient        *http.Client
+}
+
+func NewDeliveryWorker(logger *zap.Logger, webhookRepository *repository.WebhookRepository) *DeliveryWorker {
+	return &DeliveryWorker{
+		logger:            logger,
+		webhookRepository: webhookRepository,
+		httpClient:        newDeliveryClient(),
+	}
+}
+
+func (w *DeliveryWorker) Start() {
+	w.logger.Info("Starting Webhook Delivery Worker...")
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := w.deliverDue(); err != nil {
+			w.logger.Error("Error delivering webhooks", zap.Error(err))
+		}
+	}
+}
+
+func (w *DeliveryWorker) deliverDue() error {
+	deliveries, err := w.webhookRepository.ClaimDueDeliveries(deliveryBatchSize, deliveryLease)
+	if err != nil {
+		return err
+	}
+
+	subscriptions := make(map[string]*model.WebhookSubscription)
+	for _, delivery := range deliveries {
+		subscription, cached := subscriptions[delivery.SubscriptionID]
+		if !cached {
+			subscription, err = w.webhookRepository.GetSubscription(delivery.SubscriptionID)
+			if err != nil {
+				return err
+			}
+			subscriptions[delivery.SubscriptionID] = subscription
+		}
+		if subscription == nil {
+			continue // Deleted since the delivery was claimed; its deliveries went with it
+		}
+
+		w.deliver(delivery, subscription)
+	}
+
+	return nil
+}
+
+// deliver makes a single delivery attempt and records its outcome
+func (w *DeliveryWorker) deliver(delivery model.WebhookDelivery, subscription *model.WebhookSubscription) {
+	responseStatus, err := w.send(delivery, subscription)
+	if err == nil {
+		if err := w.webhookRepository.MarkDeliverySucceeded(delivery.ID, responseStatus); err != nil {
+			w.logger.Error("Failed to record webhook delivery", zap.String("delivery_id", delivery.ID), zap.Error(err))
+		}
+		return
+	}
+
+	attempts := delivery.Attempts + 1
+	giveUp := attempts >= MaxDeliveryAttempts
+
+	var status *int
+	if responseStatus != 0 {
+		status = &responseStatus
+	}
+
+	w.logger.Warn("Webhook delivery attempt failed",
+		zap.String("delivery_id", delivery.ID),
+		zap.String("subscription_id", subscription.ID),
+		zap.Int("attempts", attempts),
+		zap.Bool("giving_up", giveUp),
+		zap.Error(err))
+
+	if err := w.webhookRepository.MarkDeliveryAttemptFailed(delivery.ID, status, err.Error(), time.Now().Add(retryDelay(attempts)), giveUp); err != nil {
+		w.logger.Error("Failed to record webhook delivery attempt", zap.String("delivery_id", delivery.ID), zap.Error(err))
+	}
+}
+
+// send POSTs the signed payload and returns the response status. Any non-2xx response is an error.
+func (w *DeliveryWorker) send(delivery model.WebhookDelivery, subscription *model.WebhookSubscription) (int, error) {
+	// Subscriptions registered before https was required are not delivered to
+	if parsedURL, err := url.Parse(subscription.URL); err != nil || parsedURL.Scheme != "https" {
+		return 0, ErrInsecureURL
+	}
+
+	req, err := http.NewRequest(http.MethodPost, subscription.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "yield-webhooks/1.0")
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderDelivery, delivery.ID)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(subscription.Secret, timestamp, delivery.Payload))
+
+	resp, err := w.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyLength))
+		return resp.StatusCode, fmt.Errorf("unexpected response status %d: %s", resp.StatusCode, string(body))
+	}
+
+	return resp.StatusCode, nil
+}
+
+// retryDelay returns the backoff before the next attempt after the given number of failed attempts
+func retryDelay(attempts int) time.Duration {
+	delay := initialRetryDelay
+	for i := 1; i < attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay
+}
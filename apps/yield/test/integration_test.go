@@ -2,12 +2,22 @@ package test
 
 import (
 	"bytes"
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"io"
+	"math/big"
 	"net/http"
 	"strconv"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 	"yield/apps/yield/internal/assets"
 )
 
@@ -89,8 +99,77 @@ func TestCreateDepositTransaction(t *testing.T) {
 			t.Error("Transaction 'nonce' field should not be empty")
 		}
 
+		// The deposit is always the last of the transactions to send, after an approval if one is needed
+		expectedSteps := 1
+		if depositResp.ApprovalRequired {
+			expectedSteps = 2
+		}
+		if len(depositResp.Transactions) != expectedSteps {
+			t.Fatalf("Expected %d transactions, got %d", expectedSteps, len(depositResp.Transactions))
+		}
+		if depositResp.ApprovalRequired && depositResp.Transactions[0].Type != "approve" {
+			t.Errorf("Expected first transaction to be 'approve', got '%s'", depositResp.Transactions[0].Type)
+		}
+		if last := depositResp.Transactions[len(depositResp.Transactions)-1]; last.Type != "deposit" || last.UnsignedTransaction.Data != unsignedTx.Data {
+			t.Errorf("Expected last transaction to be the deposit, got '%s'", last.Type)
+		}
+
 		t.Logf("✅ Created unsigned transaction: %s", depositResp.UnsignedTransaction)
 	})
+
+	// Test: Create an EIP-1559 deposit transaction
+	t.Run("CreateEIP1559DepositTransaction", func(t *testing.T) {
+		depositReq := DepositRequest{
+			Amount:        TestAmount,
+			FromAssetName: TestFromAsset,
+			WalletAddress: TestWalletAddress,
+			TxType:        "eip1559",
+			FeeUrgency:    "fast",
+		}
+
+		reqBody, err := json.Marshal(depositReq)
+		if err != nil {
+			t.Fatalf("Failed to marshal request: %v", err)
+		}
+
+		resp, err := http.Post(BaseURL+"/api/orders/deposit", "application/json", bytes.NewBuffer(reqBody))
+		if err != nil {
+			t.Fatalf("Failed to make POST request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("Expected status 201, got %d", resp.StatusCode)
+		}
+
+		var depositResp DepositResponse
+		if err := json.NewDecoder(resp.Body).Decode(&depositResp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+
+		var unsignedTx UnsignedTransaction
+		if err := json.Unmarshal([]byte(depositResp.UnsignedTransaction), &unsignedTx); err != nil {
+			t.Fatalf("Failed to parse unsigned transaction: %v", err)
+		}
+
+		if unsignedTx.Type != "0x2" {
+			t.Errorf("Expected type to be '0x2', got '%s'", unsignedTx.Type)
+		}
+
+		if unsignedTx.GasPrice != "" {
+			t.Errorf("EIP-1559 transaction should not carry a gas price, got '%s'", unsignedTx.GasPrice)
+		}
+
+		if unsignedTx.MaxFeePerGas == "" || unsignedTx.MaxPriorityFeePerGas == "" {
+			t.Error("EIP-1559 transaction should carry max_fee_per_gas and max_priority_fee_per_gas")
+		}
+
+		if !strings.HasPrefix(unsignedTx.Serialized, "0x02") {
+			t.Errorf("Expected serialized transaction to start with the 0x02 type byte, got '%s'", unsignedTx.Serialized)
+		}
+
+		t.Logf("✅ Created unsigned EIP-1559 transaction: %s", depositResp.UnsignedTransaction)
+	})
 }
 
 func TestGetOrderByTxHash(t *testing.T) {
@@ -159,6 +238,36 @@ func TestCreateDepositTransactionValidation(t *testing.T) {
 			expectedStatus: http.StatusBadRequest,
 			expectedError:  "missing_wallet_address",
 		},
+		{
+			name: "NegativeAmount",
+			request: DepositRequest{
+				Amount:        "-0.001",
+				FromAssetName: TestFromAsset,
+				WalletAddress: TestWalletAddress,
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "invalid_amount",
+		},
+		{
+			name: "ExponentAmount",
+			request: DepositRequest{
+				Amount:        "1e-3",
+				FromAssetName: TestFromAsset,
+				WalletAddress: TestWalletAddress,
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "invalid_amount",
+		},
+		{
+			name: "AmountTooPrecise",
+			request: DepositRequest{
+				Amount:        "0.000000001",
+				FromAssetName: TestFromAsset,
+				WalletAddress: TestWalletAddress,
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "invalid_amount",
+		},
 		{
 			name: "UnsupportedAsset",
 			request: DepositRequest{
@@ -169,6 +278,29 @@ func TestCreateDepositTransactionValidation(t *testing.T) {
 			expectedStatus: http.StatusBadRequest,
 			expectedError:  "unsupported_asset",
 		},
+		{
+			name: "InvalidTxType",
+			request: DepositRequest{
+				Amount:        TestAmount,
+				FromAssetName: TestFromAsset,
+				WalletAddress: TestWalletAddress,
+				TxType:        "eip2930",
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "invalid_tx_type",
+		},
+		{
+			name: "InvalidFeeUrgency",
+			request: DepositRequest{
+				Amount:        TestAmount,
+				FromAssetName: TestFromAsset,
+				WalletAddress: TestWalletAddress,
+				TxType:        "eip1559",
+				FeeUrgency:    "urgent",
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "invalid_fee_urgency",
+		},
 		{
 			name: "CaseInsensitiveAsset",
 			request: DepositRequest{
@@ -307,6 +439,10 @@ func TestGetWalletBalance(t *testing.T) {
 			t.Errorf("Expected wallet address %s, got %s", TestWalletAddress, balanceResp.WalletAddress)
 		}
 
+		if balanceResp.BlockNumber == 0 {
+			t.Error("Expected the block number the balances were read at")
+		}
+
 		// Expected tokens
 		expectedTokens := []string{"LBTC", "WBTC", "CBTC", "LBTCv"}
 
@@ -339,6 +475,18 @@ func TestGetWalletBalance(t *testing.T) {
 				t.Errorf("Token %s has empty balance", expectedToken)
 			}
 
+			if balance.Allowance == "" {
+				t.Errorf("Token %s has empty allowance", expectedToken)
+			}
+
+			if !common.IsHexAddress(balance.Spender) {
+				t.Errorf("Token %s has invalid spender %s", expectedToken, balance.Spender)
+			}
+
+			if expectedToken == "LBTCv" && balance.Value == "" {
+				t.Errorf("Expected LBTCv balance to include its value")
+			}
+
 			t.Logf("✅ Token %s: Balance=%s, Address=%s, Decimals=%d",
 				balance.Symbol, balance.Balance, balance.Address, balance.Decimals)
 		}
@@ -424,6 +572,253 @@ func TestGetWalletBalanceValidation(t *testing.T) {
 	}
 }
 
+func TestHistoricalWalletBalance(t *testing.T) {
+	getBalance := func(t *testing.T, query string) (*BalanceResponse, bool) {
+		resp, err := http.Get(fmt.Sprintf("%s/api/balance/%s?%s", BaseURL, TestWalletAddress, query))
+		if err != nil {
+			t.Fatalf("Failed to make GET request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			var errorResp ErrorResponse
+			json.NewDecoder(resp.Body).Decode(&errorResp)
+			// Nodes without archive state cannot serve past blocks
+			if resp.StatusCode == http.StatusServiceUnavailable && errorResp.Error == "historical_state_unavailable" {
+				t.Logf("⚠️ Historical state unavailable on this node: %s", errorResp.Message)
+				return nil, false
+			}
+			t.Fatalf("Expected status 200, got %d. Error: %s - %s",
+				resp.StatusCode, errorResp.Error, errorResp.Message)
+		}
+
+		var balanceResp BalanceResponse
+		if err := json.NewDecoder(resp.Body).Decode(&balanceResp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return &balanceResp, true
+	}
+
+	t.Run("AtTimestamp", func(t *testing.T) {
+		timestamp := time.Now().Add(-24 * time.Hour).UTC().Truncate(time.Second)
+		balanceResp, ok := getBalance(t, "timestamp="+timestamp.Format(time.RFC3339))
+		if !ok {
+			return
+		}
+
+		if balanceResp.BlockTimestamp == nil {
+			t.Fatal("Expected the timestamp of the resolved block")
+		}
+		// The resolved block is the last one mined at or before the timestamp
+		if balanceResp.BlockTimestamp.After(timestamp) || balanceResp.BlockTimestamp.Before(timestamp.Add(-time.Minute)) {
+			t.Errorf("Expected block mined shortly before %s, got %s", timestamp, balanceResp.BlockTimestamp)
+		}
+		if len(balanceResp.Balances) != 4 {
+			t.Errorf("Expected 4 tokens, got %d", len(balanceResp.Balances))
+		}
+
+		t.Logf("✅ %s resolved to block %d mined at %s", timestamp, balanceResp.BlockNumber, balanceResp.BlockTimestamp)
+
+		// Reading at the resolved block returns the same balances
+		atBlock, ok := getBalance(t, fmt.Sprintf("block=%d", balanceResp.BlockNumber))
+		if !ok {
+			return
+		}
+		if atBlock.BlockNumber != balanceResp.BlockNumber {
+			t.Errorf("Expected block %d, got %d", balanceResp.BlockNumber, atBlock.BlockNumber)
+		}
+		for symbol, balance := range balanceResp.Balances {
+			if atBlock.Balances[symbol].Balance != balance.Balance {
+				t.Errorf("Expected %s balance %s at block %d, got %s", symbol, balance.Balance, atBlock.BlockNumber, atBlock.Balances[symbol].Balance)
+			}
+		}
+	})
+
+	t.Run("BeforeMulticall", func(t *testing.T) {
+		// Read one eth_call at a time, before the vault existed
+		balanceResp, ok := getBalance(t, "block=14000000")
+		if !ok {
+			return
+		}
+
+		if balanceResp.BlockNumber != 14000000 {
+			t.Errorf("Expected block 14000000, got %d", balanceResp.BlockNumber)
+		}
+		if len(balanceResp.Balances) != 4 {
+			t.Errorf("Expected 4 tokens, got %d", len(balanceResp.Balances))
+		}
+	})
+
+	t.Run("LatestHasNoTimestamp", func(t *testing.T) {
+		balanceResp, ok := getBalance(t, "")
+		if ok && balanceResp.BlockTimestamp != nil {
+			t.Errorf("Expected no block timestamp for the latest balances, got %s", balanceResp.BlockTimestamp)
+		}
+	})
+
+	tests := []struct {
+		name          string
+		query         string
+		expectedError string
+	}{
+		{
+			name:          "BlockAndTimestamp",
+			query:         "block=20000000&timestamp=2025-01-01",
+			expectedError: "invalid_block_params",
+		},
+		{
+			name:          "InvalidBlock",
+			query:         "block=latest",
+			expectedError: "invalid_block",
+		},
+		{
+			name:          "BlockBeyondHead",
+			query:         "block=999999999999",
+			expectedError: "invalid_block",
+		},
+		{
+			name:          "InvalidTimestamp",
+			query:         "timestamp=yesterday",
+			expectedError: "invalid_timestamp",
+		},
+		{
+			name:          "FutureTimestamp",
+			query:         "timestamp=" + time.Now().Add(24*time.Hour).UTC().Format("2006-01-02"),
+			expectedError: "invalid_timestamp",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp, err := http.Get(fmt.Sprintf("%s/api/balance/%s?%s", BaseURL, TestWalletAddress, test.query))
+			if err != nil {
+				t.Fatalf("Failed to make GET request: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != http.StatusBadRequest {
+				t.Errorf("Expected status 400, got %d", resp.StatusCode)
+			}
+
+			var errorResp ErrorResponse
+			if err := json.NewDecoder(resp.Body).Decode(&errorResp); err != nil {
+				t.Fatalf("Failed to decode error response: %v", err)
+			}
+
+			if errorResp.Error != test.expectedError {
+				t.Errorf("Expected error '%s', got '%s'", test.expectedError, errorResp.Error)
+			}
+		})
+	}
+}
+
+func TestBatchBalances(t *testing.T) {
+	otherWallet := "0x742d35Cc52C0b9550e0B7e5c5B8cd5D9E3e5C5c5"
+
+	t.Run("ReadsWalletsAtOneBlock", func(t *testing.T) {
+		reqBody, err := json.Marshal(BatchBalanceRequest{WalletAddresses: []string{TestWalletAddress, otherWallet}})
+		if err != nil {
+			t.Fatalf("Failed to marshal request: %v", err)
+		}
+
+		resp, err := http.Post(BaseURL+"/api/balances", "application/json", bytes.NewBuffer(reqBody))
+		if err != nil {
+			t.Fatalf("Failed to make POST request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			var errorResp ErrorResponse
+			json.NewDecoder(resp.Body).Decode(&errorResp)
+			t.Fatalf("Expected status 200, got %d. Error: %s - %s",
+				resp.StatusCode, errorResp.Error, errorResp.Message)
+		}
+
+		var batchResp BatchBalanceResponse
+		if err := json.NewDecoder(resp.Body).Decode(&batchResp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+
+		if batchResp.BlockNumber == 0 {
+			t.Error("Expected the block number the balances were read at")
+		}
+
+		if len(batchResp.Balances) != 2 {
+			t.Fatalf("Expected balances for 2 wallets, got %d", len(batchResp.Balances))
+		}
+
+		for i, walletAddress := range []string{TestWalletAddress, otherWallet} {
+			balanceResp := batchResp.Balances[i]
+			if balanceResp.WalletAddress != walletAddress {
+				t.Errorf("Expected wallet %d to be %s, got %s", i, walletAddress, balanceResp.WalletAddress)
+			}
+			if balanceResp.BlockNumber != batchResp.BlockNumber {
+				t.Errorf("Expected wallet %s to be read at block %d, got %d", walletAddress, batchResp.BlockNumber, balanceResp.BlockNumber)
+			}
+			if len(balanceResp.Balances) != 4 {
+				t.Errorf("Expected 4 tokens for wallet %s, got %d", walletAddress, len(balanceResp.Balances))
+			}
+		}
+
+		t.Logf("✅ Read balances for %d wallets at block %d", len(batchResp.Balances), batchResp.BlockNumber)
+	})
+
+	tooMany := make([]string, 51)
+	for i := range tooMany {
+		tooMany[i] = TestWalletAddress
+	}
+
+	tests := []struct {
+		name          string
+		body          string
+		expectedError string
+	}{
+		{
+			name:          "InvalidJSON",
+			body:          "{",
+			expectedError: "invalid_request_body",
+		},
+		{
+			name:          "MissingWalletAddresses",
+			body:          `{"wallet_addresses": []}`,
+			expectedError: "missing_wallet_addresses",
+		},
+		{
+			name:          "InvalidWalletAddress",
+			body:          fmt.Sprintf(`{"wallet_addresses": ["%s", "invalid-address"]}`, TestWalletAddress),
+			expectedError: "invalid_wallet_address",
+		},
+		{
+			name:          "TooManyWallets",
+			body:          fmt.Sprintf(`{"wallet_addresses": ["%s"]}`, strings.Join(tooMany, `", "`)),
+			expectedError: "too_many_wallets",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp, err := http.Post(BaseURL+"/api/balances", "application/json", strings.NewReader(test.body))
+			if err != nil {
+				t.Fatalf("Failed to make POST request: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != http.StatusBadRequest {
+				t.Errorf("Expected status 400, got %d", resp.StatusCode)
+			}
+
+			var errorResp ErrorResponse
+			if err := json.NewDecoder(resp.Body).Decode(&errorResp); err != nil {
+				t.Fatalf("Failed to decode error response: %v", err)
+			}
+
+			if errorResp.Error != test.expectedError {
+				t.Errorf("Expected error '%s', got '%s'", test.expectedError, errorResp.Error)
+			}
+		})
+	}
+}
+
 func TestCreateWithdrawalTransaction(t *testing.T) {
 	// Test: Create a withdrawal transaction (LBTCv to target asset)
 	t.Run("CreateWithdrawalTransaction", func(t *testing.T) {
@@ -647,6 +1042,10 @@ func TestGetVaultInfo(t *testing.T) {
 		}
 
 		// Validate response structure
+		if infoResp.BlockNumber == 0 {
+			t.Error("Expected the block number the vault was read at")
+		}
+
 		if infoResp.APY == "" {
 			t.Error("APY should not be empty")
 		}
@@ -673,6 +1072,25 @@ func TestGetVaultInfo(t *testing.T) {
 			t.Errorf("APY should be a valid number, got '%s': %v", infoResp.APY, err)
 		}
 
+		// The headline APY is the shortest trailing window, computed from rate snapshots
+		if len(infoResp.APYs) == 0 {
+			t.Fatal("Expected at least one APY window")
+		}
+		if infoResp.APYs[0].APY != infoResp.APY {
+			t.Errorf("Expected APY %s to match the %d-day window, got %s", infoResp.APY, infoResp.APYs[0].WindowDays, infoResp.APYs[0].APY)
+		}
+		for _, window := range infoResp.APYs {
+			if window.WindowDays != 7 && window.WindowDays != 30 && window.WindowDays != 90 {
+				t.Errorf("Unexpected APY window of %d days", window.WindowDays)
+			}
+			if _, err := strconv.ParseFloat(window.APY, 64); err != nil {
+				t.Errorf("%d-day APY should be a valid number, got '%s'", window.WindowDays, window.APY)
+			}
+			if window.FromBlock >= window.ToBlock || window.To.Sub(window.From) < time.Duration(window.WindowDays)*24*time.Hour {
+				t.Errorf("%d-day APY spans blocks %d-%d from %s to %s", window.WindowDays, window.FromBlock, window.ToBlock, window.From, window.To)
+			}
+		}
+
 		// Validate that TVL is a valid number (should be parseable as float)
 		_, err = strconv.ParseFloat(infoResp.TVL, 64)
 		if err != nil {
@@ -700,4 +1118,1724 @@ func TestGetVaultInfo(t *testing.T) {
 
 		t.Logf("✅ Successfully retrieved vault information")
 	})
+
+	// Test: Fees, pause flags and deposit and withdrawal settings read from the vault contracts
+	t.Run("GetVaultSettings", func(t *testing.T) {
+		resp, err := http.Get(BaseURL + "/api/info")
+		if err != nil {
+			t.Fatalf("Failed to make GET request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		var infoResp InfoResponse
+		if err := json.NewDecoder(resp.Body).Decode(&infoResp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+
+		if infoResp.Paused.Teller == nil || infoResp.Paused.Accountant == nil || infoResp.Paused.AtomicQueue == nil {
+			t.Errorf("Expected every pause flag to be read, got %+v", infoResp.Paused)
+		}
+
+		if infoResp.Fees == nil {
+			t.Error("Expected the accountant fees")
+		} else if infoResp.Fees.PlatformFeeBps < 0 || infoResp.Fees.PlatformFeeBps > 10000 || infoResp.Fees.PerformanceFeeBps < 0 || infoResp.Fees.PerformanceFeeBps > 10000 {
+			t.Errorf("Expected fees in basis points, got %+v", *infoResp.Fees)
+		}
+
+		if infoResp.ShareLockPeriodSeconds == nil {
+			t.Error("Expected the share lock period")
+		}
+
+		// The AtomicQueue's MAX_DISCOUNT is 1%
+		if infoResp.WithdrawalDiscount == nil || infoResp.WithdrawalDiscount.MinBps != 0 || infoResp.WithdrawalDiscount.MaxBps != 100 {
+			t.Errorf("Expected a withdrawal discount range of 0-100 bps, got %+v", infoResp.WithdrawalDiscount)
+		}
+
+		symbols := make(map[string]VaultAsset)
+		for _, asset := range infoResp.Assets {
+			symbols[asset.Symbol] = asset
+		}
+		for _, symbol := range []string{"LBTC", "WBTC", "CBTC"} {
+			asset, exists := symbols[symbol]
+			if !exists {
+				t.Errorf("Expected settings for %s", symbol)
+				continue
+			}
+			if asset.AllowDeposits == nil {
+				t.Errorf("Expected deposit enablement for %s", symbol)
+			}
+			if asset.AllowWithdraws != nil && *asset.AllowWithdraws && asset.MinimumWithdrawal != "0.00010001" {
+				t.Errorf("Expected minimum withdrawal 0.00010001 for %s, got %q", symbol, asset.MinimumWithdrawal)
+			}
+		}
+		if _, exists := symbols["LBTCv"]; exists {
+			t.Error("Expected LBTCv, the vault share, not to be listed as a deposit asset")
+		}
+
+		t.Logf("✅ Vault settings: paused=%+v, share lock=%v, assets=%d", infoResp.Paused, infoResp.ShareLockPeriodSeconds, len(infoResp.Assets))
+	})
+
+	// Test: The TVL is valued in BTC and USD from the price feeds, or left out when they are stale
+	t.Run("GetVaultValuation", func(t *testing.T) {
+		resp, err := http.Get(BaseURL + "/api/info")
+		if err != nil {
+			t.Fatalf("Failed to make GET request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		var infoResp InfoResponse
+		if err := json.NewDecoder(resp.Body).Decode(&infoResp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+
+		if infoResp.TVLBTC == "" {
+			if infoResp.TVLUSD != "" || infoResp.BTCPriceUSD != "" {
+				t.Errorf("Expected the USD valuation to be left out with the BTC one, got %+v", infoResp)
+			}
+			t.Skip("Price feeds unavailable or stale")
+		}
+
+		tvlBTC, err := strconv.ParseFloat(infoResp.TVLBTC, 64)
+		if err != nil || tvlBTC < 0 {
+			t.Errorf("Expected tvl_btc to be a non-negative number, got '%s'", infoResp.TVLBTC)
+		}
+		tvlUSD, err := strconv.ParseFloat(infoResp.TVLUSD, 64)
+		if err != nil {
+			t.Errorf("Expected tvl_usd to be a number, got '%s'", infoResp.TVLUSD)
+		}
+		btcPrice, err := strconv.ParseFloat(infoResp.BTCPriceUSD, 64)
+		if err != nil || btcPrice <= 0 {
+			t.Errorf("Expected btc_price_usd to be positive, got '%s'", infoResp.BTCPriceUSD)
+		}
+		if infoResp.PricedAt == nil || infoResp.PricedAt.After(time.Now()) {
+			t.Errorf("Expected priced_at in the past, got %v", infoResp.PricedAt)
+		}
+
+		// Both are rounded down, to satoshis and cents
+		if diff := tvlBTC*btcPrice - tvlUSD; diff < 0 || diff > btcPrice*0.00000001+0.01 {
+			t.Errorf("Expected tvl_usd %s to be tvl_btc %s at %s USD", infoResp.TVLUSD, infoResp.TVLBTC, infoResp.BTCPriceUSD)
+		}
+
+		t.Logf("✅ Vault valuation: %s BTC, %s USD at %s USD/BTC", infoResp.TVLBTC, infoResp.TVLUSD, infoResp.BTCPriceUSD)
+	})
+}
+
+func TestWalletPosition(t *testing.T) {
+	// Test: The position adds up its lots, and every amount is a number
+	t.Run("GetPosition", func(t *testing.T) {
+		resp, err := http.Get(fmt.Sprintf("%s/api/wallets/%s/position", BaseURL, TestWalletAddress))
+		if err != nil {
+			t.Fatalf("Failed to make GET request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			var errorResp ErrorResponse
+			json.NewDecoder(resp.Body).Decode(&errorResp)
+			t.Fatalf("Expected status 200, got %d. Error: %s - %s",
+				resp.StatusCode, errorResp.Error, errorResp.Message)
+		}
+
+		var positionResp PositionResponse
+		if err := json.NewDecoder(resp.Body).Decode(&positionResp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+
+		if positionResp.WalletAddress != TestWalletAddress {
+			t.Errorf("Expected wallet address %s, got %s", TestWalletAddress, positionResp.WalletAddress)
+		}
+
+		for name, value := range map[string]string{
+			"shares":           positionResp.Shares,
+			"ledger_shares":    positionResp.LedgerShares,
+			"value":            positionResp.Value,
+			"cost_basis":       positionResp.CostBasis,
+			"realized_yield":   positionResp.RealizedYield,
+			"unrealized_yield": positionResp.UnrealizedYield,
+		} {
+			if _, err := strconv.ParseFloat(value, 64); err != nil {
+				t.Errorf("Expected %s to be a number, got '%s'", name, value)
+			}
+		}
+
+		ledgerShares, _ := strconv.ParseFloat(positionResp.LedgerShares, 64)
+		var lotShares float64
+		for _, lot := range positionResp.Lots {
+			shares, err := strconv.ParseFloat(lot.Shares, 64)
+			if err != nil {
+				t.Errorf("Expected %s lot shares to be a number, got '%s'", lot.Asset, lot.Shares)
+			}
+			lotShares += shares
+
+			if price, err := strconv.ParseFloat(lot.SharePrice, 64); err != nil || price <= 0 {
+				t.Errorf("Expected %s share price to be positive, got '%s'", lot.Asset, lot.SharePrice)
+			}
+		}
+		if diff := lotShares - ledgerShares; diff > 0.0000001 || diff < -0.0000001 {
+			t.Errorf("Expected lot shares to add up to %s, got %f", positionResp.LedgerShares, lotShares)
+		}
+
+		// The valuation in BTC and USD is left out when the price feeds are stale
+		if positionResp.ValueBTC != "" {
+			for name, value := range map[string]string{
+				"value_btc":     positionResp.ValueBTC,
+				"value_usd":     positionResp.ValueUSD,
+				"btc_price_usd": positionResp.BTCPriceUSD,
+			} {
+				if _, err := strconv.ParseFloat(value, 64); err != nil {
+					t.Errorf("Expected %s to be a number, got '%s'", name, value)
+				}
+			}
+			for _, lot := range positionResp.Lots {
+				if lot.ValueBTC == "" || lot.ValueUSD == "" {
+					t.Errorf("Expected the %s lot to be valued along with the position", lot.Asset)
+				}
+			}
+		}
+
+		t.Logf("✅ Position: shares=%s, value=%s, realized=%s, unrealized=%s, lots=%d",
+			positionResp.Shares, positionResp.Value, positionResp.RealizedYield, positionResp.UnrealizedYield, len(positionResp.Lots))
+	})
+
+	// Test: Invalid wallet address
+	t.Run("InvalidWalletAddress", func(t *testing.T) {
+		resp, err := http.Get(BaseURL + "/api/wallets/not-an-address/position")
+		if err != nil {
+			t.Fatalf("Failed to make GET request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("Expected status 400, got %d", resp.StatusCode)
+		}
+
+		var errorResp ErrorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&errorResp); err != nil {
+			t.Fatalf("Failed to decode error response: %v", err)
+		}
+		if errorResp.Error != "invalid_wallet_address" {
+			t.Errorf("Expected error invalid_wallet_address, got %s", errorResp.Error)
+		}
+	})
+}
+
+func TestExportWalletActivity(t *testing.T) {
+	exportURL := fmt.Sprintf("%s/api/wallets/%s/export", BaseURL, TestWalletAddress)
+
+	// Test: JSON exports are an array of events in chain order
+	t.Run("JSON", func(t *testing.T) {
+		resp, err := http.Get(exportURL + "?format=json")
+		if err != nil {
+			t.Fatalf("Failed to make GET request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+		if contentType := resp.Header.Get("Content-Type"); contentType != "application/json" {
+			t.Errorf("Expected Content-Type application/json, got %s", contentType)
+		}
+
+		var records []ExportRecord
+		if err := json.NewDecoder(resp.Body).Decode(&records); err != nil {
+			t.Fatalf("Failed to decode export: %v", err)
+		}
+
+		for i, record := range records {
+			switch record.EventType {
+			case "deposit", "withdrawal_requested", "withdrawal_completed", "transfer_in", "transfer_out":
+			default:
+				t.Errorf("Unexpected event type %s", record.EventType)
+			}
+			if _, err := strconv.ParseFloat(record.Shares, 64); err != nil {
+				t.Errorf("Expected shares of %s to be a number, got '%s'", record.TxHash, record.Shares)
+			}
+			if i > 0 {
+				previous := records[i-1]
+				if record.BlockNumber < previous.BlockNumber || (record.BlockNumber == previous.BlockNumber && record.LogIndex <= previous.LogIndex) {
+					t.Errorf("Expected events in chain order, got %d/%d after %d/%d", record.BlockNumber, record.LogIndex, previous.BlockNumber, previous.LogIndex)
+				}
+			}
+		}
+
+		t.Logf("✅ Exported %d events as JSON", len(records))
+	})
+
+	// Test: CSV exports have a header row and the same number of fields in every row
+	t.Run("CSV", func(t *testing.T) {
+		resp, err := http.Get(exportURL + "?format=csv&from=2024-01-01")
+		if err != nil {
+			t.Fatalf("Failed to make GET request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+		if disposition := resp.Header.Get("Content-Disposition"); !strings.HasPrefix(disposition, "attachment") {
+			t.Errorf("Expected an attachment, got Content-Disposition %s", disposition)
+		}
+
+		rows, err := csv.NewReader(resp.Body).ReadAll()
+		if err != nil {
+			t.Fatalf("Failed to parse CSV export: %v", err)
+		}
+		if len(rows) == 0 || rows[0][0] != "event_type" || rows[0][len(rows[0])-1] != "value_btc" {
+			t.Fatalf("Expected a header row from event_type to value_btc, got %v", rows)
+		}
+
+		t.Logf("✅ Exported %d events as CSV", len(rows)-1)
+	})
+
+	// Test: Invalid parameters are rejected
+	cases := []struct {
+		name          string
+		url           string
+		expectedError string
+	}{
+		{"InvalidFormat", exportURL + "?format=xlsx", "invalid_format"},
+		{"InvalidFrom", exportURL + "?from=yesterday", "invalid_from"},
+		{"InvalidTo", exportURL + "?to=tomorrow", "invalid_to"},
+		{"FromAfterTo", exportURL + "?from=2024-02-01&to=2024-01-01", "invalid_time_range"},
+		{"InvalidWalletAddress", BaseURL + "/api/wallets/not-an-address/export", "invalid_wallet_address"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := http.Get(tc.url)
+			if err != nil {
+				t.Fatalf("Failed to make GET request: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != http.StatusBadRequest {
+				t.Fatalf("Expected status 400, got %d", resp.StatusCode)
+			}
+
+			var errorResp ErrorResponse
+			if err := json.NewDecoder(resp.Body).Decode(&errorResp); err != nil {
+				t.Fatalf("Failed to decode error response: %v", err)
+			}
+			if errorResp.Error != tc.expectedError {
+				t.Errorf("Expected error %s, got %s", tc.expectedError, errorResp.Error)
+			}
+		})
+	}
+}
+
+func TestResponseCaching(t *testing.T) {
+	endpoints := map[string]string{
+		"Info":    BaseURL + "/api/info",
+		"Balance": fmt.Sprintf("%s/api/balance/%s", BaseURL, TestWalletAddress),
+	}
+
+	for name, url := range endpoints {
+		t.Run(name, func(t *testing.T) {
+			// Concurrent identical requests share one read, so they all get the same response
+			etags := make(chan string, 5)
+			for i := 0; i < cap(etags); i++ {
+				go func() {
+					resp, err := http.Get(url)
+					if err != nil {
+						etags <- ""
+						return
+					}
+					defer resp.Body.Close()
+					io.Copy(io.Discard, resp.Body)
+					etags <- resp.Header.Get("ETag")
+				}()
+			}
+
+			seen := make(map[string]bool)
+			for i := 0; i < cap(etags); i++ {
+				etag := <-etags
+				if etag == "" {
+					t.Fatal("Expected every response to carry an ETag")
+				}
+				seen[etag] = true
+			}
+			// A new block may land between the requests
+			if len(seen) > 2 {
+				t.Errorf("Expected concurrent requests to share a response, got %d ETags", len(seen))
+			}
+
+			resp, err := http.Get(url)
+			if err != nil {
+				t.Fatalf("Failed to make GET request: %v", err)
+			}
+			resp.Body.Close()
+
+			etag := resp.Header.Get("ETag")
+			if !strings.HasPrefix(resp.Header.Get("Cache-Control"), "public, max-age=") {
+				t.Errorf("Expected a public Cache-Control header, got %q", resp.Header.Get("Cache-Control"))
+			}
+
+			// Revalidating with the ETag returns 304 while the block is unchanged
+			req, err := http.NewRequest(http.MethodGet, url, nil)
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+			req.Header.Set("If-None-Match", etag)
+
+			resp, err = http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Failed to make GET request: %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode == http.StatusNotModified {
+				if resp.Header.Get("X-Cache") != "HIT" {
+					t.Errorf("Expected revalidation to be served from the cache, got X-Cache %q", resp.Header.Get("X-Cache"))
+				}
+			} else if resp.StatusCode != http.StatusOK || resp.Header.Get("ETag") == etag {
+				t.Errorf("Expected 304 for ETag %s, got %d", etag, resp.StatusCode)
+			}
+
+			t.Logf("✅ %s responses cached with ETag %s", name, etag)
+		})
+	}
+}
+
+func TestVaultHistory(t *testing.T) {
+	// Test: Each metric is returned as one point per bucket, oldest first
+	for _, metric := range []string{"tvl", "share_price", "apy"} {
+		t.Run("Metric_"+metric, func(t *testing.T) {
+			resp, err := http.Get(fmt.Sprintf("%s/api/vault/history?metric=%s&interval=day", BaseURL, metric))
+			if err != nil {
+				t.Fatalf("Failed to make GET request: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != http.StatusOK {
+				var errorResp ErrorResponse
+				json.NewDecoder(resp.Body).Decode(&errorResp)
+				t.Fatalf("Expected status 200, got %d. Error: %s - %s",
+					resp.StatusCode, errorResp.Error, errorResp.Message)
+			}
+
+			var history VaultHistoryResponse
+			if err := json.NewDecoder(resp.Body).Decode(&history); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+
+			if history.Metric != metric || history.Interval != "day" {
+				t.Errorf("Expected %s by day, got %s by %s", metric, history.Metric, history.Interval)
+			}
+			if got := history.To.Sub(history.From); got != 30*24*time.Hour {
+				t.Errorf("Expected a default range of 30 days, got %s", got)
+			}
+			if metric == "apy" && history.WindowDays != 7 {
+				t.Errorf("Expected a 7-day APY window, got %d", history.WindowDays)
+			}
+
+			for i, point := range history.Points {
+				if !point.Timestamp.Equal(point.Timestamp.Truncate(24 * time.Hour)) {
+					t.Errorf("Expected point %d to start a day, got %s", i, point.Timestamp)
+				}
+				if i > 0 && !point.Timestamp.After(history.Points[i-1].Timestamp) {
+					t.Errorf("Expected points to be in increasing order, got %s after %s", point.Timestamp, history.Points[i-1].Timestamp)
+				}
+				if _, err := strconv.ParseFloat(point.Value, 64); err != nil {
+					t.Errorf("Expected point %d to be a number, got '%s'", i, point.Value)
+				}
+			}
+
+			t.Logf("✅ %d %s points", len(history.Points), metric)
+		})
+	}
+
+	// Test: Invalid parameters are rejected
+	cases := []struct {
+		name          string
+		query         string
+		expectedError string
+	}{
+		{"MissingMetric", "", "invalid_metric"},
+		{"UnknownMetric", "metric=volume", "invalid_metric"},
+		{"InvalidInterval", "metric=tvl&interval=week", "invalid_interval"},
+		{"InvalidFrom", "metric=tvl&from=yesterday", "invalid_from"},
+		{"InvalidTo", "metric=tvl&to=tomorrow", "invalid_to"},
+		{"FromAfterTo", "metric=tvl&from=2024-02-01&to=2024-01-01", "invalid_time_range"},
+		{"RangeTooLarge", "metric=tvl&interval=hour&from=2024-01-01&to=2024-12-31", "range_too_large"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := http.Get(BaseURL + "/api/vault/history?" + tc.query)
+			if err != nil {
+				t.Fatalf("Failed to make GET request: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != http.StatusBadRequest {
+				t.Fatalf("Expected status 400, got %d", resp.StatusCode)
+			}
+
+			var errorResp ErrorResponse
+			if err := json.NewDecoder(resp.Body).Decode(&errorResp); err != nil {
+				t.Fatalf("Failed to decode error response: %v", err)
+			}
+			if errorResp.Error != tc.expectedError {
+				t.Errorf("Expected error %s, got %s", tc.expectedError, errorResp.Error)
+			}
+		})
+	}
+}
+
+func TestListWalletOrders(t *testing.T) {
+	// Test: List a wallet's orders one page at a time
+	t.Run("ListWalletOrders", func(t *testing.T) {
+		getURL := fmt.Sprintf("%s/api/wallets/%s/orders?limit=1", BaseURL, TestWalletAddress)
+
+		resp, err := http.Get(getURL)
+		if err != nil {
+			t.Fatalf("Failed to make GET request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			var errorResp ErrorResponse
+			json.NewDecoder(resp.Body).Decode(&errorResp)
+			t.Fatalf("Expected status 200, got %d. Error: %s - %s",
+				resp.StatusCode, errorResp.Error, errorResp.Message)
+		}
+
+		var listResp OrderListResponse
+		if err := json.NewDecoder(resp.Body).Decode(&listResp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+
+		if len(listResp.Orders) > 1 {
+			t.Errorf("Expected at most 1 order, got %d", len(listResp.Orders))
+		}
+
+		for _, order := range listResp.Orders {
+			if !strings.EqualFold(order.WalletAddress, TestWalletAddress) {
+				t.Errorf("Expected wallet address %s, got %s", TestWalletAddress, order.WalletAddress)
+			}
+		}
+
+		// Follow the cursor to the next page if there is one
+		if listResp.NextCursor != "" {
+			nextResp, err := http.Get(getURL + "&cursor=" + listResp.NextCursor)
+			if err != nil {
+				t.Fatalf("Failed to make GET request: %v", err)
+			}
+			defer nextResp.Body.Close()
+
+			var nextPage OrderListResponse
+			if err := json.NewDecoder(nextResp.Body).Decode(&nextPage); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+
+			if len(nextPage.Orders) > 0 && len(listResp.Orders) > 0 && nextPage.Orders[0].OrderID == listResp.Orders[0].OrderID {
+				t.Error("Next page should not repeat the previous page")
+			}
+		}
+
+		t.Logf("✅ Listed %d orders for wallet %s", len(listResp.Orders), TestWalletAddress)
+	})
+}
+
+func TestListWalletOrdersValidation(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		walletAddress  string
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name:           "InvalidWalletAddress",
+			walletAddress:  "invalid-address",
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "invalid_wallet_address",
+		},
+		{
+			name:           "InvalidTransferType",
+			walletAddress:  TestWalletAddress,
+			query:          "transfer_type=swap",
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "invalid_transfer_type",
+		},
+		{
+			name:           "InvalidStatus",
+			walletAddress:  TestWalletAddress,
+			query:          "status=pending",
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "invalid_status",
+		},
+		{
+			name:           "InvalidLimit",
+			walletAddress:  TestWalletAddress,
+			query:          "limit=0",
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "invalid_limit",
+		},
+		{
+			name:           "InvalidCursor",
+			walletAddress:  TestWalletAddress,
+			query:          "cursor=not-a-cursor",
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "invalid_cursor",
+		},
+		{
+			name:           "FilteredByTypeStatusAndDate",
+			walletAddress:  TestWalletAddress,
+			query:          "transfer_type=withdrawal&status=completed&asset=WBTC&from=2024-01-01&sort=asc",
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			getURL := fmt.Sprintf("%s/api/wallets/%s/orders?%s", BaseURL, test.walletAddress, test.query)
+
+			resp, err := http.Get(getURL)
+			if err != nil {
+				t.Fatalf("Failed to make GET request: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != test.expectedStatus {
+				t.Errorf("Expected status %d, got %d", test.expectedStatus, resp.StatusCode)
+			}
+
+			if test.expectedError != "" {
+				var errorResp ErrorResponse
+				if err := json.NewDecoder(resp.Body).Decode(&errorResp); err != nil {
+					t.Fatalf("Failed to decode error response: %v", err)
+				}
+
+				if errorResp.Error != test.expectedError {
+					t.Errorf("Expected error '%s', got '%s'", test.expectedError, errorResp.Error)
+				}
+
+				t.Logf("✅ Validation test '%s' returned expected error: %s", test.name, errorResp.Error)
+			} else {
+				var listResp OrderListResponse
+				if err := json.NewDecoder(resp.Body).Decode(&listResp); err != nil {
+					t.Fatalf("Failed to decode success response: %v", err)
+				}
+
+				for _, order := range listResp.Orders {
+					if order.TransferType != "withdrawal" || order.Status != "completed" {
+						t.Errorf("Order %s does not match filter: %s/%s", order.OrderID, order.TransferType, order.Status)
+					}
+				}
+
+				t.Logf("✅ Validation test '%s' succeeded with %d orders", test.name, len(listResp.Orders))
+			}
+		})
+	}
+}
+
+func TestStreamWalletOrders(t *testing.T) {
+	t.Run("InvalidWalletAddress", func(t *testing.T) {
+		resp, err := http.Get(fmt.Sprintf("%s/api/wallets/invalid-address/orders/stream", BaseURL))
+		if err != nil {
+			t.Fatalf("Failed to make GET request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", resp.StatusCode)
+		}
+
+		var errorResp ErrorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&errorResp); err != nil {
+			t.Fatalf("Failed to decode error response: %v", err)
+		}
+		if errorResp.Error != "invalid_wallet_address" {
+			t.Errorf("Expected error 'invalid_wallet_address', got '%s'", errorResp.Error)
+		}
+	})
+
+	t.Run("InvalidLastEventID", func(t *testing.T) {
+		resp, err := http.Get(fmt.Sprintf("%s/api/wallets/%s/orders/stream?last_event_id=abc", BaseURL, TestWalletAddress))
+		if err != nil {
+			t.Fatalf("Failed to make GET request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("OpensEventStream", func(t *testing.T) {
+		req, err := http.NewRequest("GET", fmt.Sprintf("%s/api/wallets/%s/orders/stream", BaseURL, TestWalletAddress), nil)
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+		req.Header.Set("Last-Event-ID", "0")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to open stream: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+		if contentType := resp.Header.Get("Content-Type"); !strings.HasPrefix(contentType, "text/event-stream") {
+			t.Errorf("Expected text/event-stream content type, got '%s'", contentType)
+		}
+
+		// The stream opens with a reconnection hint
+		buf := make([]byte, len("retry:"))
+		if _, err := io.ReadFull(resp.Body, buf); err != nil {
+			t.Fatalf("Failed to read from stream: %v", err)
+		}
+		if string(buf) != "retry:" {
+			t.Errorf("Expected stream to start with 'retry:', got '%s'", string(buf))
+		}
+
+		t.Logf("✅ Opened order stream for wallet %s", TestWalletAddress)
+	})
+}
+
+func TestWebhookSubscriptionLifecycle(t *testing.T) {
+	reqBody, err := json.Marshal(CreateWebhookRequest{
+		URL:           "https://example.com/hooks/yield",
+		Secret:        "integration-test-secret",
+		WalletAddress: strings.ToLower(TestWalletAddress),
+		EventTypes:    []string{"deposit", "withdrawal_completed"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to marshal request: %v", err)
+	}
+
+	resp, err := http.Post(BaseURL+"/api/webhooks", "application/json", bytes.NewBuffer(reqBody))
+	if err != nil {
+		t.Fatalf("Failed to make POST request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d", resp.StatusCode)
+	}
+
+	var subscription WebhookSubscriptionResponse
+	if err := json.NewDecoder(resp.Body).Decode(&subscription); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	if subscription.ID == "" {
+		t.Fatal("Subscription ID should not be empty")
+	}
+	if subscription.WalletAddress == nil || *subscription.WalletAddress != TestWalletAddress {
+		t.Errorf("Expected wallet address to be stored checksummed as %s", TestWalletAddress)
+	}
+	if len(subscription.EventTypes) != 2 {
+		t.Errorf("Expected 2 event types, got %d", len(subscription.EventTypes))
+	}
+
+	t.Run("GetSubscription", func(t *testing.T) {
+		resp, err := http.Get(fmt.Sprintf("%s/api/webhooks/%s", BaseURL, subscription.ID))
+		if err != nil {
+			t.Fatalf("Failed to make GET request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+
+		var body map[string]interface{}
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if _, exposed := body["secret"]; exposed {
+			t.Error("Subscription secret must never be returned")
+		}
+	})
+
+	t.Run("ListDeliveries", func(t *testing.T) {
+		resp, err := http.Get(fmt.Sprintf("%s/api/webhooks/%s/deliveries", BaseURL, subscription.ID))
+		if err != nil {
+			t.Fatalf("Failed to make GET request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+
+		var deliveries WebhookDeliveryListResponse
+		if err := json.NewDecoder(resp.Body).Decode(&deliveries); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+
+		t.Logf("✅ Subscription %s has %d deliveries", subscription.ID, len(deliveries.Deliveries))
+	})
+
+	t.Run("DeleteSubscription", func(t *testing.T) {
+		req, err := http.NewRequest("DELETE", fmt.Sprintf("%s/api/webhooks/%s", BaseURL, subscription.ID), nil)
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to make DELETE request: %v", err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusNoContent {
+			t.Fatalf("Expected status 204, got %d", resp.StatusCode)
+		}
+
+		resp, err = http.Get(fmt.Sprintf("%s/api/webhooks/%s", BaseURL, subscription.ID))
+		if err != nil {
+			t.Fatalf("Failed to make GET request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("Expected status 404 after delete, got %d", resp.StatusCode)
+		}
+	})
+}
+
+func TestCreateWebhookValidation(t *testing.T) {
+	tests := []struct {
+		name          string
+		request       CreateWebhookRequest
+		expectedError string
+	}{
+		{
+			name:          "InvalidURL",
+			request:       CreateWebhookRequest{URL: "ftp://example.com", Secret: "integration-test-secret"},
+			expectedError: "invalid_url",
+		},
+		{
+			name:          "InsecureURL",
+			request:       CreateWebhookRequest{URL: "http://example.com/hooks", Secret: "integration-test-secret"},
+			expectedError: "invalid_url",
+		},
+		{
+			name:          "LoopbackURL",
+			request:       CreateWebhookRequest{URL: "https://127.0.0.1/hooks", Secret: "integration-test-secret"},
+			expectedError: "invalid_url",
+		},
+		{
+			name:          "MetadataServiceURL",
+			request:       CreateWebhookRequest{URL: "https://169.254.169.254/latest/meta-data", Secret: "integration-test-secret"},
+			expectedError: "invalid_url",
+		},
+		{
+			name:          "PrivateURL",
+			request:       CreateWebhookRequest{URL: "https://10.0.0.5/hooks", Secret: "integration-test-secret"},
+			expectedError: "invalid_url",
+		},
+		{
+			name:          "ShortSecret",
+			request:       CreateWebhookRequest{URL: "https://example.com/hooks", Secret: "short"},
+			expectedError: "invalid_secret",
+		},
+		{
+			name:          "InvalidWalletAddress",
+			request:       CreateWebhookRequest{URL: "https://example.com/hooks", Secret: "integration-test-secret", WalletAddress: "invalid-address"},
+			expectedError: "invalid_wallet_address",
+		},
+		{
+			name:          "InvalidEventType",
+			request:       CreateWebhookRequest{URL: "https://example.com/hooks", Secret: "integration-test-secret", EventTypes: []string{"swap"}},
+			expectedError: "invalid_event_type",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reqBody, err := json.Marshal(test.request)
+			if err != nil {
+				t.Fatalf("Failed to marshal request: %v", err)
+			}
+
+			resp, err := http.Post(BaseURL+"/api/webhooks", "application/json", bytes.NewBuffer(reqBody))
+			if err != nil {
+				t.Fatalf("Failed to make POST request: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != http.StatusBadRequest {
+				t.Errorf("Expected status 400, got %d", resp.StatusCode)
+			}
+
+			var errorResp ErrorResponse
+			if err := json.NewDecoder(resp.Body).Decode(&errorResp); err != nil {
+				t.Fatalf("Failed to decode error response: %v", err)
+			}
+
+			if errorResp.Error != test.expectedError {
+				t.Errorf("Expected error '%s', got '%s'", test.expectedError, errorResp.Error)
+			}
+		})
+	}
+}
+
+func TestCreateTransactionSimulationFailure(t *testing.T) {
+	// A wallet that holds no tokens and has approved nothing
+	const emptyWallet = "0x1111111111111111111111111111111111111111"
+
+	t.Run("DepositFromEmptyWallet", func(t *testing.T) {
+		reqBody, err := json.Marshal(DepositRequest{
+			Amount:        TestAmount,
+			FromAssetName: TestFromAsset,
+			WalletAddress: emptyWallet,
+		})
+		if err != nil {
+			t.Fatalf("Failed to marshal request: %v", err)
+		}
+
+		resp, err := http.Post(BaseURL+"/api/orders/deposit", "application/json", bytes.NewBuffer(reqBody))
+		if err != nil {
+			t.Fatalf("Failed to make POST request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusUnprocessableEntity {
+			t.Fatalf("Expected status 422, got %d", resp.StatusCode)
+		}
+
+		var errorResp ErrorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&errorResp); err != nil {
+			t.Fatalf("Failed to decode error response: %v", err)
+		}
+
+		if errorResp.Error != "insufficient_allowance" && errorResp.Error != "insufficient_balance" {
+			t.Errorf("Expected insufficient_allowance or insufficient_balance, got '%s': %s", errorResp.Error, errorResp.Message)
+		}
+
+		t.Logf("✅ Deposit simulation failed as expected: %s", errorResp.Message)
+	})
+
+	t.Run("WithdrawalFromEmptyWallet", func(t *testing.T) {
+		reqBody, err := json.Marshal(WithdrawalRequest{
+			Amount:        TestWithdrawalAmount,
+			ToAssetName:   TestToAsset,
+			WalletAddress: emptyWallet,
+		})
+		if err != nil {
+			t.Fatalf("Failed to marshal request: %v", err)
+		}
+
+		resp, err := http.Post(BaseURL+"/api/orders/withdrawal", "application/json", bytes.NewBuffer(reqBody))
+		if err != nil {
+			t.Fatalf("Failed to make POST request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusUnprocessableEntity {
+			t.Fatalf("Expected status 422, got %d", resp.StatusCode)
+		}
+
+		var errorResp ErrorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&errorResp); err != nil {
+			t.Fatalf("Failed to decode error response: %v", err)
+		}
+
+		if errorResp.Error != "insufficient_allowance" && errorResp.Error != "insufficient_balance" {
+			t.Errorf("Expected insufficient_allowance or insufficient_balance, got '%s': %s", errorResp.Error, errorResp.Message)
+		}
+
+		t.Logf("✅ Withdrawal simulation failed as expected: %s", errorResp.Message)
+	})
+}
+
+func TestPreflightPolicy(t *testing.T) {
+	// The vault's settings decide which requests the pre-flight checks must reject
+	resp, err := http.Get(BaseURL + "/api/info")
+	if err != nil {
+		t.Fatalf("Failed to make GET request: %v", err)
+	}
+	var infoResp InfoResponse
+	err = json.NewDecoder(resp.Body).Decode(&infoResp)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	postOrder := func(t *testing.T, path string, request interface{}) (int, ErrorResponse) {
+		reqBody, err := json.Marshal(request)
+		if err != nil {
+			t.Fatalf("Failed to marshal request: %v", err)
+		}
+
+		resp, err := http.Post(BaseURL+path, "application/json", bytes.NewBuffer(reqBody))
+		if err != nil {
+			t.Fatalf("Failed to make POST request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		var errorResp ErrorResponse
+		if resp.StatusCode != http.StatusCreated {
+			json.NewDecoder(resp.Body).Decode(&errorResp)
+		}
+		return resp.StatusCode, errorResp
+	}
+
+	tellerPaused := infoResp.Paused.Teller != nil && *infoResp.Paused.Teller
+	accountantPaused := infoResp.Paused.Accountant != nil && *infoResp.Paused.Accountant
+
+	for _, asset := range infoResp.Assets {
+		t.Run("Deposit"+asset.Symbol, func(t *testing.T) {
+			status, errorResp := postOrder(t, "/api/orders/deposit", DepositRequest{
+				Amount:        TestAmount,
+				FromAssetName: asset.Symbol,
+				WalletAddress: TestWalletAddress,
+			})
+
+			switch {
+			case tellerPaused || accountantPaused:
+				if status != http.StatusUnprocessableEntity || errorResp.Error != "vault_paused" {
+					t.Errorf("Expected 422 vault_paused while the vault is paused, got %d %s", status, errorResp.Error)
+				}
+			case asset.AllowDeposits != nil && !*asset.AllowDeposits:
+				if status != http.StatusUnprocessableEntity || errorResp.Error != "asset_not_supported" {
+					t.Errorf("Expected 422 asset_not_supported for %s, got %d %s", asset.Symbol, status, errorResp.Error)
+				}
+			default:
+				// The policy allows it; only the cap or the simulation may still reject it
+				if errorResp.Error == "vault_paused" || errorResp.Error == "asset_not_supported" {
+					t.Errorf("Expected %s deposit to pass the policy checks, got %d %s: %s", asset.Symbol, status, errorResp.Error, errorResp.Message)
+				}
+			}
+
+			t.Logf("✅ %s deposit: status %d %s", asset.Symbol, status, errorResp.Error)
+		})
+	}
+}
+
+func TestCreateApprovalTransaction(t *testing.T) {
+	t.Run("ApproveDepositAsset", func(t *testing.T) {
+		reqBody, err := json.Marshal(ApprovalRequest{
+			AssetName:     TestFromAsset,
+			Amount:        TestAmount,
+			WalletAddress: TestWalletAddress,
+		})
+		if err != nil {
+			t.Fatalf("Failed to marshal request: %v", err)
+		}
+
+		resp, err := http.Post(BaseURL+"/api/approvals", "application/json", bytes.NewBuffer(reqBody))
+		if err != nil {
+			t.Fatalf("Failed to make POST request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("Expected status 201, got %d", resp.StatusCode)
+		}
+
+		var approvalResp ApprovalResponse
+		if err := json.NewDecoder(resp.Body).Decode(&approvalResp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+
+		// Deposit assets are pulled by the vault, which is the LBTCv token
+		if !strings.EqualFold(approvalResp.Spender, LBTCvTokenAddress) {
+			t.Errorf("Expected spender %s, got %s", LBTCvTokenAddress, approvalResp.Spender)
+		}
+
+		var unsignedTx UnsignedTransaction
+		if err := json.Unmarshal([]byte(approvalResp.UnsignedTransaction), &unsignedTx); err != nil {
+			t.Fatalf("Failed to parse unsigned transaction: %v", err)
+		}
+
+		// approve(address,uint256)
+		if !strings.HasPrefix(unsignedTx.Data, "0x095ea7b3") {
+			t.Errorf("Expected approve calldata, got '%s'", unsignedTx.Data)
+		}
+
+		t.Logf("✅ Created approval transaction, current allowance %s", approvalResp.CurrentAllowance)
+	})
+
+	t.Run("UnsupportedAsset", func(t *testing.T) {
+		reqBody, err := json.Marshal(ApprovalRequest{
+			AssetName:     "INVALID",
+			WalletAddress: TestWalletAddress,
+		})
+		if err != nil {
+			t.Fatalf("Failed to marshal request: %v", err)
+		}
+
+		resp, err := http.Post(BaseURL+"/api/approvals", "application/json", bytes.NewBuffer(reqBody))
+		if err != nil {
+			t.Fatalf("Failed to make POST request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", resp.StatusCode)
+		}
+	})
+}
+
+func TestCreatePermit(t *testing.T) {
+	t.Run("PermitTypedData", func(t *testing.T) {
+		reqBody, err := json.Marshal(PermitRequest{
+			AssetName:     TestFromAsset,
+			Amount:        TestAmount,
+			WalletAddress: TestWalletAddress,
+		})
+		if err != nil {
+			t.Fatalf("Failed to marshal request: %v", err)
+		}
+
+		resp, err := http.Post(BaseURL+"/api/permits", "application/json", bytes.NewBuffer(reqBody))
+		if err != nil {
+			t.Fatalf("Failed to make POST request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+
+		var permitResp PermitResponse
+		if err := json.NewDecoder(resp.Body).Decode(&permitResp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+
+		if permitResp.TypedData["primaryType"] != "Permit" {
+			t.Errorf("Expected primaryType Permit, got %v", permitResp.TypedData["primaryType"])
+		}
+
+		// The vault pulls deposits, so it is the permit spender
+		if !strings.EqualFold(permitResp.Spender, LBTCvTokenAddress) {
+			t.Errorf("Expected spender %s, got %s", LBTCvTokenAddress, permitResp.Spender)
+		}
+
+		message, ok := permitResp.TypedData["message"].(map[string]interface{})
+		if !ok {
+			t.Fatalf("Expected typed data message, got %v", permitResp.TypedData["message"])
+		}
+		if !strings.EqualFold(fmt.Sprint(message["owner"]), TestWalletAddress) {
+			t.Errorf("Expected owner %s, got %v", TestWalletAddress, message["owner"])
+		}
+		if fmt.Sprint(message["deadline"]) != strconv.FormatInt(permitResp.Deadline, 10) {
+			t.Errorf("Expected deadline %d, got %v", permitResp.Deadline, message["deadline"])
+		}
+
+		t.Logf("✅ Created permit typed data with deadline %d", permitResp.Deadline)
+	})
+
+	t.Run("PermitNotSupported", func(t *testing.T) {
+		reqBody, err := json.Marshal(PermitRequest{
+			AssetName:     "WBTC",
+			Amount:        TestAmount,
+			WalletAddress: TestWalletAddress,
+		})
+		if err != nil {
+			t.Fatalf("Failed to marshal request: %v", err)
+		}
+
+		resp, err := http.Post(BaseURL+"/api/permits", "application/json", bytes.NewBuffer(reqBody))
+		if err != nil {
+			t.Fatalf("Failed to make POST request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("Expected status 400, got %d", resp.StatusCode)
+		}
+
+		var errorResp ErrorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&errorResp); err != nil {
+			t.Fatalf("Failed to decode error response: %v", err)
+		}
+
+		if errorResp.Error != "permit_not_supported" {
+			t.Errorf("Expected error 'permit_not_supported', got '%s'", errorResp.Error)
+		}
+	})
+
+	t.Run("DepositWithInvalidPermit", func(t *testing.T) {
+		reqBody, err := json.Marshal(DepositRequest{
+			Amount:        TestAmount,
+			FromAssetName: TestFromAsset,
+			WalletAddress: TestWalletAddress,
+			Permit: &DepositPermit{
+				Signature: "0x1234",
+				Deadline:  time.Now().Add(time.Hour).Unix(),
+			},
+		})
+		if err != nil {
+			t.Fatalf("Failed to marshal request: %v", err)
+		}
+
+		resp, err := http.Post(BaseURL+"/api/orders/deposit", "application/json", bytes.NewBuffer(reqBody))
+		if err != nil {
+			t.Fatalf("Failed to make POST request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("Expected status 400, got %d", resp.StatusCode)
+		}
+
+		var errorResp ErrorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&errorResp); err != nil {
+			t.Fatalf("Failed to decode error response: %v", err)
+		}
+
+		if errorResp.Error != "invalid_permit_signature" {
+			t.Errorf("Expected error 'invalid_permit_signature', got '%s'", errorResp.Error)
+		}
+	})
+}
+
+func TestTransactionSlippageProtection(t *testing.T) {
+	t.Run("DepositMinimumMint", func(t *testing.T) {
+		slippageBps := int64(100)
+		reqBody, err := json.Marshal(DepositRequest{
+			Amount:        TestAmount,
+			FromAssetName: TestFromAsset,
+			WalletAddress: TestWalletAddress,
+			SlippageBps:   &slippageBps,
+		})
+		if err != nil {
+			t.Fatalf("Failed to marshal request: %v", err)
+		}
+
+		resp, err := http.Post(BaseURL+"/api/orders/deposit", "application/json", bytes.NewBuffer(reqBody))
+		if err != nil {
+			t.Fatalf("Failed to make POST request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("Expected status 201, got %d", resp.StatusCode)
+		}
+
+		var depositResp DepositResponse
+		if err := json.NewDecoder(resp.Body).Decode(&depositResp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+
+		protection := depositResp.PriceProtection
+		if protection.SlippageBps != slippageBps {
+			t.Errorf("Expected slippage %d, got %d", slippageBps, protection.SlippageBps)
+		}
+
+		expected, err := strconv.ParseFloat(protection.ExpectedAmount, 64)
+		if err != nil || expected <= 0 {
+			t.Fatalf("Expected a positive expected amount, got '%s'", protection.ExpectedAmount)
+		}
+		minimum, err := strconv.ParseFloat(protection.MinimumAmount, 64)
+		if err != nil || minimum <= 0 || minimum > expected {
+			t.Errorf("Expected a minimum amount between 0 and %s, got '%s'", protection.ExpectedAmount, protection.MinimumAmount)
+		}
+
+		t.Logf("✅ Deposit expects %s LBTCv, minimum %s at rate %s", protection.ExpectedAmount, protection.MinimumAmount, protection.Rate)
+	})
+
+	t.Run("WithdrawalSlippageTooLarge", func(t *testing.T) {
+		// The atomic queue caps the discount at 1%
+		slippageBps := int64(500)
+		reqBody, err := json.Marshal(WithdrawalRequest{
+			Amount:        TestAmount,
+			ToAssetName:   TestFromAsset,
+			WalletAddress: TestWalletAddress,
+			SlippageBps:   &slippageBps,
+		})
+		if err != nil {
+			t.Fatalf("Failed to marshal request: %v", err)
+		}
+
+		resp, err := http.Post(BaseURL+"/api/orders/withdrawal", "application/json", bytes.NewBuffer(reqBody))
+		if err != nil {
+			t.Fatalf("Failed to make POST request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("Expected status 400, got %d", resp.StatusCode)
+		}
+
+		var errorResp ErrorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&errorResp); err != nil {
+			t.Fatalf("Failed to decode error response: %v", err)
+		}
+
+		if errorResp.Error != "invalid_slippage" {
+			t.Errorf("Expected error 'invalid_slippage', got '%s'", errorResp.Error)
+		}
+	})
+}
+
+func TestQuotes(t *testing.T) {
+	t.Run("DepositQuote", func(t *testing.T) {
+		resp, err := http.Get(fmt.Sprintf("%s/api/quotes/deposit?asset=%s&amount=%s", BaseURL, TestFromAsset, TestAmount))
+		if err != nil {
+			t.Fatalf("Failed to make GET request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+
+		var quote QuoteResponse
+		if err := json.NewDecoder(resp.Body).Decode(&quote); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+
+		if quote.BlockNumber == 0 {
+			t.Error("Expected a block number")
+		}
+		if !quote.ExpiresAt.After(time.Now()) {
+			t.Errorf("Expected the quote to expire in the future, got %s", quote.ExpiresAt)
+		}
+		if quote.AtomicPrice != nil {
+			t.Errorf("Expected no atomic price on a deposit quote, got %s", *quote.AtomicPrice)
+		}
+
+		expected, err := strconv.ParseFloat(quote.ExpectedAmount, 64)
+		if err != nil || expected <= 0 {
+			t.Errorf("Expected a positive expected amount, got '%s'", quote.ExpectedAmount)
+		}
+
+		t.Logf("✅ Deposit of %s %s mints %s LBTCv at block %d", quote.Amount, quote.Asset, quote.ExpectedAmount, quote.BlockNumber)
+	})
+
+	t.Run("WithdrawalQuote", func(t *testing.T) {
+		resp, err := http.Get(fmt.Sprintf("%s/api/quotes/withdrawal?asset=WBTC&amount=%s&slippage_bps=100", BaseURL, TestAmount))
+		if err != nil {
+			t.Fatalf("Failed to make GET request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+
+		var quote QuoteResponse
+		if err := json.NewDecoder(resp.Body).Decode(&quote); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+
+		if quote.AtomicPrice == nil {
+			t.Fatal("Expected an atomic price on a withdrawal quote")
+		}
+		if quote.FeeBps != nil {
+			t.Errorf("Expected no fee on a withdrawal quote, got %d", *quote.FeeBps)
+		}
+		// The request is filled at the discounted atomic price, which is all the user receives
+		if quote.ExpectedAmount != quote.MinimumAmount {
+			t.Errorf("Expected the expected amount %s to be the discounted minimum %s", quote.ExpectedAmount, quote.MinimumAmount)
+		}
+
+		t.Logf("✅ Withdrawal of %s LBTCv returns %s WBTC at atomic price %s", quote.Amount, quote.MinimumAmount, *quote.AtomicPrice)
+	})
+
+	t.Run("WithdrawalQuoteDefaultSlippage", func(t *testing.T) {
+		resp, err := http.Get(fmt.Sprintf("%s/api/quotes/withdrawal?asset=WBTC&amount=%s", BaseURL, TestAmount))
+		if err != nil {
+			t.Fatalf("Failed to make GET request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+
+		var quote QuoteResponse
+		if err := json.NewDecoder(resp.Body).Decode(&quote); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+
+		// Withdrawals default to a 1 bps discount, not the 50 bps deposit tolerance
+		if quote.SlippageBps != 1 {
+			t.Errorf("Expected the default withdrawal slippage to be 1 bps, got %d", quote.SlippageBps)
+		}
+	})
+
+	t.Run("InvalidAmount", func(t *testing.T) {
+		resp, err := http.Get(BaseURL + "/api/quotes/deposit?asset=LBTC&amount=abc")
+		if err != nil {
+			t.Fatalf("Failed to make GET request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", resp.StatusCode)
+		}
+	})
+}
+
+func TestRelayTransaction(t *testing.T) {
+	postRelay := func(t *testing.T, signedTransaction string) ErrorResponse {
+		reqBody, err := json.Marshal(RelayTransactionRequest{SignedTransaction: signedTransaction})
+		if err != nil {
+			t.Fatalf("Failed to marshal request: %v", err)
+		}
+
+		resp, err := http.Post(BaseURL+"/api/transactions", "application/json", bytes.NewBuffer(reqBody))
+		if err != nil {
+			t.Fatalf("Failed to make POST request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("Expected status 400, got %d", resp.StatusCode)
+		}
+
+		var errorResp ErrorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&errorResp); err != nil {
+			t.Fatalf("Failed to decode error response: %v", err)
+		}
+		return errorResp
+	}
+
+	t.Run("InvalidEncoding", func(t *testing.T) {
+		errorResp := postRelay(t, "0xnothex")
+		if errorResp.Error != "invalid_signed_transaction" {
+			t.Errorf("Expected error 'invalid_signed_transaction', got '%s'", errorResp.Error)
+		}
+	})
+
+	t.Run("UnknownTransaction", func(t *testing.T) {
+		// A transaction from a fresh key cannot match anything the service built
+		privateKey, err := crypto.GenerateKey()
+		if err != nil {
+			t.Fatalf("Failed to generate key: %v", err)
+		}
+
+		to := common.HexToAddress(LBTCvTokenAddress)
+		tx, err := types.SignNewTx(privateKey, types.NewLondonSigner(big.NewInt(1)), &types.DynamicFeeTx{
+			ChainID:   big.NewInt(1),
+			Nonce:     0,
+			GasTipCap: big.NewInt(1),
+			GasFeeCap: big.NewInt(1),
+			Gas:       21000,
+			To:        &to,
+			Value:     big.NewInt(0),
+		})
+		if err != nil {
+			t.Fatalf("Failed to sign transaction: %v", err)
+		}
+
+		raw, err := tx.MarshalBinary()
+		if err != nil {
+			t.Fatalf("Failed to encode transaction: %v", err)
+		}
+
+		errorResp := postRelay(t, hexutil.Encode(raw))
+		if errorResp.Error != "unknown_transaction" {
+			t.Errorf("Expected error 'unknown_transaction', got '%s'", errorResp.Error)
+		}
+	})
+
+	t.Run("TransactionNotFound", func(t *testing.T) {
+		resp, err := http.Get(BaseURL + "/api/transactions/0x0000000000000000000000000000000000000000000000000000000000000001")
+		if err != nil {
+			t.Fatalf("Failed to make GET request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", resp.StatusCode)
+		}
+	})
+}
+
+func TestSmartAccountOutputFormats(t *testing.T) {
+	testCases := []struct {
+		name          string
+		endpoint      string
+		request       interface{}
+		expectedError string
+	}{
+		{
+			name:     "InvalidOutputFormat",
+			endpoint: "/api/orders/deposit",
+			request: DepositRequest{
+				Amount:        TestAmount,
+				FromAssetName: TestFromAsset,
+				WalletAddress: TestWalletAddress,
+				OutputFormat:  "multisig",
+			},
+			expectedError: "invalid_output_format",
+		},
+		{
+			// The test wallet is an externally owned account
+			name:     "SafeFormatForEOA",
+			endpoint: "/api/orders/deposit",
+			request: DepositRequest{
+				Amount:        TestAmount,
+				FromAssetName: TestFromAsset,
+				WalletAddress: TestWalletAddress,
+				OutputFormat:  "safe",
+			},
+			expectedError: "not_smart_account",
+		},
+		{
+			name:     "UserOperationForEOA",
+			endpoint: "/api/orders/withdrawal",
+			request: WithdrawalRequest{
+				Amount:        TestWithdrawalAmount,
+				ToAssetName:   TestToAsset,
+				WalletAddress: TestWalletAddress,
+				OutputFormat:  "erc4337",
+			},
+			expectedError: "not_smart_account",
+		},
+		{
+			name:     "PermitWithSafeFormat",
+			endpoint: "/api/orders/deposit",
+			request: DepositRequest{
+				Amount:        TestAmount,
+				FromAssetName: TestFromAsset,
+				WalletAddress: TestWalletAddress,
+				OutputFormat:  "safe",
+				Permit: &DepositPermit{
+					Signature: "0x" + strings.Repeat("00", 65),
+					Deadline:  time.Now().Add(time.Hour).Unix(),
+				},
+			},
+			expectedError: "permit_not_supported",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			reqBody, err := json.Marshal(tc.request)
+			if err != nil {
+				t.Fatalf("Failed to marshal request: %v", err)
+			}
+
+			resp, err := http.Post(BaseURL+tc.endpoint, "application/json", bytes.NewBuffer(reqBody))
+			if err != nil {
+				t.Fatalf("Failed to make POST request: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != http.StatusBadRequest {
+				t.Fatalf("Expected status 400, got %d", resp.StatusCode)
+			}
+
+			var errorResp ErrorResponse
+			if err := json.NewDecoder(resp.Body).Decode(&errorResp); err != nil {
+				t.Fatalf("Failed to decode error response: %v", err)
+			}
+
+			if errorResp.Error != tc.expectedError {
+				t.Errorf("Expected error '%s', got '%s'", tc.expectedError, errorResp.Error)
+			}
+		})
+	}
+}
+
+func TestDecodeCalldata(t *testing.T) {
+	t.Run("DecodeBuiltDeposit", func(t *testing.T) {
+		reqBody, err := json.Marshal(DepositRequest{
+			Amount:        TestAmount,
+			FromAssetName: TestFromAsset,
+			WalletAddress: TestWalletAddress,
+		})
+		if err != nil {
+			t.Fatalf("Failed to marshal request: %v", err)
+		}
+
+		resp, err := http.Post(BaseURL+"/api/orders/deposit", "application/json", bytes.NewBuffer(reqBody))
+		if err != nil {
+			t.Fatalf("Failed to make POST request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("Expected status 201, got %d", resp.StatusCode)
+		}
+
+		var depositResp DepositResponse
+		if err := json.NewDecoder(resp.Body).Decode(&depositResp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+
+		// Decode every step: the approval, if any, and the deposit
+		for _, step := range depositResp.Transactions {
+			decodeBody, err := json.Marshal(DecodeRequest{
+				To:   step.UnsignedTransaction.To,
+				Data: step.UnsignedTransaction.Data,
+			})
+			if err != nil {
+				t.Fatalf("Failed to marshal request: %v", err)
+			}
+
+			decodeResp, err := http.Post(BaseURL+"/api/decode", "application/json", bytes.NewBuffer(decodeBody))
+			if err != nil {
+				t.Fatalf("Failed to make POST request: %v", err)
+			}
+			defer decodeResp.Body.Close()
+
+			if decodeResp.StatusCode != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d", decodeResp.StatusCode)
+			}
+
+			var decoded DecodeResponse
+			if err := json.NewDecoder(decodeResp.Body).Decode(&decoded); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+
+			switch step.Type {
+			case "approve":
+				if decoded.Contract != TestFromAsset || decoded.Function != "approve" {
+					t.Errorf("Expected %s approve, got %s %s", TestFromAsset, decoded.Contract, decoded.Function)
+				}
+			case "deposit":
+				if decoded.Contract != "Teller" || decoded.Function != "deposit" {
+					t.Fatalf("Expected Teller deposit, got %s %s", decoded.Contract, decoded.Function)
+				}
+				if len(decoded.Arguments) != 3 {
+					t.Fatalf("Expected 3 arguments, got %d", len(decoded.Arguments))
+				}
+				if decoded.Arguments[0].Symbol != TestFromAsset {
+					t.Errorf("Expected deposit asset %s, got '%s'", TestFromAsset, decoded.Arguments[0].Symbol)
+				}
+				if decoded.Arguments[1].Value != "10" {
+					t.Errorf("Expected deposit amount 10, got %v", decoded.Arguments[1].Value)
+				}
+			}
+
+			t.Logf("✅ Decoded %s step as %s", step.Type, decoded.Signature)
+		}
+	})
+
+	t.Run("DecodeSmartAccountBatches", func(t *testing.T) {
+		batchABI, err := abi.JSON(strings.NewReader(`[
+			{"type": "function", "name": "multiSend", "inputs": [{"name": "transactions", "type": "bytes"}], "outputs": []},
+			{"type": "function", "name": "executeBatch", "inputs": [{"name": "dest", "type": "address[]"}, {"name": "value", "type": "uint256[]"}, {"name": "func", "type": "bytes[]"}], "outputs": []}
+		]`))
+		if err != nil {
+			t.Fatalf("Failed to parse ABI: %v", err)
+		}
+
+		// Two approvals of the vault shares, as a Safe proposal and as a UserOperation would batch them
+		token := common.HexToAddress(LBTCvTokenAddress)
+		approve := common.FromHex("0x095ea7b3" + strings.Repeat("00", 12) + strings.ToLower(TestWalletAddress[2:]) + strings.Repeat("00", 31) + "0a")
+
+		var packed []byte
+		for i := 0; i < 2; i++ {
+			packed = append(packed, 0)
+			packed = append(packed, token.Bytes()...)
+			packed = append(packed, make([]byte, 32)...)
+			packed = append(packed, common.LeftPadBytes(big.NewInt(int64(len(approve))).Bytes(), 32)...)
+			packed = append(packed, approve...)
+		}
+		multiSend, err := batchABI.Pack("multiSend", packed)
+		if err != nil {
+			t.Fatalf("Failed to pack multiSend: %v", err)
+		}
+		executeBatch, err := batchABI.Pack("executeBatch", []common.Address{token, token}, []*big.Int{big.NewInt(0), big.NewInt(0)}, [][]byte{approve, approve})
+		if err != nil {
+			t.Fatalf("Failed to pack executeBatch: %v", err)
+		}
+
+		for _, tc := range []struct {
+			request  DecodeRequest
+			contract string
+		}{
+			{DecodeRequest{To: "0x40A2aCCbd92BCA938b02010E17A5b8929b49130D", Data: hexutil.Encode(multiSend)}, "MultiSendCallOnly"},
+			{DecodeRequest{To: TestWalletAddress, Data: hexutil.Encode(executeBatch)}, "SmartAccount"},
+		} {
+			reqBody, err := json.Marshal(tc.request)
+			if err != nil {
+				t.Fatalf("Failed to marshal request: %v", err)
+			}
+
+			resp, err := http.Post(BaseURL+"/api/decode", "application/json", bytes.NewBuffer(reqBody))
+			if err != nil {
+				t.Fatalf("Failed to make POST request: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+			}
+
+			var decoded DecodeResponse
+			if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+
+			if decoded.Contract != tc.contract {
+				t.Errorf("Expected contract %s, got %s", tc.contract, decoded.Contract)
+			}
+			if len(decoded.Calls) != 2 {
+				t.Fatalf("Expected 2 inner calls, got %d", len(decoded.Calls))
+			}
+			for _, call := range decoded.Calls {
+				if call.Function != "approve" || call.Value != "0" {
+					t.Errorf("Expected approve with no value, got %s with value %s", call.Function, call.Value)
+				}
+			}
+
+			t.Logf("✅ Decoded %s batch of %d calls", decoded.Contract, len(decoded.Calls))
+		}
+	})
+
+	testCases := []struct {
+		name          string
+		request       DecodeRequest
+		expectedError string
+	}{
+		{
+			name:          "MissingTo",
+			request:       DecodeRequest{Data: "0x095ea7b3"},
+			expectedError: "missing_to",
+		},
+		{
+			name:          "InvalidData",
+			request:       DecodeRequest{To: LBTCvTokenAddress, Data: "not hex"},
+			expectedError: "invalid_data",
+		},
+		{
+			name:          "UnknownContract",
+			request:       DecodeRequest{To: TestWalletAddress, Data: "0x095ea7b3"},
+			expectedError: "unknown_contract",
+		},
+		{
+			name:          "UnknownFunction",
+			request:       DecodeRequest{To: LBTCvTokenAddress, Data: "0xdeadbeef"},
+			expectedError: "unknown_function",
+		},
+		{
+			name:          "TruncatedArguments",
+			request:       DecodeRequest{To: LBTCvTokenAddress, Data: "0x095ea7b3" + strings.Repeat("00", 32)},
+			expectedError: "invalid_calldata",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			reqBody, err := json.Marshal(tc.request)
+			if err != nil {
+				t.Fatalf("Failed to marshal request: %v", err)
+			}
+
+			resp, err := http.Post(BaseURL+"/api/decode", "application/json", bytes.NewBuffer(reqBody))
+			if err != nil {
+				t.Fatalf("Failed to make POST request: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != http.StatusBadRequest {
+				t.Fatalf("Expected status 400, got %d", resp.StatusCode)
+			}
+
+			var errorResp ErrorResponse
+			if err := json.NewDecoder(resp.Body).Decode(&errorResp); err != nil {
+				t.Fatalf("Failed to decode error response: %v", err)
+			}
+
+			if errorResp.Error != tc.expectedError {
+				t.Errorf("Expected error '%s', got '%s'", tc.expectedError, errorResp.Error)
+			}
+		})
+	}
 }
@@ -0,0 +1,77 @@
+package test
+
+import (
+	"math/big"
+	"testing"
+
+	"yield/apps/yield/internal/position"
+)
+
+// These tests exercise the position ledger directly and do not need the server
+
+func rat(t *testing.T, value string) *big.Rat {
+	t.Helper()
+	r, ok := new(big.Rat).SetString(value)
+	if !ok {
+		t.Fatalf("Invalid decimal %s", value)
+	}
+	return r
+}
+
+func expectRat(t *testing.T, name string, got *big.Rat, expected string) {
+	t.Helper()
+	if got.Cmp(rat(t, expected)) != 0 {
+		t.Errorf("Expected %s to be %s, got %s", name, expected, got.FloatString(8))
+	}
+}
+
+func TestLedgerWithdrawalRealizesYield(t *testing.T) {
+	ledger := position.NewLedger()
+	ledger.Add("LBTC", rat(t, "1"), rat(t, "1"))
+	ledger.Add("WBTC", rat(t, "3"), rat(t, "3"))
+
+	// Half the shares leave at a 10% gain: each lot gives up half its shares and cost basis
+	ledger.Remove(rat(t, "2"), rat(t, "2.2"))
+
+	expectRat(t, "shares", ledger.Shares(), "2")
+
+	lots := ledger.Lots()
+	if len(lots) != 2 || lots[0].Asset != "LBTC" || lots[1].Asset != "WBTC" {
+		t.Fatalf("Expected LBTC and WBTC lots in opening order, got %+v", lots)
+	}
+	expectRat(t, "LBTC shares", lots[0].Shares, "0.5")
+	expectRat(t, "LBTC cost basis", lots[0].CostBasis, "0.5")
+	expectRat(t, "LBTC realized yield", lots[0].RealizedYield, "0.05")
+	expectRat(t, "WBTC shares", lots[1].Shares, "1.5")
+	expectRat(t, "WBTC cost basis", lots[1].CostBasis, "1.5")
+	expectRat(t, "WBTC realized yield", lots[1].RealizedYield, "0.15")
+}
+
+func TestLedgerTransferOutRealizesNothing(t *testing.T) {
+	ledger := position.NewLedger()
+	ledger.Add("LBTC", rat(t, "2"), rat(t, "1.9"))
+
+	ledger.Remove(rat(t, "0.5"), nil)
+
+	lot := ledger.Lots()[0]
+	expectRat(t, "shares", lot.Shares, "1.5")
+	expectRat(t, "cost basis", lot.CostBasis, "1.425")
+	expectRat(t, "realized yield", lot.RealizedYield, "0")
+}
+
+func TestLedgerRemoveMoreThanHeld(t *testing.T) {
+	ledger := position.NewLedger()
+	ledger.Add("CBTC", rat(t, "1"), rat(t, "1"))
+
+	// Only the held share is attributed, so half the proceeds
+	ledger.Remove(rat(t, "2"), rat(t, "2.4"))
+
+	lot := ledger.Lots()[0]
+	expectRat(t, "shares", lot.Shares, "0")
+	expectRat(t, "cost basis", lot.CostBasis, "0")
+	expectRat(t, "realized yield", lot.RealizedYield, "0.2")
+
+	// An empty ledger ignores further removals
+	ledger.Remove(rat(t, "1"), rat(t, "1"))
+	expectRat(t, "realized yield", lot.RealizedYield, "0.2")
+}
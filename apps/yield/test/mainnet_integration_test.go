@@ -85,11 +85,6 @@ func (ch *ChainHelper) SignTransaction(unsignedTx UnsignedTransaction, privateKe
 		return nil, fmt.Errorf("invalid gas limit: %s", unsignedTx.GasLimit)
 	}
 
-	gasPrice, ok := new(big.Int).SetString(strings.TrimPrefix(unsignedTx.GasPrice, "0x"), 16)
-	if !ok {
-		return nil, fmt.Errorf("invalid gas price: %s", unsignedTx.GasPrice)
-	}
-
 	nonce, err := strconv.ParseUint(strings.TrimPrefix(unsignedTx.Nonce, "0x"), 16, 64)
 	if err != nil {
 		return nil, fmt.Errorf("invalid nonce: %s", unsignedTx.Nonce)
@@ -106,17 +101,48 @@ func (ch *ChainHelper) SignTransaction(unsignedTx UnsignedTransaction, privateKe
 	}
 
 	// Create the transaction
-	tx := types.NewTransaction(
-		nonce,
-		to,
-		value,
-		gasLimit,
-		gasPrice,
-		data,
-	)
+	var tx *types.Transaction
+	var signer types.Signer
+	if unsignedTx.Type == "0x2" {
+		maxFeePerGas, ok := new(big.Int).SetString(strings.TrimPrefix(unsignedTx.MaxFeePerGas, "0x"), 16)
+		if !ok {
+			return nil, fmt.Errorf("invalid max fee per gas: %s", unsignedTx.MaxFeePerGas)
+		}
+
+		maxPriorityFeePerGas, ok := new(big.Int).SetString(strings.TrimPrefix(unsignedTx.MaxPriorityFeePerGas, "0x"), 16)
+		if !ok {
+			return nil, fmt.Errorf("invalid max priority fee per gas: %s", unsignedTx.MaxPriorityFeePerGas)
+		}
+
+		tx = types.NewTx(&types.DynamicFeeTx{
+			ChainID:   chainID,
+			Nonce:     nonce,
+			GasTipCap: maxPriorityFeePerGas,
+			GasFeeCap: maxFeePerGas,
+			Gas:       gasLimit,
+			To:        &to,
+			Value:     value,
+			Data:      data,
+		})
+		signer = types.NewLondonSigner(chainID)
+	} else {
+		gasPrice, ok := new(big.Int).SetString(strings.TrimPrefix(unsignedTx.GasPrice, "0x"), 16)
+		if !ok {
+			return nil, fmt.Errorf("invalid gas price: %s", unsignedTx.GasPrice)
+		}
+
+		tx = types.NewTransaction(
+			nonce,
+			to,
+			value,
+			gasLimit,
+			gasPrice,
+			data,
+		)
+		signer = types.NewEIP155Signer(chainID)
+	}
 
 	// Sign the transaction
-	signer := types.NewEIP155Signer(chainID)
 	signedTx, err := types.SignTx(tx, signer, privateKey)
 	if err != nil {
 		return nil, fmt.Errorf("failed to sign transaction: %w", err)
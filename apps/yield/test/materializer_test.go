@@ -0,0 +1,100 @@
+package test
+
+import (
+	"testing"
+
+	"go.uber.org/zap"
+	"yield/apps/yield/internal/events"
+	"yield/apps/yield/internal/model"
+	"yield/apps/yield/internal/repository"
+	"yield/apps/yield/internal/transfer_materializer"
+)
+
+func TestMaterializerParksEarlyEvents(t *testing.T) {
+	db := newTestDatabase(t)
+	logger := zap.NewNop()
+	crawlerRepository := repository.NewCrawlerRepository(db, logger)
+	orderRepository := repository.NewOrderRepository(db, logger)
+
+	// The consumer is never started; events are fed through ProcessEvent
+	materializer, err := transfer_materializer.NewTransferMaterializer("localhost:9092", "transfer-events", logger, orderRepository, nil)
+	if err != nil {
+		t.Fatalf("Failed to create materializer: %v", err)
+	}
+	t.Cleanup(func() { materializer.Close() })
+
+	first := storeSentEvent(t, crawlerRepository, model.OutboxEvent{
+		TxHash: "0xfirst", EventType: "deposit", BlockNumber: 100, LogIndex: 2,
+		Amount: "1", FromAssetName: "LBTC", ToAssetName: "LBTCv",
+	}, map[string]string{"share_amount": "100000000"})
+	second := storeSentEvent(t, crawlerRepository, model.OutboxEvent{
+		TxHash: "0xsecond", EventType: "deposit", BlockNumber: 101, LogIndex: 0,
+		Amount: "2", FromAssetName: "LBTC", ToAssetName: "LBTCv",
+	}, map[string]string{"share_amount": "200000000"})
+
+	// The second deposit arrives first and waits for the first
+	if err := materializer.ProcessEvent(events.NewTransferEvent(second)); err != nil {
+		t.Fatalf("Failed to process early event: %v", err)
+	}
+	if orders, err := orderRepository.ListOrdersByWalletInChainOrder(TestWalletAddress); err != nil || len(orders) != 0 {
+		t.Fatalf("Expected the early event to be parked, got %+v (%v)", orders, err)
+	}
+	if parked, err := orderRepository.GetParkedEvents(TestWalletAddress); err != nil || len(parked) != 1 {
+		t.Fatalf("Expected 1 parked event, got %d (%v)", len(parked), err)
+	}
+
+	// The first deposit fills the gap and releases the second
+	if err := materializer.ProcessEvent(events.NewTransferEvent(first)); err != nil {
+		t.Fatalf("Failed to process event: %v", err)
+	}
+	orders, err := orderRepository.ListOrdersByWalletInChainOrder(TestWalletAddress)
+	if err != nil {
+		t.Fatalf("Failed to list orders: %v", err)
+	}
+	if len(orders) != 2 || orders[0].TxHash != "0xfirst" || orders[1].TxHash != "0xsecond" {
+		t.Fatalf("Expected both deposits in chain order, got %+v", orders)
+	}
+	if parked, err := orderRepository.GetParkedEvents(TestWalletAddress); err != nil || len(parked) != 0 {
+		t.Errorf("Expected no parked events, got %d (%v)", len(parked), err)
+	}
+
+	block, logIndex, found, err := orderRepository.GetWalletWatermark(TestWalletAddress)
+	if err != nil || !found || block != 101 || logIndex != 0 {
+		t.Errorf("Expected watermark 101/0, got %d/%d found=%t (%v)", block, logIndex, found, err)
+	}
+
+	// A redelivery behind the watermark is skipped
+	if err := materializer.ProcessEvent(events.NewTransferEvent(first)); err != nil {
+		t.Errorf("Expected a redelivered event to be skipped, got %v", err)
+	}
+	if count, err := repository.NewOrderProjectionRepository(db, logger).CountOrders(repository.OrdersTable); err != nil || count != 2 {
+		t.Errorf("Expected 2 orders after the redelivery, got %d (%v)", count, err)
+	}
+}
+
+func TestMaterializerReplacesRepeatedWithdrawalRequest(t *testing.T) {
+	db := newTestDatabase(t)
+	logger := zap.NewNop()
+	crawlerRepository := repository.NewCrawlerRepository(db, logger)
+	orderRepository := repository.NewOrderRepository(db, logger)
+	materializer := transfer_materializer.NewReplayMaterializer(logger, orderRepository)
+
+	// A second request for the same shares replaces the first, which is still in progress
+	for _, event := range []model.OutboxEvent{
+		{TxHash: "0xrequest", EventType: "withdrawal_requested", BlockNumber: 100, LogIndex: 0, Amount: "0.5", FromAssetName: "LBTCv", ToAssetName: "LBTC"},
+		{TxHash: "0xrerequest", EventType: "withdrawal_requested", BlockNumber: 200, LogIndex: 1, Amount: "0.5", FromAssetName: "LBTCv", ToAssetName: "LBTC"},
+	} {
+		stored := storeSentEvent(t, crawlerRepository, event, map[string]string{"min_price": "100000000"})
+		if err := materializer.ProcessEvent(events.NewTransferEvent(stored)); err != nil {
+			t.Fatalf("Failed to process %s: %v", event.TxHash, err)
+		}
+	}
+
+	orders, err := orderRepository.ListOrdersByWalletInChainOrder(TestWalletAddress)
+	if err != nil {
+		t.Fatalf("Failed to list orders: %v", err)
+	}
+	if len(orders) != 1 || orders[0].TxHash != "0xrerequest" || orders[0].LogIndex != 1 || orders[0].Status != "in_progress" {
+		t.Fatalf("Expected the request to move to the second transaction, got %+v", orders)
+	}
+}
@@ -0,0 +1,237 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+	"yield/apps/yield/internal/assets"
+	"yield/apps/yield/internal/pricing"
+)
+
+// These tests read mock Chainlink aggregators on a simulated chain and do not need the server
+
+// Runtime code of a mock aggregator: decimals() returns storage slot 0, and latestRoundData() returns
+// slots 1 to 5 as roundId, answer, startedAt, updatedAt and answeredInRound
+const mockAggregatorCode = "0x60003560e01c8063313ce56714601d5763feaf968c146029576000" +
+	"80fd5b60005460005260206000f35b600154600052600254602052600354604052600454606052600554608052" +
+	"60a06000f3"
+
+// Mock aggregator addresses
+var (
+	btcUSDFeed      = common.HexToAddress("0x00000000000000000000000000000000000f0001")
+	lbtcPegFeed     = common.HexToAddress("0x00000000000000000000000000000000000f0002")
+	staleFeed       = common.HexToAddress("0x00000000000000000000000000000000000f0003")
+	negativeFeed    = common.HexToAddress("0x00000000000000000000000000000000000f0004")
+	carriedOverFeed = common.HexToAddress("0x00000000000000000000000000000000000f0005")
+	incompleteFeed  = common.HexToAddress("0x00000000000000000000000000000000000f0006")
+)
+
+// A chain ID no price feeds are configured for
+const unconfiguredChain = 999999
+
+type mockRound struct {
+	decimals        int64
+	roundID         int64
+	answer          int64
+	updatedAt       time.Time // Zero for a round that has not been answered
+	answeredInRound int64
+}
+
+func mockAggregator(round mockRound) types.Account {
+	slot := func(value *big.Int) common.Hash {
+		return common.BytesToHash(math.U256Bytes(value))
+	}
+
+	updatedAt := int64(0)
+	if !round.updatedAt.IsZero() {
+		updatedAt = round.updatedAt.Unix()
+	}
+
+	return types.Account{
+		Code:    common.FromHex(mockAggregatorCode),
+		Balance: big.NewInt(0),
+		Storage: map[common.Hash]common.Hash{
+			slot(big.NewInt(0)): slot(big.NewInt(round.decimals)),
+			slot(big.NewInt(1)): slot(big.NewInt(round.roundID)),
+			slot(big.NewInt(2)): slot(big.NewInt(round.answer)),
+			slot(big.NewInt(3)): slot(big.NewInt(updatedAt)),
+			slot(big.NewInt(4)): slot(big.NewInt(updatedAt)),
+			slot(big.NewInt(5)): slot(big.NewInt(round.answeredInRound)),
+		},
+	}
+}
+
+// newPricingChain starts a simulated chain holding the mock aggregators and returns an oracle reading it
+// with the given feeds
+func newPricingChain(t *testing.T, feeds *assets.PriceFeeds) *pricing.Oracle {
+	t.Helper()
+
+	now := time.Now()
+	backend := simulated.NewBackend(types.GenesisAlloc{
+		// 60000.12345678 USD, 8 decimals like the mainnet feed
+		btcUSDFeed: mockAggregator(mockRound{decimals: 8, roundID: 10, answer: 6000012345678, updatedAt: now.Add(-time.Minute), answeredInRound: 10}),
+		// 0.9995 BTC, 18 decimals
+		lbtcPegFeed:     mockAggregator(mockRound{decimals: 18, roundID: 7, answer: 999500000000000000, updatedAt: now.Add(-2 * time.Minute), answeredInRound: 7}),
+		staleFeed:       mockAggregator(mockRound{decimals: 8, roundID: 3, answer: 100000000, updatedAt: now.Add(-3 * time.Hour), answeredInRound: 3}),
+		negativeFeed:    mockAggregator(mockRound{decimals: 8, roundID: 4, answer: -1, updatedAt: now, answeredInRound: 4}),
+		carriedOverFeed: mockAggregator(mockRound{decimals: 8, roundID: 5, answer: 100000000, updatedAt: now, answeredInRound: 4}),
+		incompleteFeed:  mockAggregator(mockRound{decimals: 8, roundID: 6, answer: 100000000, answeredInRound: 6}),
+	})
+	t.Cleanup(func() { backend.Close() })
+
+	// Blocks after genesis carry the current time, which staleness is measured against
+	backend.Commit()
+
+	client := backend.Client()
+	chainID, err := client.ChainID(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to get chain ID: %v", err)
+	}
+
+	registry := assets.NewAssetRegistry()
+	registry.SetPriceFeeds(chainID.Uint64(), feeds)
+
+	oracle, err := pricing.NewOracle(client, registry)
+	if err != nil {
+		t.Fatalf("Failed to create oracle: %v", err)
+	}
+	return oracle
+}
+
+func feed(address common.Address) assets.PriceFeed {
+	return assets.PriceFeed{Address: address, MaxAge: time.Hour}
+}
+
+func TestPriceFeedValuation(t *testing.T) {
+	oracle := newPricingChain(t, &assets.PriceFeeds{
+		BTCUSD:  feed(btcUSDFeed),
+		BTCPegs: map[string]assets.PriceFeed{"LBTC": feed(lbtcPegFeed)},
+	})
+
+	quote, err := oracle.Quote(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("Failed to read quote: %v", err)
+	}
+
+	expectRat(t, "BTC price", quote.BTCPriceUSD.Value, "60000.12345678")
+	if quote.BTCPriceUSD.RoundID.Int64() != 10 {
+		t.Errorf("Expected round 10, got %s", quote.BTCPriceUSD.RoundID)
+	}
+
+	// LBTC goes through its peg
+	btc, usd, err := quote.Value("LBTC", rat(t, "2"))
+	if err != nil {
+		t.Fatalf("Failed to value LBTC: %v", err)
+	}
+	expectRat(t, "LBTC value in BTC", btc, "1.999")
+	expectRat(t, "LBTC value in USD", usd, "119940.24679010322")
+
+	// CBTC has no peg feed and is valued at par
+	if quote.Peg("CBTC") != nil {
+		t.Error("Expected CBTC to have no peg")
+	}
+	btc, usd, err = quote.Value("CBTC", rat(t, "0.5"))
+	if err != nil {
+		t.Fatalf("Failed to value CBTC: %v", err)
+	}
+	expectRat(t, "CBTC value in BTC", btc, "0.5")
+	expectRat(t, "CBTC value in USD", usd, "30000.06172839")
+
+	// The peg is the older of the two answers
+	if !quote.OldestUpdate().Equal(quote.Peg("LBTC").UpdatedAt) {
+		t.Errorf("Expected oldest update %s, got %s", quote.Peg("LBTC").UpdatedAt, quote.OldestUpdate())
+	}
+}
+
+func TestPriceFeedStaleness(t *testing.T) {
+	t.Run("StaleBTCPrice", func(t *testing.T) {
+		oracle := newPricingChain(t, &assets.PriceFeeds{BTCUSD: feed(staleFeed)})
+
+		if _, err := oracle.Quote(context.Background(), nil); !errors.Is(err, pricing.ErrStalePrice) {
+			t.Errorf("Expected a stale price error, got %v", err)
+		}
+	})
+
+	t.Run("StalePeg", func(t *testing.T) {
+		oracle := newPricingChain(t, &assets.PriceFeeds{
+			BTCUSD:  feed(btcUSDFeed),
+			BTCPegs: map[string]assets.PriceFeed{"LBTC": feed(staleFeed)},
+		})
+
+		quote, err := oracle.Quote(context.Background(), nil)
+		if err != nil {
+			t.Fatalf("Expected the quote to survive a stale peg, got %v", err)
+		}
+
+		if _, _, err := quote.Value("LBTC", rat(t, "1")); !errors.Is(err, pricing.ErrStalePrice) {
+			t.Errorf("Expected a stale price error valuing LBTC, got %v", err)
+		}
+
+		// Other assets are still valued
+		if _, _, err := quote.Value("WBTC", rat(t, "1")); err != nil {
+			t.Errorf("Expected WBTC to be valued at par, got %v", err)
+		}
+	})
+
+	t.Run("LongerMaxAge", func(t *testing.T) {
+		oracle := newPricingChain(t, &assets.PriceFeeds{
+			BTCUSD: assets.PriceFeed{Address: staleFeed, MaxAge: 4 * time.Hour},
+		})
+
+		if _, err := oracle.Quote(context.Background(), nil); err != nil {
+			t.Errorf("Expected a 3 hour old answer to pass a 4 hour max age, got %v", err)
+		}
+	})
+}
+
+func TestPriceFeedInvalidAnswers(t *testing.T) {
+	tests := []struct {
+		name     string
+		feed     common.Address
+		expected error
+	}{
+		{"NonPositiveAnswer", negativeFeed, pricing.ErrInvalidPrice},
+		{"IncompleteRound", incompleteFeed, pricing.ErrInvalidPrice},
+		{"CarriedOverRound", carriedOverFeed, pricing.ErrStalePrice},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oracle := newPricingChain(t, &assets.PriceFeeds{BTCUSD: feed(tt.feed)})
+
+			if _, err := oracle.Quote(context.Background(), nil); !errors.Is(err, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestPriceFeedsUnconfiguredChain(t *testing.T) {
+	if _, exists := assets.NewAssetRegistry().GetPriceFeeds(unconfiguredChain); exists {
+		t.Fatalf("Expected no price feeds for chain %d", unconfiguredChain)
+	}
+
+	// The mainnet feeds are configured out of the box
+	if _, exists := assets.NewAssetRegistry().GetPriceFeeds(1); !exists {
+		t.Error("Expected price feeds for Ethereum mainnet")
+	}
+
+	backend := simulated.NewBackend(types.GenesisAlloc{})
+	t.Cleanup(func() { backend.Close() })
+
+	oracle, err := pricing.NewOracle(backend.Client(), assets.NewAssetRegistry())
+	if err != nil {
+		t.Fatalf("Failed to create oracle: %v", err)
+	}
+
+	if _, err := oracle.Quote(context.Background(), nil); !errors.Is(err, pricing.ErrNoPriceFeeds) {
+		t.Errorf("Expected a missing price feeds error, got %v", err)
+	}
+}
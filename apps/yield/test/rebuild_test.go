@@ -0,0 +1,359 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+	_ "github.com/lib/pq"
+	"go.uber.org/zap"
+	"yield/apps/yield/internal/events"
+	"yield/apps/yield/internal/model"
+	"yield/apps/yield/internal/order_rebuilder"
+	"yield/apps/yield/internal/repository"
+)
+
+// These tests need the test database but not the server
+
+// newTestDatabase migrates a fresh schema in the test database and drops it when the test ends
+func newTestDatabase(t *testing.T) *sql.DB {
+	t.Helper()
+
+	admin, err := sql.Open("postgres", TestDatabaseURL)
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	t.Cleanup(func() { admin.Close() })
+
+	if err := admin.Ping(); err != nil {
+		t.Skipf("Test database is not available: %v", err)
+	}
+
+	schema := fmt.Sprintf("test_%d", time.Now().UnixNano())
+	if _, err := admin.Exec(fmt.Sprintf("CREATE SCHEMA %s", schema)); err != nil {
+		t.Fatalf("Failed to create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		if _, err := admin.Exec(fmt.Sprintf("DROP SCHEMA %s CASCADE", schema)); err != nil {
+			t.Errorf("Failed to drop schema %s: %v", schema, err)
+		}
+	})
+
+	db, err := sql.Open("postgres", TestDatabaseURL+"&search_path="+schema)
+	if err != nil {
+		t.Fatalf("Failed to open test schema: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if err := repository.InitMigration(db); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+	return db
+}
+
+// storeSentEvent stores a published event, for the test wallet unless it names another, and returns it as
+// stored
+func storeSentEvent(t *testing.T, crawlerRepository *repository.CrawlerRepository, event model.OutboxEvent, blob map[string]string) model.OutboxEvent {
+	t.Helper()
+
+	eventBlob, err := json.Marshal(blob)
+	if err != nil {
+		t.Fatalf("Failed to marshal event blob: %v", err)
+	}
+	event.Status = "sent"
+	if event.Address == "" {
+		event.Address = TestWalletAddress
+	}
+	event.EventBlob = eventBlob
+	event.TxDate = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC).Add(time.Duration(event.BlockNumber) * time.Second)
+
+	if err := crawlerRepository.StoreOutboxEvent(event); err != nil {
+		t.Fatalf("Failed to store %s event: %v", event.EventType, err)
+	}
+	return event
+}
+
+// newTestTopic creates a fresh topic on the test Kafka broker and deletes it when the test ends
+func newTestTopic(t *testing.T, partitions int) string {
+	t.Helper()
+
+	admin, err := kafka.NewAdminClient(&kafka.ConfigMap{"bootstrap.servers": TestKafkaBroker})
+	if err != nil {
+		t.Fatalf("Failed to create Kafka admin client: %v", err)
+	}
+	t.Cleanup(admin.Close)
+
+	if _, err := admin.GetMetadata(nil, true, 5000); err != nil {
+		t.Skipf("Test Kafka broker is not available: %v", err)
+	}
+
+	topic := fmt.Sprintf("test-%d", time.Now().UnixNano())
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	results, err := admin.CreateTopics(ctx, []kafka.TopicSpecification{{Topic: topic, NumPartitions: partitions, ReplicationFactor: 1}})
+	if err != nil {
+		t.Fatalf("Failed to create topic: %v", err)
+	}
+	if results[0].Error.Code() != kafka.ErrNoError {
+		t.Fatalf("Failed to create topic: %v", results[0].Error)
+	}
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		admin.DeleteTopics(ctx, []string{topic})
+	})
+	return topic
+}
+
+// publishEvents publishes stored outbox events to a topic keyed by wallet, as the event publisher does
+func publishEvents(t *testing.T, topic string, outboxEvents ...model.OutboxEvent) {
+	t.Helper()
+
+	producer, err := kafka.NewProducer(&kafka.ConfigMap{"bootstrap.servers": TestKafkaBroker, "acks": "all"})
+	if err != nil {
+		t.Fatalf("Failed to create Kafka producer: %v", err)
+	}
+	defer producer.Close()
+
+	for _, event := range outboxEvents {
+		message, err := json.Marshal(events.NewTransferEvent(event))
+		if err != nil {
+			t.Fatalf("Failed to marshal %s event: %v", event.EventType, err)
+		}
+
+		deliveryChan := make(chan kafka.Event, 1)
+		if err := producer.Produce(&kafka.Message{
+			TopicPartition: kafka.TopicPartition{Topic: &topic, Partition: kafka.PartitionAny},
+			Key:            []byte(event.Address),
+			Value:          message,
+		}, deliveryChan); err != nil {
+			t.Fatalf("Failed to produce %s event: %v", event.EventType, err)
+		}
+		if delivered := (<-deliveryChan).(*kafka.Message); delivered.TopicPartition.Error != nil {
+			t.Fatalf("Failed to deliver %s event: %v", event.EventType, delivered.TopicPartition.Error)
+		}
+	}
+}
+
+func tableExists(t *testing.T, db *sql.DB, table string) bool {
+	t.Helper()
+
+	var exists bool
+	if err := db.QueryRow(`SELECT to_regclass($1) IS NOT NULL`, table).Scan(&exists); err != nil {
+		t.Fatalf("Failed to look up table %s: %v", table, err)
+	}
+	return exists
+}
+
+func TestRebuildReplaysOutboxAndSwaps(t *testing.T) {
+	db := newTestDatabase(t)
+	logger := zap.NewNop()
+	crawlerRepository := repository.NewCrawlerRepository(db, logger)
+	projectionRepository := repository.NewOrderProjectionRepository(db, logger)
+	orderRepository := repository.NewOrderRepository(db, logger)
+
+	storeSentEvent(t, crawlerRepository, model.OutboxEvent{
+		TxHash: "0xdeposit", EventType: "deposit", BlockNumber: 100, LogIndex: 1,
+		Amount: "1.5", FromAssetName: "LBTC", ToAssetName: "LBTCv",
+	}, map[string]string{"share_amount": "150000000"})
+	storeSentEvent(t, crawlerRepository, model.OutboxEvent{
+		TxHash: "0xrequest", EventType: "withdrawal_requested", BlockNumber: 200, LogIndex: 3,
+		Amount: "0.5", FromAssetName: "LBTCv", ToAssetName: "LBTC",
+	}, map[string]string{"min_price": "100000000"})
+	storeSentEvent(t, crawlerRepository, model.OutboxEvent{
+		TxHash: "0xfulfilled", EventType: "withdrawal_completed", BlockNumber: 300, LogIndex: 0,
+		Amount: "0.5", FromAssetName: "LBTCv", ToAssetName: "LBTC",
+	}, map[string]string{"offer_amount_spent": "50000000"})
+
+	// A live order no event backs, which the rebuild must drop
+	if err := orderRepository.UpsertOrder(model.Order{
+		OrderID: "stale", TxHash: "0xstale", BlockNumber: 50, TxDate: time.Now(),
+		TransferType: "deposit", Status: "completed", WalletAddress: TestWalletAddress,
+		Amount: "9", FromAssetName: "LBTC", ToAssetName: "LBTCv",
+	}); err != nil {
+		t.Fatalf("Failed to insert stale order: %v", err)
+	}
+
+	rebuilder := order_rebuilder.NewOrderRebuilder(db, "", "", logger, crawlerRepository, projectionRepository)
+
+	t.Run("DryRun", func(t *testing.T) {
+		if err := rebuilder.Rebuild(order_rebuilder.Options{Source: order_rebuilder.SourceOutbox, DryRun: true}); err != nil {
+			t.Fatalf("Dry run failed: %v", err)
+		}
+
+		if count, err := projectionRepository.CountOrders(repository.OrdersShadowTable); err != nil || count != 2 {
+			t.Errorf("Expected 2 orders in the shadow table, got %d (%v)", count, err)
+		}
+		if count, err := projectionRepository.CountOrders(repository.OrdersTable); err != nil || count != 1 {
+			t.Errorf("Expected the live table to be untouched, got %d orders (%v)", count, err)
+		}
+	})
+
+	t.Run("Swap", func(t *testing.T) {
+		if err := rebuilder.Rebuild(order_rebuilder.Options{Source: order_rebuilder.SourceOutbox}); err != nil {
+			t.Fatalf("Rebuild failed: %v", err)
+		}
+
+		if tableExists(t, db, repository.OrdersShadowTable) {
+			t.Errorf("Expected %s to be swapped away", repository.OrdersShadowTable)
+		}
+
+		orders, err := orderRepository.ListOrdersByWalletInChainOrder(TestWalletAddress)
+		if err != nil {
+			t.Fatalf("Failed to list orders: %v", err)
+		}
+		if len(orders) != 2 {
+			t.Fatalf("Expected 2 orders, got %+v", orders)
+		}
+
+		deposit, withdrawal := orders[0], orders[1]
+		if deposit.TxHash != "0xdeposit" || deposit.TransferType != "deposit" || deposit.Status != "completed" {
+			t.Errorf("Unexpected deposit order %+v", deposit)
+		}
+		if deposit.ShareAmount == nil || *deposit.ShareAmount != "1.5" {
+			t.Errorf("Expected deposit share amount 1.5, got %v", deposit.ShareAmount)
+		}
+		if withdrawal.TxHash != "0xrequest" || withdrawal.TransferType != "withdrawal" || withdrawal.Status != "completed" {
+			t.Errorf("Expected the withdrawal request to be completed, got %+v", withdrawal)
+		}
+
+		// The watermark was rebuilt with the orders
+		block, logIndex, found, err := orderRepository.GetWalletWatermark(TestWalletAddress)
+		if err != nil || !found || block != 300 || logIndex != 0 {
+			t.Errorf("Expected watermark 300/0, got %d/%d found=%t (%v)", block, logIndex, found, err)
+		}
+	})
+
+	t.Run("FailedReplayKeepsLiveTable", func(t *testing.T) {
+		// A deposit without its share amount cannot be materialized
+		storeSentEvent(t, crawlerRepository, model.OutboxEvent{
+			TxHash: "0xbroken", EventType: "deposit", BlockNumber: 400, LogIndex: 0,
+			Amount: "1", FromAssetName: "LBTC", ToAssetName: "LBTCv",
+		}, map[string]string{})
+
+		if err := rebuilder.Rebuild(order_rebuilder.Options{Source: order_rebuilder.SourceOutbox}); err == nil {
+			t.Fatal("Expected the rebuild to fail")
+		}
+
+		if tableExists(t, db, repository.OrdersShadowTable) {
+			t.Errorf("Expected %s to be dropped after the failed rebuild", repository.OrdersShadowTable)
+		}
+		if count, err := projectionRepository.CountOrders(repository.OrdersTable); err != nil || count != 2 {
+			t.Errorf("Expected the live table to keep 2 orders, got %d (%v)", count, err)
+		}
+	})
+}
+
+func TestRebuildKeepsBothSidesOfTransfers(t *testing.T) {
+	db := newTestDatabase(t)
+	logger := zap.NewNop()
+	crawlerRepository := repository.NewCrawlerRepository(db, logger)
+	projectionRepository := repository.NewOrderProjectionRepository(db, logger)
+	orderRepository := repository.NewOrderRepository(db, logger)
+
+	// A transfer between two monitored wallets stores an event for each side of the same log
+	const receiver = "0x00000000000000000000000000000000000000b2"
+	storeSentEvent(t, crawlerRepository, model.OutboxEvent{
+		TxHash: "0xtransfer", EventType: "transfer_out", BlockNumber: 100, LogIndex: 2,
+		Amount: "0.25", FromAssetName: "LBTCv", ToAssetName: "LBTCv",
+	}, map[string]string{})
+	storeSentEvent(t, crawlerRepository, model.OutboxEvent{
+		TxHash: "0xtransfer", EventType: "transfer_in", BlockNumber: 100, LogIndex: 2, Address: receiver,
+		Amount: "0.25", FromAssetName: "LBTCv", ToAssetName: "LBTCv",
+	}, map[string]string{})
+
+	// A request for 1 share that was only filled for 0.4 of them
+	storeSentEvent(t, crawlerRepository, model.OutboxEvent{
+		TxHash: "0xrequest", EventType: "withdrawal_requested", BlockNumber: 200, LogIndex: 0,
+		Amount: "1", FromAssetName: "LBTCv", ToAssetName: "LBTC",
+	}, map[string]string{"min_price": "100000000"})
+	storeSentEvent(t, crawlerRepository, model.OutboxEvent{
+		TxHash: "0xfulfilled", EventType: "withdrawal_completed", BlockNumber: 300, LogIndex: 0,
+		Amount: "0.4", FromAssetName: "LBTCv", ToAssetName: "LBTC",
+	}, map[string]string{"offer_amount_spent": "40000000"})
+
+	rebuilder := order_rebuilder.NewOrderRebuilder(db, "", "", logger, crawlerRepository, projectionRepository)
+	if err := rebuilder.Rebuild(order_rebuilder.Options{Source: order_rebuilder.SourceOutbox}); err != nil {
+		t.Fatalf("Rebuild failed: %v", err)
+	}
+
+	senderOrders, err := orderRepository.ListOrdersByWalletInChainOrder(TestWalletAddress)
+	if err != nil {
+		t.Fatalf("Failed to list sender orders: %v", err)
+	}
+	if len(senderOrders) != 2 || senderOrders[0].TransferType != "transfer_out" {
+		t.Fatalf("Expected the sender's transfer_out and withdrawal, got %+v", senderOrders)
+	}
+
+	receiverOrders, err := orderRepository.ListOrdersByWalletInChainOrder(receiver)
+	if err != nil {
+		t.Fatalf("Failed to list receiver orders: %v", err)
+	}
+	if len(receiverOrders) != 1 || receiverOrders[0].TransferType != "transfer_in" || receiverOrders[0].TxHash != "0xtransfer" {
+		t.Fatalf("Expected the receiver's transfer_in, got %+v", receiverOrders)
+	}
+
+	// The completed withdrawal records what was received, not the estimate for the whole request
+	withdrawal := senderOrders[1]
+	if withdrawal.Status != "completed" || withdrawal.EstimatedAmount == nil {
+		t.Fatalf("Expected a completed withdrawal with an amount received, got %+v", withdrawal)
+	}
+	received, ok := new(big.Rat).SetString(*withdrawal.EstimatedAmount)
+	if !ok || received.Cmp(big.NewRat(2, 5)) != 0 {
+		t.Errorf("Expected 0.4 received, got %s", *withdrawal.EstimatedAmount)
+	}
+	if withdrawal.ShareAmount == nil {
+		t.Fatal("Expected the shares spent to be recorded")
+	}
+	if spent, ok := new(big.Rat).SetString(*withdrawal.ShareAmount); !ok || spent.Cmp(big.NewRat(2, 5)) != 0 {
+		t.Errorf("Expected 0.4 shares spent, got %s", *withdrawal.ShareAmount)
+	}
+}
+
+func TestRebuildFromKafkaKeepsBothSidesOfTransfers(t *testing.T) {
+	db := newTestDatabase(t)
+	topic := newTestTopic(t, 2)
+	logger := zap.NewNop()
+	crawlerRepository := repository.NewCrawlerRepository(db, logger)
+	projectionRepository := repository.NewOrderProjectionRepository(db, logger)
+	orderRepository := repository.NewOrderRepository(db, logger)
+
+	const receiver = "0x00000000000000000000000000000000000000b2"
+	transferOut := storeSentEvent(t, crawlerRepository, model.OutboxEvent{
+		TxHash: "0xtransfer", EventType: "transfer_out", BlockNumber: 100, LogIndex: 2,
+		Amount: "0.25", FromAssetName: "LBTCv", ToAssetName: "LBTCv",
+	}, map[string]string{})
+	transferIn := storeSentEvent(t, crawlerRepository, model.OutboxEvent{
+		TxHash: "0xtransfer", EventType: "transfer_in", BlockNumber: 100, LogIndex: 2, Address: receiver,
+		Amount: "0.25", FromAssetName: "LBTCv", ToAssetName: "LBTCv",
+	}, map[string]string{})
+
+	// The sender's side is delivered twice, as the publisher may do after a retry
+	publishEvents(t, topic, transferOut, transferIn, transferOut)
+
+	rebuilder := order_rebuilder.NewOrderRebuilder(db, TestKafkaBroker, topic, logger, crawlerRepository, projectionRepository)
+	if err := rebuilder.Rebuild(order_rebuilder.Options{Source: order_rebuilder.SourceKafka, ToOffset: -1}); err != nil {
+		t.Fatalf("Rebuild failed: %v", err)
+	}
+
+	senderOrders, err := orderRepository.ListOrdersByWalletInChainOrder(TestWalletAddress)
+	if err != nil {
+		t.Fatalf("Failed to list sender orders: %v", err)
+	}
+	if len(senderOrders) != 1 || senderOrders[0].TransferType != "transfer_out" {
+		t.Errorf("Expected the sender's transfer_out once, got %+v", senderOrders)
+	}
+
+	receiverOrders, err := orderRepository.ListOrdersByWalletInChainOrder(receiver)
+	if err != nil {
+		t.Fatalf("Failed to list receiver orders: %v", err)
+	}
+	if len(receiverOrders) != 1 || receiverOrders[0].TransferType != "transfer_in" || receiverOrders[0].TxHash != "0xtransfer" {
+		t.Errorf("Expected the receiver's transfer_in, got %+v", receiverOrders)
+	}
+}
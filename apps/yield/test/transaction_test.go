@@ -0,0 +1,61 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"yield/apps/yield/internal/model"
+	"yield/apps/yield/internal/repository"
+)
+
+func TestSameNonceRelaysStayPendingUntilOneIsMined(t *testing.T) {
+	db := newTestDatabase(t)
+	transactionRepository := repository.NewTransactionRepository(db, zap.NewNop())
+
+	const vault = LBTCvTokenAddress
+	if err := transactionRepository.RecordBuiltTransaction(model.BuiltTransaction{
+		WalletAddress: TestWalletAddress, StepType: "deposit", ToAddress: vault, Data: "0x", ChainID: EthereumChainID,
+	}); err != nil {
+		t.Fatalf("Failed to record built transaction: %v", err)
+	}
+	built, err := transactionRepository.FindBuiltTransaction(TestWalletAddress, vault, "0x", EthereumChainID, time.Time{})
+	if err != nil || built == nil {
+		t.Fatalf("Failed to find built transaction: %v", err)
+	}
+
+	// A speed-up of the same transaction, either of which may be mined
+	for _, txHash := range []string{"0xoriginal", "0xspeedup"} {
+		if err := transactionRepository.CreateRelayedTransaction(model.RelayedTransaction{
+			TxHash: txHash, BuiltTransactionID: built.ID, WalletAddress: TestWalletAddress,
+			Nonce: 7, ToAddress: vault, StepType: "deposit",
+		}); err != nil {
+			t.Fatalf("Failed to relay %s: %v", txHash, err)
+		}
+	}
+
+	pending, err := transactionRepository.ListPendingRelayedTransactions(10)
+	if err != nil {
+		t.Fatalf("Failed to list pending transactions: %v", err)
+	}
+	if len(pending) != 2 {
+		t.Fatalf("Expected both relays to stay pending, got %+v", pending)
+	}
+
+	// The original is mined; the tracker resolves the speed-up as replaced afterwards
+	blockNumber := uint64(100)
+	if err := transactionRepository.ResolveRelayedTransaction("0xoriginal", model.TransactionConfirmed, &blockNumber, nil); err != nil {
+		t.Fatalf("Failed to confirm the original: %v", err)
+	}
+	if err := transactionRepository.ResolveRelayedTransaction("0xspeedup", model.TransactionReplaced, nil, nil); err != nil {
+		t.Fatalf("Failed to replace the speed-up: %v", err)
+	}
+
+	speedup, err := transactionRepository.GetRelayedTransaction("0xspeedup")
+	if err != nil || speedup == nil {
+		t.Fatalf("Failed to get the speed-up: %v", err)
+	}
+	if speedup.Status != model.TransactionReplaced || speedup.ReplacedBy == nil || *speedup.ReplacedBy != "0xoriginal" {
+		t.Errorf("Expected the speed-up to be replaced by the original, got %+v", speedup)
+	}
+}